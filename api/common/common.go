@@ -28,9 +28,19 @@ const (
 	HEADER_AUTH_SIGN             = "X-Pigeon-Auth-Sign"
 	HEADER_AUTH_TOKEN            = "X-Pigeon-Auth-Token"
 	HEADER_AUTH_TIMESTAMP        = "X-Pigeon-Auth-Timestamp"
+	HEADER_AUTH_API_KEY          = "X-Pigeon-Auth-Api-Key"
+	HEADER_AUTH_SIGN_VERSION     = "X-Pigeon-Auth-Sign-Version"
+	HEADER_AUTH_NONCE            = "X-Pigeon-Auth-Nonce"
+	HEADER_RATE_LIMIT_LIMIT      = "X-RateLimit-Limit"
+	HEADER_RATE_LIMIT_BURST      = "X-RateLimit-Burst"
+	HEADER_RATE_LIMIT_REMAINING  = "X-RateLimit-Remaining"
+	HEADER_RETRY_AFTER           = "Retry-After"
+	// set by server after the request authenticated
+	HEADER_AUTH_USER             = "X-Pigeon-Auth-User"
 	HEADER_CURVE_CONSOLE_VERSION = "X-Pigeon-Curve-Manager-Version"
 
 	HEADER_LOG_ENABLE   = "X-Pigeon-Log-Enable"
 	HEADER_LOG_USER     = "X-Pigeon-Log-User"
 	HEADER_LOG_CONTENT  = "X-Pigeon-Log-Content"
+	HEADER_LOG_API_KEY  = "X-Pigeon-Log-Api-Key"
 )
@@ -23,6 +23,8 @@
 package agent
 
 import (
+	"time"
+
 	metrics "github.com/opencurve/curve-manager/internal/metrics/core"
 	bsrpc "github.com/opencurve/curve-manager/internal/rpc/curvebs"
 	"github.com/opencurve/curve-manager/internal/snapshotclone"
@@ -36,11 +38,15 @@ const (
 
 	SYSTEM_ALERT_EXPIRATION_DAYS         = "system.alert.expiration.days"
 	DEFAULT_SYSTEM_ALERT_EXPIRATION_DAYS = 30
+
+	SYSTEM_ALERT_REPEAT_INTERVAL_SECONDS         = "system.alert.repeat_interval_seconds"
+	DEFAULT_SYSTEM_ALERT_REPEAT_INTERVAL_SECONDS = 4 * 60 * 60
 )
 
 var (
 	logExpirationDays   int
 	alertExpirationDays int
+	alertRepeatInterval time.Duration
 	systemLogChann      chan storage.Log
 	clusterAddrs        clusterServicesAddr
 	currentClusterId    int
@@ -56,14 +62,37 @@ func Init(cfg *pigeon.Configure, logger *pigeon.Logger) error {
 	if alertExpirationDays <= 0 {
 		alertExpirationDays = DEFAULT_SYSTEM_ALERT_EXPIRATION_DAYS
 	}
+	alertRepeatSeconds := cfg.GetConfig().GetInt(SYSTEM_ALERT_REPEAT_INTERVAL_SECONDS)
+	if alertRepeatSeconds <= 0 {
+		alertRepeatSeconds = DEFAULT_SYSTEM_ALERT_REPEAT_INTERVAL_SECONDS
+	}
+	alertRepeatInterval = time.Duration(alertRepeatSeconds) * time.Second
+
+	loginExpireSeconds := cfg.GetConfig().GetInt(LOGIN_EXPIRE_SECONDS)
+	if loginExpireSeconds <= 0 {
+		loginExpireSeconds = DEFAULT_LOGIN_EXPIRE_SECONDS
+	}
+	loginMaxLifetime := cfg.GetConfig().GetInt(LOGIN_MAX_LIFETIME)
+	if loginMaxLifetime <= 0 {
+		loginMaxLifetime = DEFAULT_LOGIN_MAX_LIFETIME
+	}
 
 	curveadm_service_addr = cfg.GetConfig().GetString(CURVEADM_SERVICE_ADDRESS)
+	enableVolumeGrant = cfg.GetConfig().GetBool(VOLUME_GRANT_ENABLE)
+	initLockout(cfg)
+	initWriteLock(cfg)
+	initRateLimit(cfg, logger)
+	if err := initNotification(cfg, logger); err != nil {
+		return err
+	}
 
 	// write system operation log
 	systemLogChann = make(chan storage.Log, 128)
 	go writeSystemLog(logger)
 	// clear expired logs
 	go clearExpiredSystemLog(logExpirationDays, logger)
+	// clear expired login sessions
+	go clearExpiredSession(loginExpireSeconds, loginMaxLifetime, logger)
 	return nil
 }
 
@@ -73,10 +73,21 @@ type AlertConf struct {
 	Rule       string   `json:"rule"`
 	Desc       string   `json:"desc"`
 	AlertUsers []string `json:"alertUsers"`
+	Webhooks   []string `json:"webhooks"`
+	// the fields below are only used by the custom rules
+	Custom    bool    `json:"custom"`
+	Creator   string  `json:"creator"`
+	Expr      string  `json:"expr"`
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"`
+	For       uint32  `json:"for"`
+	Summary   string  `json:"summary"`
 }
 
 var (
 	alertClients map[string]Alert = make(map[string]Alert)
+	// wake the updater to apply the changed alert conf immediately
+	alertConfWake = make(chan struct{}, 1)
 
 	updateAlertConfStopCtx = stopContext{
 		run:  false,
@@ -199,6 +210,31 @@ func (ctx *alertContext) setTimes(times uint32) {
 	ctx.opt.Times = times
 }
 
+// observe count the consecutive evaluations in which the condition holds,
+// returns whether the count reaches times and the alert should be firing
+func (ctx *alertContext) observe(holds bool) bool {
+	if !holds {
+		ctx.times = 0
+		return false
+	}
+	if ctx.times < ctx.getTimes() {
+		ctx.times++
+	}
+	return ctx.times >= ctx.getTimes()
+}
+
+func (ctx *alertContext) getOpt() storage.AlertConf {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	return ctx.opt
+}
+
+func (ctx *alertContext) setOpt(opt storage.AlertConf) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+	ctx.opt = opt
+}
+
 func (ctx *alertContext) getRule() string {
 	ctx.mutex.Lock()
 	defer ctx.mutex.Unlock()
@@ -246,9 +282,12 @@ type snapshotCloneServiceAlert struct {
 	ctx     alertContext
 }
 
+// chunkserverServiceAlert fires an alert for each chunkserver not online
 type chunkserverServiceAlert struct {
 	stopCtx stopContext
 	ctx     alertContext
+	// the consecutive evaluations each chunkserver is not online, keyed by endpoint
+	times map[string]uint32
 }
 
 func (alert *clusterAlert) stop() {
@@ -312,6 +351,11 @@ func initAlerts(expirationDays int, logger *pigeon.Logger) error {
 	}
 
 	for _, conf := range initConfs {
+		if conf.Custom {
+			alertClients[conf.Name] = newCustomAlert(conf)
+			go alertClients[conf.Name].check(logger)
+			continue
+		}
 		switch conf.Name {
 		case ALERT_CLUSTER:
 			alertClients[ALERT_CLUSTER] = &clusterAlert{
@@ -425,11 +469,17 @@ func clearExpiredAlert(expirationDays int, logger *pigeon.Logger) {
 			logger.Info("stop clearExpiredAlert thread")
 			return
 		case <-timer.C:
-			err := storage.DeleteAlert(currentClusterId, time.Now().AddDate(0, 0, -expirationDays).UnixMilli())
+			expiration := time.Now().AddDate(0, 0, -expirationDays).UnixMilli()
+			err := storage.DeleteAlert(currentClusterId, expiration)
 			if err != nil {
 				logger.Error("clear expired alert failed",
 					pigeon.Field("error", err))
 			}
+			err = storage.DeleteExpiredSilence(currentClusterId, expiration)
+			if err != nil {
+				logger.Error("clear expired alert silence failed",
+					pigeon.Field("error", err))
+			}
 			timer.Reset(CLEAR_ALERT_INTERVAL)
 		}
 	}
@@ -448,33 +498,66 @@ func updateAlertConf(logger *pigeon.Logger) {
 			updateAlertConfStopCtx.run = false
 			logger.Info("stop updateAlertConf thread")
 			return
+		case <-alertConfWake:
+			reloadAlertConf(logger)
 		case <-timer.C:
-			alertInfo, err := storage.GetAlertConf(currentClusterId)
-			if err != nil {
-				logger.Error("UpdateAlertConf get alert conf failed",
-					pigeon.Field("error", err),
-					pigeon.Field("requestId", ALERT_REQUEST_ID))
+			reloadAlertConf(logger)
+			timer.Reset(time.Duration(UPDATE_ALERT_CONF_INTERVAL_SEC) * time.Second)
+		}
+	}
+}
+
+// wakeAlertConf let the updater apply the changed alert conf without waiting for the next poll
+func wakeAlertConf() {
+	select {
+	case alertConfWake <- struct{}{}:
+	default:
+	}
+}
+
+func reloadAlertConf(logger *pigeon.Logger) {
+	alertInfo, err := storage.GetAlertConf(currentClusterId)
+	if err != nil {
+		logger.Error("UpdateAlertConf get alert conf failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", ALERT_REQUEST_ID))
+		return
+	}
+	customs := make(map[string]bool)
+	for _, conf := range alertInfo {
+		if conf.Custom {
+			customs[conf.Name] = true
+			if client, ok := alertClients[conf.Name].(*customAlert); ok {
+				client.ctx.setOpt(conf)
 			} else {
-				for _, conf := range alertInfo {
-					if _, ok := alertClients[conf.Name]; ok {
-						switch conf.Name {
-						case ALERT_CLUSTER:
-							updateConf(&alertClients[conf.Name].(*clusterAlert).ctx, &conf)
-						case ALERT_SPACE:
-							updateConf(&alertClients[conf.Name].(*spaceAlert).ctx, &conf)
-						case ALERT_ETCD:
-							updateConf(&alertClients[conf.Name].(*etcdServiceAlert).ctx, &conf)
-						case ALERT_MDS:
-							updateConf(&alertClients[conf.Name].(*mdsServiceAlert).ctx, &conf)
-						case ALERT_SNAPSHOT_CLONE_SERVER:
-							updateConf(&alertClients[conf.Name].(*snapshotCloneServiceAlert).ctx, &conf)
-						case ALERT_CHUNKSERVER:
-							updateConf(&alertClients[conf.Name].(*chunkserverServiceAlert).ctx, &conf)
-						}
-					}
-				}
+				alertClients[conf.Name] = newCustomAlert(conf)
+				go alertClients[conf.Name].check(logger)
 			}
-			timer.Reset(time.Duration(UPDATE_ALERT_CONF_INTERVAL_SEC) * time.Second)
+			continue
+		}
+		if _, ok := alertClients[conf.Name]; ok {
+			switch conf.Name {
+			case ALERT_CLUSTER:
+				updateConf(&alertClients[conf.Name].(*clusterAlert).ctx, &conf)
+			case ALERT_SPACE:
+				updateConf(&alertClients[conf.Name].(*spaceAlert).ctx, &conf)
+			case ALERT_ETCD:
+				updateConf(&alertClients[conf.Name].(*etcdServiceAlert).ctx, &conf)
+			case ALERT_MDS:
+				updateConf(&alertClients[conf.Name].(*mdsServiceAlert).ctx, &conf)
+			case ALERT_SNAPSHOT_CLONE_SERVER:
+				updateConf(&alertClients[conf.Name].(*snapshotCloneServiceAlert).ctx, &conf)
+			case ALERT_CHUNKSERVER:
+				updateConf(&alertClients[conf.Name].(*chunkserverServiceAlert).ctx, &conf)
+			}
+		}
+	}
+	// stop the checkers of the deleted custom rules and resolve their alerts
+	for name, client := range alertClients {
+		if _, ok := client.(*customAlert); ok && !customs[name] {
+			client.stop()
+			delete(alertClients, name)
+			updateAlertState(logger, 0, name, nil)
 		}
 	}
 }
@@ -486,24 +569,6 @@ func updateConf(ctx *alertContext, conf *storage.AlertConf) {
 	ctx.setTimes(conf.Times)
 }
 
-func handleAlert(level int, name string, duration uint32, summary string) error {
-	now := time.Now().UnixMilli()
-	alert := &storage.Alert{
-		ClusterId:   currentClusterId,
-		TimeMs:      now,
-		Level:       level,
-		Name:        name,
-		DurationSec: duration,
-		Summary:     summary,
-	}
-	err := storage.AddAlert(alert)
-	if err != nil {
-		return err
-	}
-	err = storage.SendAlert(currentClusterId, alert)
-	return err
-}
-
 func (alert *clusterAlert) check(logger *pigeon.Logger) {
 	logger.Info("start cluster alert checker",
 		pigeon.Field("clusterId", currentClusterId),
@@ -518,26 +583,23 @@ func (alert *clusterAlert) check(logger *pigeon.Logger) {
 			logger.Info("stop cluster alert thread")
 			return
 		case <-timer.C:
+			var observations []alertObservation
 			if alert.ctx.getEnable() {
 				status := GetClusterStatus(logger, ALERT_REQUEST_ID)
-				if !status.(ClusterStatus).Healthy {
-					alert.ctx.times++
-					if alert.ctx.times >= alert.ctx.getTimes() {
-						summary := "cluster is not healthy"
-						unhealthy := status.(ClusterStatus).CopysetNum.Unhealthy
-						if unhealthy > 0 {
-							summary = fmt.Sprintf("%s, unhealthy copysets number is %d", summary, unhealthy)
-						}
-						e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(), summary)
-						if e != nil {
-							logger.Error("handle cluster alert info failed",
-								pigeon.Field("error", e),
-								pigeon.Field("requestId", ALERT_REQUEST_ID))
-						}
-						alert.ctx.times = 0
+				unhealthy := !status.(ClusterStatus).Healthy
+				firing := alert.ctx.observe(unhealthy)
+				if unhealthy {
+					summary := "cluster is not healthy"
+					unhealthyNum := status.(ClusterStatus).CopysetNum.Unhealthy
+					if unhealthyNum > 0 {
+						summary = fmt.Sprintf("%s, unhealthy copysets number is %d", summary, unhealthyNum)
 					}
+					observations = append(observations, alertObservation{summary: summary, firing: firing})
 				}
+			} else {
+				alert.ctx.observe(false)
 			}
+			updateAlertState(logger, alert.ctx.opt.Level, alert.ctx.opt.Name, observations)
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
 	}
@@ -568,25 +630,44 @@ func (alert *spaceAlert) check(logger *pigeon.Logger) {
 							pigeon.Field("error", err))
 						limitPercent = CAPACITY_ALERT_LIMIT_PERCENT
 					}
+					var observations []alertObservation
+					firing := alert.ctx.observe(percent >= limitPercent)
 					if percent >= limitPercent {
-						alert.ctx.times++
-						if alert.ctx.times >= alert.ctx.getTimes() {
-							e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(),
-								fmt.Sprintf("Cluster space have alloced %d%%(alert trigger is %d%%)", percent, limitPercent))
-							if e != nil {
-								logger.Error("handle space alert falied",
-									pigeon.Field("error", e))
-							}
-							alert.ctx.times = 0
-						}
+						observations = append(observations, alertObservation{
+							summary: fmt.Sprintf("Cluster space have alloced %d%%(alert trigger is %d%%)", percent, limitPercent),
+							firing:  firing,
+						})
 					}
+					updateAlertState(logger, alert.ctx.opt.Level, alert.ctx.opt.Name, observations)
 				}
+			} else {
+				alert.ctx.observe(false)
+				updateAlertState(logger, alert.ctx.opt.Level, alert.ctx.opt.Name, nil)
 			}
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
 	}
 }
 
+// checkService evaluate the service alert of etcd, mds or snapshotcloneserver
+func checkService(ctx *alertContext, logger *pigeon.Logger) {
+	var observations []alertObservation
+	if ctx.getEnable() {
+		result := checkServiceHealthy(ctx.opt.Name)
+		unhealthy := !result.Result.(serviceStatus).healthy
+		firing := ctx.observe(unhealthy)
+		if unhealthy {
+			observations = append(observations, alertObservation{
+				summary: result.Result.(serviceStatus).detail,
+				firing:  firing,
+			})
+		}
+	} else {
+		ctx.observe(false)
+	}
+	updateAlertState(logger, ctx.opt.Level, ctx.opt.Name, observations)
+}
+
 func (alert *etcdServiceAlert) check(logger *pigeon.Logger) {
 	logger.Info("start etcd alert checker",
 		pigeon.Field("clusterId", currentClusterId),
@@ -601,22 +682,7 @@ func (alert *etcdServiceAlert) check(logger *pigeon.Logger) {
 			logger.Info("stop etcd alert thread")
 			return
 		case <-timer.C:
-			if alert.ctx.getEnable() {
-				result := checkServiceHealthy(alert.ctx.opt.Name)
-				if !result.Result.(serviceStatus).healthy {
-					alert.ctx.times++
-					if alert.ctx.times >= alert.ctx.getTimes() {
-						e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(),
-							result.Result.(serviceStatus).detail)
-						if e != nil {
-							logger.Error("handle service alert info failed",
-								pigeon.Field("error", e),
-								pigeon.Field("requestId", ALERT_REQUEST_ID))
-						}
-						alert.ctx.times = 0
-					}
-				}
-			}
+			checkService(&alert.ctx, logger)
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
 	}
@@ -636,22 +702,7 @@ func (alert *mdsServiceAlert) check(logger *pigeon.Logger) {
 			logger.Info("stop mds alert thread")
 			return
 		case <-timer.C:
-			if alert.ctx.getEnable() {
-				result := checkServiceHealthy(alert.ctx.opt.Name)
-				if !result.Result.(serviceStatus).healthy {
-					alert.ctx.times++
-					if alert.ctx.times >= alert.ctx.getTimes() {
-						e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(),
-							result.Result.(serviceStatus).detail)
-						if e != nil {
-							logger.Error("handle service alert info failed",
-								pigeon.Field("error", e),
-								pigeon.Field("requestId", ALERT_REQUEST_ID))
-						}
-						alert.ctx.times = 0
-					}
-				}
-			}
+			checkService(&alert.ctx, logger)
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
 	}
@@ -671,22 +722,7 @@ func (alert *snapshotCloneServiceAlert) check(logger *pigeon.Logger) {
 			logger.Info("stop snapshotclone alert thread")
 			return
 		case <-timer.C:
-			if alert.ctx.getEnable() {
-				result := checkServiceHealthy(alert.ctx.opt.Name)
-				if !result.Result.(serviceStatus).healthy {
-					alert.ctx.times++
-					if alert.ctx.times >= alert.ctx.getTimes() {
-						e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(),
-							result.Result.(serviceStatus).detail)
-						if e != nil {
-							logger.Error("handle service alert info failed",
-								pigeon.Field("error", e),
-								pigeon.Field("requestId", ALERT_REQUEST_ID))
-						}
-						alert.ctx.times = 0
-					}
-				}
-			}
+			checkService(&alert.ctx, logger)
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
 	}
@@ -710,20 +746,26 @@ func (alert *chunkserverServiceAlert) check(logger *pigeon.Logger) {
 				csStatus, err := GetChunkServerStatus(logger, ALERT_REQUEST_ID)
 				if err == errno.OK {
 					notOnline := csStatus.(*ChunkServerStatus).NotOnlines
-					if len(notOnline) > 0 {
-						alert.ctx.times++
-						if alert.ctx.times >= alert.ctx.getTimes() {
-							e := handleAlert(alert.ctx.opt.Level, alert.ctx.opt.Name, alert.ctx.getInterval()*alert.ctx.getTimes(),
-								fmt.Sprintf("chunkserver not online number = %d, notOnlines: %v", len(notOnline), notOnline))
-							if e != nil {
-								logger.Error("handle service alert info failed",
-									pigeon.Field("error", e),
-									pigeon.Field("requestId", ALERT_REQUEST_ID))
-							}
-							alert.ctx.times = 0
+					var observations []alertObservation
+					times := make(map[string]uint32)
+					for _, endpoint := range notOnline {
+						times[endpoint] = alert.times[endpoint]
+						if times[endpoint] < alert.ctx.getTimes() {
+							times[endpoint]++
 						}
+						observations = append(observations, alertObservation{
+							target: endpoint,
+							summary: fmt.Sprintf("chunkserver %s is not online, not online number = %d",
+								endpoint, len(notOnline)),
+							firing: times[endpoint] >= alert.ctx.getTimes(),
+						})
 					}
+					alert.times = times
+					updateAlertState(logger, alert.ctx.opt.Level, alert.ctx.opt.Name, observations)
 				}
+			} else {
+				alert.times = nil
+				updateAlertState(logger, alert.ctx.opt.Level, alert.ctx.opt.Name, nil)
 			}
 			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
 		}
@@ -731,11 +773,9 @@ func (alert *chunkserverServiceAlert) check(logger *pigeon.Logger) {
 }
 
 func GetUnreadSysAlertNum(r *pigeon.Request) (int64, errno.Errno) {
-	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
-	user := storage.GetLoginUserByToken(token)
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
 	if user == "" {
-		r.Logger().Error("GetUnreadSysAlertNum get user by token failed",
-			pigeon.Field("token", token),
+		r.Logger().Error("GetUnreadSysAlertNum get authenticated user failed",
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return 0, errno.GET_USER_FAILED
 	}
@@ -769,8 +809,7 @@ func GetUnreadSysAlertNum(r *pigeon.Request) (int64, errno.Errno) {
 }
 
 func UpdateReadSysAlertId(r *pigeon.Request, id int64) errno.Errno {
-	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
-	user := storage.GetLoginUserByToken(token)
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
 	err := storage.UpdateReadAlertId(id, user)
 	if err != nil {
 		r.Logger().Error("UpdateReadSysAlertId failed",
@@ -783,17 +822,25 @@ func UpdateReadSysAlertId(r *pigeon.Request, id int64) errno.Errno {
 	return errno.OK
 }
 
-func GetSysAlert(r *pigeon.Request, start, end int64, page, size uint32, name, level, content string) (interface{}, errno.Errno) {
+func GetSysAlert(r *pigeon.Request, start, end int64, page, size uint32, name, level, state, status, assignee,
+	content string) (interface{}, errno.Errno) {
+	if status != "" && status != storage.ALERT_STATUS_ACKNOWLEDGED && status != storage.ALERT_STATUS_UNACKNOWLEDGED {
+		return nil, errno.BAD_REQUEST_FORM_PARAM
+	}
 	if start == 0 && end == 0 {
 		end = time.Now().UnixMilli()
 	}
-	info, err := storage.GetAlert(currentClusterId, start, end, size, (page-1)*size, name, level, content)
+	info, err := storage.GetAlert(currentClusterId, start, end, size, (page-1)*size, name, level, state, status,
+		assignee, content)
 	if err != nil {
 		r.Logger().Error("GetAlert failed",
 			pigeon.Field("start", start),
 			pigeon.Field("end", end),
 			pigeon.Field("name", name),
 			pigeon.Field("level", level),
+			pigeon.Field("state", state),
+			pigeon.Field("status", status),
+			pigeon.Field("assignee", assignee),
 			pigeon.Field("content", content),
 			pigeon.Field("page", page),
 			pigeon.Field("size", size),
@@ -822,6 +869,14 @@ func GetAlertConf(r *pigeon.Request) (interface{}, errno.Errno) {
 				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 			return nil, errno.GET_ALERT_USER_FAILED
 		}
+		webhooks, err := storage.GetAlertWebhook(conf.ClusterId, conf.Name)
+		if err != nil {
+			r.Logger().Error("GetAlertWebhook failed",
+				pigeon.Field("alertName", conf.Name),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return nil, errno.GET_ALERT_WEBHOOK_FAILED
+		}
 		listConfs = append(listConfs, AlertConf{
 			Name:       conf.Name,
 			Level:      conf.LevelStr,
@@ -831,37 +886,59 @@ func GetAlertConf(r *pigeon.Request) (interface{}, errno.Errno) {
 			Rule:       conf.Rule,
 			Desc:       conf.Desc,
 			AlertUsers: users,
+			Webhooks:   webhooks,
+			Custom:     conf.Custom,
+			Creator:    conf.Creator,
+			Expr:       conf.Expr,
+			Op:         conf.Op,
+			Threshold:  conf.Threshold,
+			For:        conf.ForSec,
+			Summary:    conf.Summary,
 		})
 	}
 	return listConfs, errno.OK
 }
 
-func UpdateAlertConf(r *pigeon.Request, enable bool, interval, times uint32,
-	rule, name string, alertUsers []string) errno.Errno {
-	flag := 1
-	if !enable {
-		flag = 0
-	}
-	err := storage.UpdateAlertConf(&storage.AlertConf{
-		ClusterId: currentClusterId,
-		Interval:  interval,
-		Enable:    flag,
-		Times:     times,
-		Rule:      rule,
-		Name:      name,
-	})
+// UpdateAlertConf update the builtin alert or the custom rule,
+// only interval, times, enable and rule of the builtin alert are changeable
+func UpdateAlertConf(r *pigeon.Request, conf *storage.AlertConf, alertUsers []string) errno.Errno {
+	old, e := getAlertConf(r, conf.Name)
+	if e != errno.OK {
+		return e
+	}
+	conf.ClusterId = currentClusterId
+	conf.Enable = 0
+	if conf.EnableBool {
+		conf.Enable = 1
+	}
+	var err error
+	if old.Custom {
+		if e := checkAlertRuleOwner(r, &old); e != errno.OK {
+			return e
+		}
+		if e := checkAlertRule(r, conf); e != errno.OK {
+			return e
+		}
+		err = storage.UpdateCustomAlertConf(conf)
+	} else {
+		if conf.Interval == 0 || conf.Times == 0 || conf.Rule == "" {
+			return errno.INVALID_ALERT_RULE
+		}
+		err = storage.UpdateAlertConf(conf)
+	}
 	if err != nil {
 		r.Logger().Error("UpdateAlertConf failed",
-			pigeon.Field("name", name),
-			pigeon.Field("enable", enable),
-			pigeon.Field("interval", interval),
-			pigeon.Field("times", times),
-			pigeon.Field("rule", rule),
+			pigeon.Field("name", conf.Name),
+			pigeon.Field("enable", conf.EnableBool),
+			pigeon.Field("interval", conf.Interval),
+			pigeon.Field("times", conf.Times),
+			pigeon.Field("rule", conf.Rule),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.UPDATE_ALERT_CONF_FAILED
 	}
-	return UpdateAlertUser(r, name, alertUsers)
+	wakeAlertConf()
+	return UpdateAlertUser(r, conf.Name, alertUsers)
 }
 
 func GetAlertCandidate(r *pigeon.Request) (interface{}, errno.Errno) {
@@ -0,0 +1,169 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"database/sql"
+	"time"
+	"unicode/utf8"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	ALERT_NOTE_MAX_LENGTH = 4096
+)
+
+func checkAlertExist(r *pigeon.Request, id int64) errno.Errno {
+	_, err := storage.GetAlertById(currentClusterId, id)
+	if err == sql.ErrNoRows {
+		return errno.ALERT_NOT_EXIST
+	} else if err != nil {
+		r.Logger().Error("GetAlertById failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_SYSTEM_ALERT_FAILED
+	}
+	return errno.OK
+}
+
+// AckSysAlert acknowledge the alert by the current user with an optional note,
+// the firing alert acknowledged is not notified repeatedly
+func AckSysAlert(r *pigeon.Request, id int64, note string) errno.Errno {
+	if note != "" && utf8.RuneCountInString(note) > ALERT_NOTE_MAX_LENGTH {
+		return errno.INVALID_ALERT_NOTE
+	}
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	exist, err := storage.AckAlert(currentClusterId, id, user, time.Now().UnixMilli())
+	if err != nil {
+		r.Logger().Error("AckSysAlert failed",
+			pigeon.Field("id", id),
+			pigeon.Field("user", user),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.ACK_ALERT_FAILED
+	} else if !exist {
+		return errno.ALERT_NOT_EXIST
+	}
+	if note == "" {
+		return errno.OK
+	}
+	_, e := AddSysAlertNote(r, id, note)
+	return e
+}
+
+// UnackSysAlert clear the acknowledgement, so the firing alert is notified repeatedly again
+func UnackSysAlert(r *pigeon.Request, id int64) errno.Errno {
+	exist, err := storage.AckAlert(currentClusterId, id, "", 0)
+	if err != nil {
+		r.Logger().Error("UnackSysAlert failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.ACK_ALERT_FAILED
+	} else if !exist {
+		return errno.ALERT_NOT_EXIST
+	}
+	return errno.OK
+}
+
+// AssignSysAlert assign the alert to the user who is allowed to call method, which is the
+// method to acknowledge alerts, empty assignee means unassign
+func AssignSysAlert(r *pigeon.Request, id int64, assignee, method string) errno.Errno {
+	if assignee != "" {
+		user, err := storage.GetUser(assignee)
+		if err != nil {
+			r.Logger().Error("AssignSysAlert get user failed",
+				pigeon.Field("userName", assignee),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.GET_USER_FAILED
+		}
+		allowed, err := storage.IsMethodAllowed(&user, method)
+		if err != nil {
+			r.Logger().Error("AssignSysAlert get user roles failed",
+				pigeon.Field("userName", assignee),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.GET_USER_FAILED
+		} else if !allowed {
+			return errno.INVALID_ALERT_ASSIGNEE
+		}
+	}
+	exist, err := storage.AssignAlert(currentClusterId, id, assignee)
+	if err != nil {
+		r.Logger().Error("AssignSysAlert failed",
+			pigeon.Field("id", id),
+			pigeon.Field("assignee", assignee),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.ASSIGN_ALERT_FAILED
+	} else if !exist {
+		return errno.ALERT_NOT_EXIST
+	}
+	return errno.OK
+}
+
+// AddSysAlertNote add a note of the current user to the alert, returns the note id
+func AddSysAlertNote(r *pigeon.Request, id int64, content string) (int64, errno.Errno) {
+	if content == "" || utf8.RuneCountInString(content) > ALERT_NOTE_MAX_LENGTH {
+		return 0, errno.INVALID_ALERT_NOTE
+	}
+	if e := checkAlertExist(r, id); e != errno.OK {
+		return 0, e
+	}
+	note := &storage.AlertNote{
+		User:    r.HeadersIn[comm.HEADER_AUTH_USER],
+		TimeMs:  time.Now().UnixMilli(),
+		Content: content,
+	}
+	err := storage.AddAlertNote(id, note)
+	if err != nil {
+		r.Logger().Error("AddAlertNote failed",
+			pigeon.Field("id", id),
+			pigeon.Field("user", note.User),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return 0, errno.ADD_ALERT_NOTE_FAILED
+	}
+	return note.Id, errno.OK
+}
+
+func ListSysAlertNote(r *pigeon.Request, id int64) (interface{}, errno.Errno) {
+	if e := checkAlertExist(r, id); e != errno.OK {
+		return nil, e
+	}
+	notes, err := storage.ListAlertNote(id)
+	if err != nil {
+		r.Logger().Error("ListAlertNote failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_ALERT_NOTE_FAILED
+	}
+	return notes, errno.OK
+}
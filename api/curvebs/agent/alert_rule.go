@@ -0,0 +1,393 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	metricomm "github.com/opencurve/curve-manager/internal/metrics/common"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	ALERT_RULE_OP_GT = ">"
+	ALERT_RULE_OP_GE = ">="
+	ALERT_RULE_OP_LT = "<"
+	ALERT_RULE_OP_LE = "<="
+	ALERT_RULE_OP_EQ = "=="
+	ALERT_RULE_OP_NE = "!="
+
+	ALERT_RULE_NAME_MAX_LENGTH = 64
+
+	// fields of alertSample, e.g. {{ .Labels.instance }} or {{ printf "%.2f" .Value }}
+	ALERT_RULE_DEFAULT_SUMMARY = `{{ .Expr }} {{ .Op }} {{ .Threshold }}, value is {{ .Value }}` +
+		`{{ range $k, $v := .Labels }}, {{ $k }}={{ $v }}{{ end }}`
+	// so the summaries written for prometheus like {{ $labels.instance }} also work
+	ALERT_RULE_SUMMARY_PREFIX = `{{- $labels := .Labels -}}{{- $value := .Value -}}`
+
+	PROMETHEUS_RESULT_TYPE_VECTOR = "vector"
+	PROMETHEUS_STATUS_SUCCESS     = "success"
+)
+
+// alertSample is one series of the query result, which is also the data of the summary template
+type alertSample struct {
+	Name      string
+	Expr      string
+	Op        string
+	Threshold float64
+	Value     float64
+	Labels    map[string]string
+}
+
+// customAlert evaluates the promql expression of the user defined rule, each series whose value
+// satisfies the comparison is an alert instance, which fires after satisfied for ForSec seconds
+type customAlert struct {
+	stopCtx stopContext
+	ctx     alertContext
+	// the time since when the series satisfies the comparison, keyed by its labels
+	pending map[string]time.Time
+}
+
+func newCustomAlert(conf storage.AlertConf) *customAlert {
+	return &customAlert{
+		ctx: alertContext{
+			opt:   conf,
+			mutex: sync.Mutex{},
+			times: 0,
+		},
+		stopCtx: stopContext{
+			run:  false,
+			stop: make(chan bool, 1),
+		},
+		pending: make(map[string]time.Time),
+	}
+}
+
+func (alert *customAlert) stop() {
+	if alert.stopCtx.run {
+		alert.stopCtx.stop <- true
+	}
+}
+
+func (alert *customAlert) check(logger *pigeon.Logger) {
+	opt := alert.ctx.getOpt()
+	logger.Info("start custom alert checker",
+		pigeon.Field("clusterId", currentClusterId),
+		pigeon.Field("name", opt.Name),
+		pigeon.Field("interval seconds", opt.Interval))
+	alert.stopCtx.run = true
+	timer := time.NewTimer(time.Duration(alert.ctx.getInterval()) * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case <-alert.stopCtx.stop:
+			alert.stopCtx.run = false
+			logger.Info("stop custom alert thread",
+				pigeon.Field("name", opt.Name))
+			return
+		case <-timer.C:
+			if alert.ctx.getEnable() {
+				alert.evaluate(logger)
+			} else {
+				alert.pending = make(map[string]time.Time)
+				updateAlertState(logger, alert.ctx.getOpt().Level, opt.Name, nil)
+			}
+			timer.Reset(time.Duration(alert.ctx.getInterval()) * time.Second)
+		}
+	}
+}
+
+func (alert *customAlert) evaluate(logger *pigeon.Logger) {
+	opt := alert.ctx.getOpt()
+	samples, err := queryAlertRule(opt.Expr)
+	if err != nil {
+		logger.Error("evaluate custom alert rule failed",
+			pigeon.Field("name", opt.Name),
+			pigeon.Field("expr", opt.Expr),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", ALERT_REQUEST_ID))
+		return
+	}
+	now := time.Now()
+	pending := make(map[string]time.Time)
+	observations := []alertObservation{}
+	for _, sample := range samples {
+		if !compareAlertValue(sample.Value, opt.Op, opt.Threshold) {
+			continue
+		}
+		key := labelsKey(sample.Labels)
+		since, ok := alert.pending[key]
+		if !ok {
+			since = now
+		}
+		pending[key] = since
+		sample.Name = opt.Name
+		sample.Expr = opt.Expr
+		sample.Op = opt.Op
+		sample.Threshold = opt.Threshold
+		summary, err := renderAlertSummary(opt.Summary, &sample)
+		if err != nil {
+			logger.Error("render custom alert summary failed",
+				pigeon.Field("name", opt.Name),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", ALERT_REQUEST_ID))
+			summary, _ = renderAlertSummary("", &sample)
+		}
+		observations = append(observations, alertObservation{
+			target:  key,
+			summary: summary,
+			firing:  now.Sub(since) >= time.Duration(opt.ForSec)*time.Second,
+		})
+	}
+	alert.pending = pending
+	updateAlertState(logger, opt.Level, opt.Name, observations)
+}
+
+func queryAlertRule(expr string) ([]alertSample, error) {
+	results := make(chan metricomm.MetricResult, 1)
+	metricomm.QueryInstantMetric(url.QueryEscape(expr), &results)
+	res := <-results
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	vector := res.Result.(*metricomm.QueryResponseOfVector)
+	if vector.Status != PROMETHEUS_STATUS_SUCCESS {
+		return nil, fmt.Errorf("query status is %s", vector.Status)
+	}
+	if vector.Data.ResultType != PROMETHEUS_RESULT_TYPE_VECTOR {
+		return nil, fmt.Errorf("result type is %s, expect %s", vector.Data.ResultType, PROMETHEUS_RESULT_TYPE_VECTOR)
+	}
+	samples := []alertSample{}
+	for _, item := range vector.Data.Result {
+		if len(item.Value) != 2 {
+			continue
+		}
+		str, ok := item.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+		samples = append(samples, alertSample{
+			Value:  value,
+			Labels: item.Metric,
+		})
+	}
+	return samples, nil
+}
+
+func compareAlertValue(value float64, op string, threshold float64) bool {
+	switch op {
+	case ALERT_RULE_OP_GT:
+		return value > threshold
+	case ALERT_RULE_OP_GE:
+		return value >= threshold
+	case ALERT_RULE_OP_LT:
+		return value < threshold
+	case ALERT_RULE_OP_LE:
+		return value <= threshold
+	case ALERT_RULE_OP_EQ:
+		return value == threshold
+	case ALERT_RULE_OP_NE:
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+func isAlertRuleOp(op string) bool {
+	switch op {
+	case ALERT_RULE_OP_GT, ALERT_RULE_OP_GE, ALERT_RULE_OP_LT, ALERT_RULE_OP_LE, ALERT_RULE_OP_EQ, ALERT_RULE_OP_NE:
+		return true
+	default:
+		return false
+	}
+}
+
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%q,", k, labels[k])
+	}
+	return b.String()
+}
+
+func parseAlertSummary(summary string) (*template.Template, error) {
+	if summary == "" {
+		summary = ALERT_RULE_DEFAULT_SUMMARY
+	}
+	return template.New("summary").Option("missingkey=zero").Parse(ALERT_RULE_SUMMARY_PREFIX + summary)
+}
+
+func renderAlertSummary(summary string, sample *alertSample) (string, error) {
+	tmpl, err := parseAlertSummary(summary)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err = tmpl.Execute(&b, sample); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func isBuiltinAlert(name string) bool {
+	for _, conf := range defaultAlertConf {
+		if conf.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAlertRule validate the custom rule and fill its descriptive rule
+func checkAlertRule(r *pigeon.Request, conf *storage.AlertConf) errno.Errno {
+	var err error
+	switch {
+	case conf.Name == "" || len(conf.Name) > ALERT_RULE_NAME_MAX_LENGTH || isBuiltinAlert(conf.Name):
+		err = fmt.Errorf("invalid name")
+	case conf.Level == 0:
+		err = fmt.Errorf("invalid level")
+	case conf.Interval == 0:
+		err = fmt.Errorf("interval must be positive")
+	case strings.TrimSpace(conf.Expr) == "":
+		err = fmt.Errorf("empty expression")
+	case !isAlertRuleOp(conf.Op):
+		err = fmt.Errorf("invalid operator")
+	case math.IsNaN(conf.Threshold) || math.IsInf(conf.Threshold, 0):
+		err = fmt.Errorf("invalid threshold")
+	default:
+		_, err = parseAlertSummary(conf.Summary)
+	}
+	if err != nil {
+		r.Logger().Error("invalid custom alert rule",
+			pigeon.Field("name", conf.Name),
+			pigeon.Field("expr", conf.Expr),
+			pigeon.Field("op", conf.Op),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.INVALID_ALERT_RULE
+	}
+	conf.Times = 1
+	conf.Rule = fmt.Sprintf("%s %s %s", conf.Expr, conf.Op, strconv.FormatFloat(conf.Threshold, 'g', -1, 64))
+	if conf.ForSec > 0 {
+		conf.Rule = fmt.Sprintf("%s for %ds", conf.Rule, conf.ForSec)
+	}
+	return errno.OK
+}
+
+func getAlertConf(r *pigeon.Request, name string) (storage.AlertConf, errno.Errno) {
+	conf, err := storage.GetAlertConfByName(currentClusterId, name)
+	if err == sql.ErrNoRows {
+		return conf, errno.ALERT_CONF_NOT_EXIST
+	} else if err != nil {
+		r.Logger().Error("GetAlertConfByName failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return conf, errno.GET_ALERT_CONF_FAILED
+	}
+	return conf, errno.OK
+}
+
+// checkAlertRuleOwner only the creator of the custom rule and admin are permitted to change it
+func checkAlertRuleOwner(r *pigeon.Request, conf *storage.AlertConf) errno.Errno {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if conf.Creator != user && user != storage.USER_ADMIN_NAME {
+		r.Logger().Error("change custom alert rule failed, not the creator",
+			pigeon.Field("name", conf.Name),
+			pigeon.Field("creator", conf.Creator),
+			pigeon.Field("userName", user),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
+	return errno.OK
+}
+
+// CreateAlertConf add a custom rule, whose checker is started by the alert conf updater
+func CreateAlertConf(r *pigeon.Request, conf *storage.AlertConf, alertUsers []string) errno.Errno {
+	conf.ClusterId = currentClusterId
+	conf.Creator = r.HeadersIn[comm.HEADER_AUTH_USER]
+	conf.Enable = 0
+	if conf.EnableBool {
+		conf.Enable = 1
+	}
+	if e := checkAlertRule(r, conf); e != errno.OK {
+		return e
+	}
+	created, err := storage.CreateAlertConf(conf)
+	if err != nil {
+		r.Logger().Error("CreateAlertConf failed",
+			pigeon.Field("name", conf.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.CREATE_ALERT_CONF_FAILED
+	} else if !created {
+		return errno.ALERT_CONF_ALREADY_EXIST
+	}
+	wakeAlertConf()
+	return UpdateAlertUser(r, conf.Name, alertUsers)
+}
+
+// DeleteAlertConf delete the custom rule, the builtin ones can only be disabled
+func DeleteAlertConf(r *pigeon.Request, name string) errno.Errno {
+	conf, e := getAlertConf(r, name)
+	if e != errno.OK {
+		return e
+	}
+	if !conf.Custom {
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
+	if e := checkAlertRuleOwner(r, &conf); e != errno.OK {
+		return e
+	}
+	err := storage.DeleteCustomAlertConf(currentClusterId, name)
+	if err != nil {
+		r.Logger().Error("DeleteAlertConf failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_ALERT_CONF_FAILED
+	}
+	wakeAlertConf()
+	return errno.OK
+}
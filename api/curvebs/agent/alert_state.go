@@ -0,0 +1,201 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+// alertObservation is an instance of the rule whose condition holds in an evaluation
+type alertObservation struct {
+	// identify the instance in the rule, e.g. the labels of a series, empty if the whole cluster
+	target  string
+	summary string
+	// whether the condition holds long enough, otherwise the alert is pending
+	firing bool
+}
+
+func alertFingerprint(name, target string) string {
+	sum := sha256.Sum256([]byte(name + "\n" + target))
+	return hex.EncodeToString(sum[:8])
+}
+
+/*
+* updateAlertState merge the observations of an evaluation into the active alerts of the rule:
+* 1. an instance observed firstly starts a pending alert, or a firing one if it fires at once
+* 2. a pending alert turns firing when the observation fires, and is notified
+* 3. a firing alert is notified again every alertRepeatInterval instead of recorded again,
+*    unless it is acknowledged, which means someone is handling it
+* 4. a firing alert not acknowledged is escalated through the tiers of its escalation policy
+* 5. an active alert not observed any more is resolved and notified, or dropped if pending,
+*    and the escalated tiers are notified too
+* The silenced alert is recorded but not notified, so the firing one is notified once the
+* silence ends, and the resolved one is notified only if its firing is notified
+ */
+func updateAlertState(logger *pigeon.Logger, level int, name string, observations []alertObservation) {
+	active, err := storage.GetActiveAlert(currentClusterId, name)
+	if err != nil {
+		logger.Error("get active alert failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", ALERT_REQUEST_ID))
+		return
+	}
+	alerts := make(map[string]*storage.Alert)
+	for i := range active {
+		alerts[active[i].Fingerprint] = &active[i]
+	}
+	now := time.Now().UnixMilli()
+	checker := silenceChecker{logger: logger}
+	escalator := escalationChecker{logger: logger}
+	notified := false
+	for _, o := range observations {
+		fingerprint := alertFingerprint(name, o.target)
+		alert, exist := alerts[fingerprint]
+		if !exist {
+			alert = &storage.Alert{
+				ClusterId:   currentClusterId,
+				TimeMs:      now,
+				Name:        name,
+				Fingerprint: fingerprint,
+				Target:      o.target,
+				State:       storage.ALERT_STATE_PENDING,
+			}
+		}
+		delete(alerts, fingerprint)
+		alert.Level = level
+		alert.Summary = o.summary
+		alert.DurationSec = uint32((now - alert.TimeMs) / 1000)
+		notify := false
+		if o.firing && (alert.State == storage.ALERT_STATE_PENDING ||
+			time.Duration(now-alert.NotifyTimeMs)*time.Millisecond >= alertRepeatInterval) {
+			alert.State = storage.ALERT_STATE_FIRING
+			notify = alert.AckTimeMs == 0 && !checker.silenced(alert, time.UnixMilli(now))
+			if notify {
+				alert.NotifyTimeMs = now
+			}
+		}
+		var users, webhooks []string
+		if alert.State == storage.ALERT_STATE_FIRING {
+			if alert.FireTimeMs == 0 {
+				alert.FireTimeMs = now
+			}
+			if alert.AckTimeMs == 0 && !checker.silenced(alert, time.UnixMilli(now)) {
+				users, webhooks = escalator.escalate(alert, time.UnixMilli(now))
+			}
+			if len(users)+len(webhooks) > 0 {
+				alert.NotifyTimeMs = now
+			}
+		}
+		if exist {
+			err = storage.UpdateAlert(alert)
+		} else {
+			err = storage.AddAlert(alert)
+		}
+		if err == nil && notify {
+			notified = true
+			err = storage.SendAlert(currentClusterId, alert)
+		}
+		if err == nil && len(users)+len(webhooks) > 0 {
+			notified = true
+			err = storage.SendAlertTo(alert, users, webhooks)
+		}
+		if err != nil {
+			logger.Error("update alert state failed",
+				pigeon.Field("name", name),
+				pigeon.Field("target", o.target),
+				pigeon.Field("state", alert.State),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", ALERT_REQUEST_ID))
+		}
+	}
+
+	for _, alert := range alerts {
+		if alert.State == storage.ALERT_STATE_PENDING {
+			err = storage.RemoveAlert(alert.Id)
+		} else {
+			notify := alert.NotifyTimeMs > 0 && !checker.silenced(alert, time.UnixMilli(now))
+			alert.State = storage.ALERT_STATE_RESOLVED
+			alert.EndTimeMs = now
+			var users, webhooks []string
+			if notify {
+				alert.NotifyTimeMs = now
+				users, webhooks = escalator.escalated(alert, time.UnixMilli(now))
+			}
+			alert.DurationSec = uint32((now - alert.TimeMs) / 1000)
+			err = storage.UpdateAlert(alert)
+			if err == nil && notify {
+				notified = true
+				err = storage.SendAlert(currentClusterId, alert)
+			}
+			if err == nil && len(users)+len(webhooks) > 0 {
+				err = storage.SendAlertTo(alert, users, webhooks)
+			}
+		}
+		if err != nil {
+			logger.Error("resolve alert failed",
+				pigeon.Field("name", name),
+				pigeon.Field("target", alert.Target),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", ALERT_REQUEST_ID))
+		}
+	}
+	if notified {
+		wakeNotification()
+	}
+}
+
+// handleAlert record and notify an one-shot alert such as login lockout, which has no
+// condition to clear, so it is notified as firing and recorded as resolved at once
+func handleAlert(logger *pigeon.Logger, level int, name string, duration uint32, summary string) error {
+	now := time.Now().UnixMilli()
+	alert := &storage.Alert{
+		ClusterId:   currentClusterId,
+		TimeMs:      now,
+		Level:       level,
+		Name:        name,
+		DurationSec: duration,
+		Summary:     summary,
+		State:       storage.ALERT_STATE_RESOLVED,
+		EndTimeMs:   now,
+	}
+	checker := silenceChecker{logger: logger}
+	silenced := checker.silenced(alert, time.UnixMilli(now))
+	if !silenced {
+		alert.NotifyTimeMs = now
+	}
+	err := storage.AddAlert(alert)
+	if err != nil || silenced {
+		return err
+	}
+	alert.State = storage.ALERT_STATE_FIRING
+	alert.EndTimeMs = 0
+	err = storage.SendAlert(currentClusterId, alert)
+	wakeNotification()
+	return err
+}
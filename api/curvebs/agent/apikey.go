@@ -0,0 +1,131 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+type ApiKeyInfo struct {
+	storage.ApiKey
+	// the plaintext of key, only returned when created
+	Key string `json:"key"`
+}
+
+func isValidIPAllowlist(ips []string) bool {
+	for _, ip := range ips {
+		if strings.Contains(ip, "/") {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return false
+			}
+		} else if net.ParseIP(ip) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func CreateApiKey(r *pigeon.Request, name string, scopes []string, expireSeconds int64,
+	ips []string) (interface{}, errno.Errno) {
+	userName := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if !isValidIPAllowlist(ips) {
+		r.Logger().Error("CreateApiKey failed, invalid ip allowlist",
+			pigeon.Field("userName", userName),
+			pigeon.Field("ipAllowlist", ips),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.INVALID_IP_ALLOWLIST
+	}
+	key := storage.ApiKey{
+		UserName:    userName,
+		Name:        name,
+		Scopes:      scopes,
+		IPAllowlist: ips,
+	}
+	if expireSeconds > 0 {
+		key.ExpireTime = time.Now().Unix() + expireSeconds
+	}
+	plaintext, err := storage.CreateApiKey(&key)
+	if err != nil {
+		r.Logger().Error("CreateApiKey failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.CREATE_API_KEY_FAILED
+	}
+	return ApiKeyInfo{ApiKey: key, Key: plaintext}, errno.OK
+}
+
+// ListApiKey list the keys of the login user, admin can list all keys or filter by userName
+func ListApiKey(r *pigeon.Request, userName string) (interface{}, errno.Errno) {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if user != storage.USER_ADMIN_NAME {
+		userName = user
+	}
+	keys, err := storage.ListApiKey(userName)
+	if err != nil {
+		r.Logger().Error("ListApiKey failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_API_KEY_FAILED
+	}
+	return keys, errno.OK
+}
+
+// RevokeApiKey delete the key, only owner of the key and admin are permitted
+func RevokeApiKey(r *pigeon.Request, id string) errno.Errno {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	key, err := storage.GetApiKey(id)
+	if err != nil {
+		r.Logger().Error("RevokeApiKey get key failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_API_KEY_FAILED
+	}
+	if key.UserName != user && user != storage.USER_ADMIN_NAME {
+		r.Logger().Error("RevokeApiKey failed, not the owner",
+			pigeon.Field("id", id),
+			pigeon.Field("owner", key.UserName),
+			pigeon.Field("userName", user),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
+	err = storage.DeleteApiKey(id)
+	if err != nil {
+		r.Logger().Error("RevokeApiKey failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.REVOKE_API_KEY_FAILED
+	}
+	return errno.OK
+}
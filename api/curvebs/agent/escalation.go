@@ -0,0 +1,385 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+type OnCallSchedule struct {
+	storage.OnCallSchedule
+	// the user on call now
+	OnCall string `json:"onCall"`
+}
+
+// escalationChecker loads the escalation policies and on-call schedules once when the
+// first alert to escalate is checked
+type escalationChecker struct {
+	logger    *pigeon.Logger
+	loaded    bool
+	policies  []storage.EscalationPolicy
+	schedules map[string]*storage.OnCallSchedule
+}
+
+// load leaves no policy if the policies or schedules can not be loaded, so the alerts
+// are escalated in the next evaluation
+func (c *escalationChecker) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.schedules = make(map[string]*storage.OnCallSchedule)
+	policies, err := storage.ListEscalationPolicy(currentClusterId)
+	if err != nil {
+		c.logger.Error("list escalation policy failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", ALERT_REQUEST_ID))
+		return
+	}
+	schedules, err := storage.ListOnCallSchedule(currentClusterId)
+	if err != nil {
+		c.logger.Error("list on-call schedule failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", ALERT_REQUEST_ID))
+		return
+	}
+	for i := range schedules {
+		c.schedules[schedules[i].Name] = &schedules[i]
+	}
+	c.policies = policies
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		exist := false
+		for _, v := range list {
+			if v == item {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// recipients returns the users and webhooks of the tiers, including the on-call users at now
+func (c *escalationChecker) recipients(tiers []storage.EscalationTier, now time.Time) ([]string, []string) {
+	var users, webhooks []string
+	for _, tier := range tiers {
+		users = appendUnique(users, tier.Users...)
+		webhooks = appendUnique(webhooks, tier.Webhooks...)
+		if tier.Schedule == "" {
+			continue
+		}
+		if schedule, ok := c.schedules[tier.Schedule]; ok && schedule.OnCall(now) != "" {
+			users = appendUnique(users, schedule.OnCall(now))
+		}
+	}
+	return users, webhooks
+}
+
+// escalate advances alert.Escalation through the tiers whose delay elapsed since the alert
+// fires, and returns the recipients of the tiers newly escalated to
+func (c *escalationChecker) escalate(alert *storage.Alert, now time.Time) ([]string, []string) {
+	c.load()
+	policy := storage.MatchEscalationPolicy(c.policies, alert)
+	if policy == nil {
+		return nil, nil
+	}
+	from := alert.Escalation
+	elapsed := now.UnixMilli() - alert.FireTimeMs
+	for alert.Escalation < len(policy.Tiers) &&
+		elapsed >= int64(policy.Tiers[alert.Escalation].DelayMin)*time.Minute.Milliseconds() {
+		alert.Escalation++
+	}
+	if alert.Escalation <= from {
+		return nil, nil
+	}
+	return c.recipients(policy.Tiers[from:alert.Escalation], now)
+}
+
+// escalated returns the recipients of the tiers the alert escalated to, which are notified
+// when the alert resolves
+func (c *escalationChecker) escalated(alert *storage.Alert, now time.Time) ([]string, []string) {
+	if alert.Escalation == 0 {
+		return nil, nil
+	}
+	c.load()
+	policy := storage.MatchEscalationPolicy(c.policies, alert)
+	if policy == nil {
+		return nil, nil
+	}
+	n := alert.Escalation
+	if n > len(policy.Tiers) {
+		n = len(policy.Tiers)
+	}
+	return c.recipients(policy.Tiers[:n], now)
+}
+
+func getOnCallSchedule(r *pigeon.Request, name string) (storage.OnCallSchedule, errno.Errno) {
+	s, err := storage.GetOnCallSchedule(currentClusterId, name)
+	if err == sql.ErrNoRows {
+		return s, errno.ONCALL_NOT_EXIST
+	} else if err != nil {
+		r.Logger().Error("GetOnCallSchedule failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return s, errno.LIST_ONCALL_SCHEDULE_FAILED
+	}
+	return s, errno.OK
+}
+
+func checkUserExist(r *pigeon.Request, name string) errno.Errno {
+	if _, err := storage.GetUser(name); err != nil {
+		r.Logger().Error("check user exist failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_FAILED
+	}
+	return errno.OK
+}
+
+/*
+* checkEscalationPolicy check the policy and the recipients exist:
+* 1. the alert is optional, the policy escalates all the alerts of the level if not set
+* 2. the delays of the tiers are increasing, the first one is usually 0 to notify immediately
+* 3. each tier has at least one of users, webhooks and on-call schedule
+ */
+func checkEscalationPolicy(r *pigeon.Request, p *storage.EscalationPolicy) errno.Errno {
+	p.Level = storage.GetLevel(p.LevelStr)
+	if p.Name == "" || p.Level == 0 || len(p.Tiers) == 0 {
+		return errno.INVALID_ESCALATION_POLICY
+	}
+	if p.Alert != "" {
+		if _, e := getAlertConf(r, p.Alert); e != errno.OK {
+			return e
+		}
+	}
+	for i := range p.Tiers {
+		tier := &p.Tiers[i]
+		if i > 0 && tier.DelayMin <= p.Tiers[i-1].DelayMin {
+			return errno.INVALID_ESCALATION_POLICY
+		}
+		if len(tier.Users) == 0 && len(tier.Webhooks) == 0 && tier.Schedule == "" {
+			return errno.INVALID_ESCALATION_POLICY
+		}
+		if tier.Users == nil {
+			tier.Users = []string{}
+		}
+		if tier.Webhooks == nil {
+			tier.Webhooks = []string{}
+		}
+		for _, user := range tier.Users {
+			if e := checkUserExist(r, user); e != errno.OK {
+				return e
+			}
+		}
+		for _, webhook := range tier.Webhooks {
+			if _, e := getWebhook(r, webhook); e != errno.OK {
+				return e
+			}
+		}
+		if tier.Schedule != "" {
+			if _, e := getOnCallSchedule(r, tier.Schedule); e != errno.OK {
+				return e
+			}
+		}
+	}
+	return errno.OK
+}
+
+func ListEscalationPolicy(r *pigeon.Request) (interface{}, errno.Errno) {
+	policies, err := storage.ListEscalationPolicy(currentClusterId)
+	if err != nil {
+		r.Logger().Error("ListEscalationPolicy failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_ESCALATION_POLICY_FAILED
+	}
+	return policies, errno.OK
+}
+
+func CreateEscalationPolicy(r *pigeon.Request, p *storage.EscalationPolicy) errno.Errno {
+	if e := checkEscalationPolicy(r, p); e != errno.OK {
+		return e
+	}
+	p.ClusterId = currentClusterId
+	created, err := storage.CreateEscalationPolicy(p)
+	if err != nil {
+		r.Logger().Error("CreateEscalationPolicy failed",
+			pigeon.Field("name", p.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.CREATE_ESCALATION_POLICY_FAILED
+	} else if !created {
+		return errno.ESCALATION_ALREADY_EXIST
+	}
+	return errno.OK
+}
+
+func UpdateEscalationPolicy(r *pigeon.Request, p *storage.EscalationPolicy) errno.Errno {
+	if e := checkEscalationPolicy(r, p); e != errno.OK {
+		return e
+	}
+	p.ClusterId = currentClusterId
+	exist, err := storage.UpdateEscalationPolicy(p)
+	if err != nil {
+		r.Logger().Error("UpdateEscalationPolicy failed",
+			pigeon.Field("name", p.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_ESCALATION_POLICY_FAILED
+	} else if !exist {
+		return errno.ESCALATION_NOT_EXIST
+	}
+	return errno.OK
+}
+
+func DeleteEscalationPolicy(r *pigeon.Request, name string) errno.Errno {
+	exist, err := storage.DeleteEscalationPolicy(currentClusterId, name)
+	if err != nil {
+		r.Logger().Error("DeleteEscalationPolicy failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_ESCALATION_POLICY_FAILED
+	} else if !exist {
+		return errno.ESCALATION_NOT_EXIST
+	}
+	return errno.OK
+}
+
+// checkOnCallSchedule check the rotation, startTime defaults to now
+func checkOnCallSchedule(r *pigeon.Request, s *storage.OnCallSchedule) errno.Errno {
+	if s.Name == "" || len(s.Users) == 0 || s.RotationHours == 0 {
+		return errno.INVALID_ONCALL_SCHEDULE
+	}
+	for _, user := range s.Users {
+		if user == "" || strings.Contains(user, ",") {
+			return errno.INVALID_ONCALL_SCHEDULE
+		}
+		if e := checkUserExist(r, user); e != errno.OK {
+			return e
+		}
+	}
+	if s.StartTimeMs == 0 {
+		s.StartTimeMs = time.Now().UnixMilli()
+	}
+	return errno.OK
+}
+
+func ListOnCallSchedule(r *pigeon.Request) (interface{}, errno.Errno) {
+	schedules, err := storage.ListOnCallSchedule(currentClusterId)
+	if err != nil {
+		r.Logger().Error("ListOnCallSchedule failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_ONCALL_SCHEDULE_FAILED
+	}
+	now := time.Now()
+	list := []OnCallSchedule{}
+	for i := range schedules {
+		list = append(list, OnCallSchedule{
+			OnCallSchedule: schedules[i],
+			OnCall:         schedules[i].OnCall(now),
+		})
+	}
+	return list, errno.OK
+}
+
+func CreateOnCallSchedule(r *pigeon.Request, s *storage.OnCallSchedule) errno.Errno {
+	if e := checkOnCallSchedule(r, s); e != errno.OK {
+		return e
+	}
+	s.ClusterId = currentClusterId
+	created, err := storage.CreateOnCallSchedule(s)
+	if err != nil {
+		r.Logger().Error("CreateOnCallSchedule failed",
+			pigeon.Field("name", s.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.CREATE_ONCALL_SCHEDULE_FAILED
+	} else if !created {
+		return errno.ONCALL_ALREADY_EXIST
+	}
+	return errno.OK
+}
+
+func UpdateOnCallSchedule(r *pigeon.Request, s *storage.OnCallSchedule) errno.Errno {
+	if e := checkOnCallSchedule(r, s); e != errno.OK {
+		return e
+	}
+	s.ClusterId = currentClusterId
+	exist, err := storage.UpdateOnCallSchedule(s)
+	if err != nil {
+		r.Logger().Error("UpdateOnCallSchedule failed",
+			pigeon.Field("name", s.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_ONCALL_SCHEDULE_FAILED
+	} else if !exist {
+		return errno.ONCALL_NOT_EXIST
+	}
+	return errno.OK
+}
+
+// DeleteOnCallSchedule delete the schedule not used by any escalation policy
+func DeleteOnCallSchedule(r *pigeon.Request, name string) errno.Errno {
+	policies, err := storage.ListEscalationPolicy(currentClusterId)
+	if err != nil {
+		r.Logger().Error("ListEscalationPolicy failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.LIST_ESCALATION_POLICY_FAILED
+	}
+	for _, p := range policies {
+		for _, tier := range p.Tiers {
+			if tier.Schedule == name {
+				return errno.ONCALL_IN_USE
+			}
+		}
+	}
+	exist, err := storage.DeleteOnCallSchedule(currentClusterId, name)
+	if err != nil {
+		r.Logger().Error("DeleteOnCallSchedule failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_ONCALL_SCHEDULE_FAILED
+	} else if !exist {
+		return errno.ONCALL_NOT_EXIST
+	}
+	return errno.OK
+}
@@ -0,0 +1,207 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"path"
+	"strings"
+
+	"github.com/SeanHai/curve-go-rpc/rpc/curvebs"
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/snapshotclone"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	VOLUME_GRANT_ENABLE = "access.volume.enable_grant"
+
+	// "/teamA/*" is the same as "/teamA"
+	GRANT_DIR_WILDCARD = "/*"
+)
+
+var (
+	// whether volumes of non-admin users are restricted to the granted directories, default false
+	enableVolumeGrant bool
+)
+
+// volumeAccess is the grants of the login user, nil means no restriction
+type volumeAccess struct {
+	grants []storage.VolumeGrant
+}
+
+func getVolumeAccess(r *pigeon.Request) (*volumeAccess, error) {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if !enableVolumeGrant || user == storage.USER_ADMIN_NAME {
+		return nil, nil
+	}
+	grants, err := storage.ListVolumeGrant(user)
+	if err != nil {
+		return nil, err
+	}
+	return &volumeAccess{grants: grants}, nil
+}
+
+func isUnderDir(p, dir string) bool {
+	return dir == ROOT_DIR || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// allow reports whether p is in a granted directory, write requires read-write grant
+func (a *volumeAccess) allow(p string, write bool) bool {
+	if a == nil {
+		return true
+	}
+	p = path.Clean(p)
+	for _, grant := range a.grants {
+		if isUnderDir(p, grant.Dir) && (!write || grant.Mode == storage.GRANT_MODE_READ_WRITE) {
+			return true
+		}
+	}
+	return false
+}
+
+// visible reports whether p is in or on the way to a granted directory
+func (a *volumeAccess) visible(p string) bool {
+	if a.allow(p, false) {
+		return true
+	}
+	p = path.Clean(p)
+	for _, grant := range a.grants {
+		if isUnderDir(grant.Dir, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFile keep the files in dir which are visible
+func (a *volumeAccess) filterFile(dir string, files []curvebs.FileInfo) []curvebs.FileInfo {
+	if a.allow(dir, false) {
+		return files
+	}
+	visible := []curvebs.FileInfo{}
+	for _, file := range files {
+		if a.visible(path.Join(dir, file.FileName)) {
+			visible = append(visible, file)
+		}
+	}
+	return visible
+}
+
+// filterSnapshot keep the snapshots of readable volumes
+func (a *volumeAccess) filterSnapshot(snapshots []snapshotclone.SnapshotInfo) []snapshotclone.SnapshotInfo {
+	if a == nil {
+		return snapshots
+	}
+	readable := []snapshotclone.SnapshotInfo{}
+	for _, snapshot := range snapshots {
+		if a.allow(snapshot.File, false) {
+			readable = append(readable, snapshot)
+		}
+	}
+	return readable
+}
+
+// checkVolumeAccess check the login user is granted to access all the paths
+func checkVolumeAccess(r *pigeon.Request, write bool, paths ...string) errno.Errno {
+	access, err := getVolumeAccess(r)
+	if err != nil {
+		r.Logger().Error("checkVolumeAccess get volume grant failed",
+			pigeon.Field("userName", r.HeadersIn[comm.HEADER_AUTH_USER]),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_VOLUME_GRANT_FAILED
+	}
+	for _, p := range paths {
+		if !access.allow(p, write) {
+			r.Logger().Error("checkVolumeAccess failed, path not granted",
+				pigeon.Field("userName", r.HeadersIn[comm.HEADER_AUTH_USER]),
+				pigeon.Field("path", p),
+				pigeon.Field("write", write),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.VOLUME_ACCESS_DENIED
+		}
+	}
+	return errno.OK
+}
+
+func GrantVolume(r *pigeon.Request, userName, dir, mode string) errno.Errno {
+	dir = path.Clean(strings.TrimSuffix(dir, GRANT_DIR_WILDCARD))
+	if !path.IsAbs(dir) || (mode != storage.GRANT_MODE_READ_ONLY && mode != storage.GRANT_MODE_READ_WRITE) {
+		r.Logger().Error("GrantVolume failed, invalid dir or mode",
+			pigeon.Field("userName", userName),
+			pigeon.Field("dir", dir),
+			pigeon.Field("mode", mode),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.BAD_REQUEST_FORM_PARAM
+	}
+	if _, err := storage.GetUser(userName); err != nil {
+		r.Logger().Error("GrantVolume get user failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_FAILED
+	}
+	err := storage.AddVolumeGrant(&storage.VolumeGrant{
+		UserName: userName,
+		Dir:      dir,
+		Mode:     mode,
+	})
+	if err != nil {
+		r.Logger().Error("GrantVolume failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("dir", dir),
+			pigeon.Field("mode", mode),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.ADD_VOLUME_GRANT_FAILED
+	}
+	return errno.OK
+}
+
+func RevokeVolume(r *pigeon.Request, userName, dir string) errno.Errno {
+	dir = path.Clean(strings.TrimSuffix(dir, GRANT_DIR_WILDCARD))
+	err := storage.DeleteVolumeGrant(userName, dir)
+	if err != nil {
+		r.Logger().Error("RevokeVolume failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("dir", dir),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_VOLUME_GRANT_FAILED
+	}
+	return errno.OK
+}
+
+func ListVolumeGrant(r *pigeon.Request, userName string) (interface{}, errno.Errno) {
+	grants, err := storage.ListVolumeGrant(userName)
+	if err != nil {
+		r.Logger().Error("ListVolumeGrant failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_VOLUME_GRANT_FAILED
+	}
+	return grants, errno.OK
+}
@@ -0,0 +1,265 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	LOCKOUT_MAX_FAILURES    = "access.lockout.max_failures"
+	LOCKOUT_WINDOW_SECONDS  = "access.lockout.window_seconds"
+	LOCKOUT_DURATION        = "access.lockout.duration_seconds"
+	LOCKOUT_MAX_LOCKOUTS    = "access.lockout.max_lockouts"
+	LOCKOUT_IP_MAX_FAILURES = "access.lockout.ip_max_failures"
+	LOCKOUT_ENABLE_ALERT    = "access.lockout.enable_alert"
+
+	DEFAULT_LOCKOUT_WINDOW_SECONDS = 900
+	DEFAULT_LOCKOUT_DURATION       = 900
+
+	// lockouts are recorded as the operations of user module
+	LOCKOUT_LOG_MODULE = "user"
+	LOCKOUT_LOG_METHOD = "user.lockout"
+
+	ALERT_LOGIN = "login"
+
+	// purge the expired ip records when there are too many
+	MAX_IP_FAILURE_RECORDS = 10000
+)
+
+type ipFailure struct {
+	failures    int
+	windowStart int64
+	lockedUntil int64
+}
+
+var (
+	// failures of user in window lead to a temporary lockout, 0 means disabled
+	lockoutMaxFailures int
+	lockoutWindowSec   int64
+	lockoutDurationSec int64
+	// temporary lockouts without a successful login lead to a permanent lockout, 0 means never
+	lockoutMaxLockouts int
+	// failures of client ip in window lead to a throttling, 0 means disabled
+	lockoutIPMaxFailures int
+	lockoutEnableAlert   bool
+
+	lockoutMutex sync.Mutex
+	ipFailures   = map[string]*ipFailure{}
+)
+
+func initLockout(cfg *pigeon.Configure) {
+	lockoutMaxFailures = cfg.GetConfig().GetInt(LOCKOUT_MAX_FAILURES)
+	lockoutWindowSec = int64(cfg.GetConfig().GetInt(LOCKOUT_WINDOW_SECONDS))
+	if lockoutWindowSec <= 0 {
+		lockoutWindowSec = DEFAULT_LOCKOUT_WINDOW_SECONDS
+	}
+	lockoutDurationSec = int64(cfg.GetConfig().GetInt(LOCKOUT_DURATION))
+	if lockoutDurationSec <= 0 {
+		lockoutDurationSec = DEFAULT_LOCKOUT_DURATION
+	}
+	lockoutMaxLockouts = cfg.GetConfig().GetInt(LOCKOUT_MAX_LOCKOUTS)
+	lockoutIPMaxFailures = cfg.GetConfig().GetInt(LOCKOUT_IP_MAX_FAILURES)
+	lockoutEnableAlert = cfg.GetConfig().GetBool(LOCKOUT_ENABLE_ALERT)
+}
+
+func writeLockoutLog(r *pigeon.Request, name, method string, code errno.Errno, content string) {
+	WriteSystemLog(r.Context.ClientIP(), name, "", LOCKOUT_LOG_MODULE, method, code.Description(), content, code.Code())
+}
+
+func sendLockoutAlert(r *pigeon.Request, summary string) {
+	if !lockoutEnableAlert {
+		return
+	}
+	logger := r.Logger()
+	requestId := r.HeadersIn[comm.HEADER_REQUEST_ID]
+	go func() {
+		err := handleAlert(logger, storage.ALERT_WARNING, ALERT_LOGIN, 0, summary)
+		if err != nil {
+			logger.Error("send lockout alert failed",
+				pigeon.Field("summary", summary),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", requestId))
+		}
+	}()
+}
+
+// checkLoginAllowed reject the login from a throttled ip or to a locked user before verifying password
+func checkLoginAllowed(r *pigeon.Request, name string) errno.Errno {
+	now := time.Now().Unix()
+	ip := r.Context.ClientIP()
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+	if f, ok := ipFailures[ip]; ok && f.lockedUntil > now {
+		r.Logger().Error("Login failed, too many failures from ip",
+			pigeon.Field("userName", name),
+			pigeon.Field("ip", ip),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.LOGIN_IS_THROTTLED
+	}
+	lockout, err := storage.GetUserLockout(name)
+	if err != nil {
+		r.Logger().Error("Login get user lockout failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_LOCKOUT_FAILED
+	}
+	if lockout.Permanent || lockout.LockedUntil > now {
+		r.Logger().Error("Login failed, user is locked",
+			pigeon.Field("userName", name),
+			pigeon.Field("lockedUntil", lockout.LockedUntil),
+			pigeon.Field("permanent", lockout.Permanent),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.USER_IS_LOCKED
+	}
+	return errno.OK
+}
+
+func recordIPFailure(ip string, now int64) {
+	if lockoutIPMaxFailures <= 0 {
+		return
+	}
+	if len(ipFailures) >= MAX_IP_FAILURE_RECORDS {
+		for k, v := range ipFailures {
+			if v.windowStart+lockoutWindowSec < now && v.lockedUntil < now {
+				delete(ipFailures, k)
+			}
+		}
+	}
+	f, ok := ipFailures[ip]
+	if !ok || f.windowStart+lockoutWindowSec < now {
+		f = &ipFailure{windowStart: now}
+		ipFailures[ip] = f
+	}
+	f.failures++
+	if f.failures >= lockoutIPMaxFailures {
+		f.lockedUntil = now + lockoutDurationSec
+		f.failures = 0
+		f.windowStart = now
+	}
+}
+
+// recordLoginFailure count the failure of ip and the existing user, lock the user if too many
+func recordLoginFailure(r *pigeon.Request, name string, userExist bool) {
+	now := time.Now().Unix()
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+	recordIPFailure(r.Context.ClientIP(), now)
+	if !userExist || lockoutMaxFailures <= 0 {
+		return
+	}
+	lockout, err := storage.GetUserLockout(name)
+	if err != nil {
+		r.Logger().Error("recordLoginFailure get user lockout failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return
+	}
+	if lockout.WindowStart+lockoutWindowSec < now {
+		lockout.Failures = 0
+		lockout.WindowStart = now
+	}
+	lockout.Failures++
+	locked := false
+	if lockout.Failures >= lockoutMaxFailures {
+		locked = true
+		lockout.Failures = 0
+		lockout.Lockouts++
+		lockout.LockedUntil = now + lockoutDurationSec
+		// admin is only locked temporarily, otherwise nobody can unlock
+		if lockoutMaxLockouts > 0 && lockout.Lockouts >= lockoutMaxLockouts && name != storage.USER_ADMIN_NAME {
+			lockout.Permanent = true
+		}
+	}
+	err = storage.SetUserLockout(&lockout)
+	if err != nil {
+		r.Logger().Error("recordLoginFailure set user lockout failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return
+	}
+	if locked {
+		until := time.Unix(lockout.LockedUntil, 0).Format(time.RFC3339)
+		if lockout.Permanent {
+			until = "unlocked by admin"
+		}
+		summary := fmt.Sprintf("user %s is locked after %d failed logins from %s, until: %s",
+			name, lockoutMaxFailures, r.Context.ClientIP(), until)
+		r.Logger().Warn("user is locked",
+			pigeon.Field("userName", name),
+			pigeon.Field("lockouts", lockout.Lockouts),
+			pigeon.Field("permanent", lockout.Permanent),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		writeLockoutLog(r, name, LOCKOUT_LOG_METHOD, errno.USER_IS_LOCKED, summary)
+		sendLockoutAlert(r, summary)
+	}
+}
+
+// recordLoginSuccess clear the failures of user
+func recordLoginSuccess(r *pigeon.Request, name string) {
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+	err := storage.DeleteUserLockout(name)
+	if err != nil {
+		r.Logger().Warn("recordLoginSuccess delete user lockout failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	}
+}
+
+func ListLockedUser(r *pigeon.Request) (interface{}, errno.Errno) {
+	lockouts, err := storage.ListLockedUser(time.Now().Unix())
+	if err != nil {
+		r.Logger().Error("ListLockedUser failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_LOCKOUT_FAILED
+	}
+	return lockouts, errno.OK
+}
+
+func UnlockUser(r *pigeon.Request, name string) errno.Errno {
+	lockoutMutex.Lock()
+	err := storage.DeleteUserLockout(name)
+	lockoutMutex.Unlock()
+	if err != nil {
+		r.Logger().Error("UnlockUser failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_USER_LOCKOUT_FAILED
+	}
+	sendLockoutAlert(r, fmt.Sprintf("user %s is unlocked by %s", name, r.HeadersIn[comm.HEADER_AUTH_USER]))
+	return errno.OK
+}
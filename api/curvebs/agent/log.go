@@ -76,7 +76,7 @@ func writeSystemLog(logger *pigeon.Logger) {
 	}
 }
 
-func WriteSystemLog(ip, user, module, method, error_msg, content string, error_code int) {
+func WriteSystemLog(ip, user, apiKey, module, method, error_msg, content string, error_code int) {
 	timeMs := time.Now().UnixMilli()
 	logItem := storage.Log{
 		TimeMs:    timeMs,
@@ -87,6 +87,7 @@ func WriteSystemLog(ip, user, module, method, error_msg, content string, error_c
 		ErrorCode: error_code,
 		ErrorMsg:  error_msg,
 		Content:   content,
+		ApiKey:    apiKey,
 	}
 	systemLogChann <- logItem
 }
@@ -95,7 +96,7 @@ func GetSysLog(r *pigeon.Request, start, end int64, page, size uint32, filter st
 	if start == 0 && end == 0 {
 		end = time.Now().UnixMilli()
 	}
-	userName := storage.GetLoginUserByToken(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
+	userName := r.HeadersIn[comm.HEADER_AUTH_USER]
 	info, err := storage.GetSystemLog(start, end, size, (page-1)*size, filter, userName)
 	if err != nil {
 		r.Logger().Error("GetSysLog failed",
@@ -0,0 +1,249 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/email"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+/*
+* The notifications are persisted in the outbox before delivered:
+* 1. the dispatcher claims the due pending notifications and hands them to the workers
+* 2. a failed delivery is retried after base_seconds * 2^(attempts-1), at most max_seconds
+* 3. the notification is dead after max_attempts failed deliveries
+ */
+const (
+	NOTIFICATION_WORKERS         = "notification.workers"
+	NOTIFICATION_MAX_ATTEMPTS    = "notification.max_attempts"
+	NOTIFICATION_RETRY_BASE      = "notification.retry.base_seconds"
+	NOTIFICATION_RETRY_MAX       = "notification.retry.max_seconds"
+	NOTIFICATION_EXPIRATION_DAYS = "notification.expiration.days"
+
+	DEFAULT_NOTIFICATION_WORKERS         = 2
+	DEFAULT_NOTIFICATION_MAX_ATTEMPTS    = 8
+	DEFAULT_NOTIFICATION_RETRY_BASE      = 30
+	DEFAULT_NOTIFICATION_RETRY_MAX       = 3600
+	DEFAULT_NOTIFICATION_EXPIRATION_DAYS = 30
+
+	NOTIFICATION_POLL_INTERVAL  = 5 * time.Second
+	CLEAR_NOTIFICATION_INTERVAL = 1 * time.Hour
+	NOTIFICATION_CLAIM_BATCH    = 32
+)
+
+// notifier deliver the notification by its channel
+type notifier func(n *storage.Notification) error
+
+var (
+	notificationMaxAttempts int
+	notificationRetryBase   time.Duration
+	notificationRetryMax    time.Duration
+
+	notifiers = map[string]notifier{
+		storage.NOTIFICATION_CHANNEL_EMAIL:   sendEmailNotification,
+		storage.NOTIFICATION_CHANNEL_WEBHOOK: sendWebhookNotification,
+	}
+	notificationQueue chan storage.Notification
+	notificationWake  = make(chan struct{}, 1)
+)
+
+func initNotification(cfg *pigeon.Configure, logger *pigeon.Logger) error {
+	workers := cfg.GetConfig().GetInt(NOTIFICATION_WORKERS)
+	if workers <= 0 {
+		workers = DEFAULT_NOTIFICATION_WORKERS
+	}
+	notificationMaxAttempts = cfg.GetConfig().GetInt(NOTIFICATION_MAX_ATTEMPTS)
+	if notificationMaxAttempts <= 0 {
+		notificationMaxAttempts = DEFAULT_NOTIFICATION_MAX_ATTEMPTS
+	}
+	retryBase := cfg.GetConfig().GetInt(NOTIFICATION_RETRY_BASE)
+	if retryBase <= 0 {
+		retryBase = DEFAULT_NOTIFICATION_RETRY_BASE
+	}
+	notificationRetryBase = time.Duration(retryBase) * time.Second
+	retryMax := cfg.GetConfig().GetInt(NOTIFICATION_RETRY_MAX)
+	if retryMax <= 0 {
+		retryMax = DEFAULT_NOTIFICATION_RETRY_MAX
+	}
+	notificationRetryMax = time.Duration(retryMax) * time.Second
+	expirationDays := cfg.GetConfig().GetInt(NOTIFICATION_EXPIRATION_DAYS)
+	if expirationDays <= 0 {
+		expirationDays = DEFAULT_NOTIFICATION_EXPIRATION_DAYS
+	}
+
+	// the notifications being sent when stopped are sent again
+	if err := storage.ResetSendingNotification(); err != nil {
+		return err
+	}
+	notificationQueue = make(chan storage.Notification, workers)
+	for i := 0; i < workers; i++ {
+		go deliverNotification(logger)
+	}
+	go dispatchNotification(logger)
+	go clearExpiredNotification(expirationDays, logger)
+	return nil
+}
+
+// wakeNotification let the dispatcher deliver the new notifications without waiting for the next poll
+func wakeNotification() {
+	select {
+	case notificationWake <- struct{}{}:
+	default:
+	}
+}
+
+func sendEmailNotification(n *storage.Notification) error {
+	switch n.Kind {
+	case storage.NOTIFICATION_KIND_ALERT:
+		alert := email.Alert{}
+		if err := json.Unmarshal([]byte(n.Payload), &alert); err != nil {
+			return err
+		}
+		return email.SendAlert2Users(&alert, []string{n.Recipient})
+	}
+	return fmt.Errorf("unsupported notification kind: %s", n.Kind)
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := notificationRetryBase
+	for i := 1; i < attempts && delay < notificationRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > notificationRetryMax {
+		delay = notificationRetryMax
+	}
+	return delay
+}
+
+func dispatchNotification(logger *pigeon.Logger) {
+	logger.Info("start dispatch notification",
+		pigeon.Field("poll interval seconds", NOTIFICATION_POLL_INTERVAL.Seconds()))
+	timer := time.NewTimer(NOTIFICATION_POLL_INTERVAL)
+	defer timer.Stop()
+	for {
+		select {
+		case <-notificationWake:
+		case <-timer.C:
+			timer.Reset(NOTIFICATION_POLL_INTERVAL)
+		}
+		claimed, err := storage.ClaimNotification(time.Now().UnixMilli(), NOTIFICATION_CLAIM_BATCH)
+		if err != nil {
+			logger.Error("claim notification failed",
+				pigeon.Field("error", err))
+		}
+		for _, n := range claimed {
+			notificationQueue <- n
+		}
+		// there may be more due notifications
+		if len(claimed) == NOTIFICATION_CLAIM_BATCH {
+			wakeNotification()
+		}
+	}
+}
+
+func deliverNotification(logger *pigeon.Logger) {
+	for n := range notificationQueue {
+		var err error
+		if send, ok := notifiers[n.Channel]; ok {
+			err = send(&n)
+		} else {
+			err = fmt.Errorf("unsupported notification channel: %s", n.Channel)
+		}
+		now := time.Now()
+		n.Attempts++
+		n.UpdateTime = now.UnixMilli()
+		if err == nil {
+			n.State = storage.NOTIFICATION_STATE_DELIVERED
+			n.LastError = ""
+		} else if n.Attempts >= notificationMaxAttempts {
+			n.State = storage.NOTIFICATION_STATE_DEAD
+			n.LastError = err.Error()
+		} else {
+			n.State = storage.NOTIFICATION_STATE_PENDING
+			n.NextTime = now.Add(retryDelay(n.Attempts)).UnixMilli()
+			n.LastError = err.Error()
+		}
+		if err != nil {
+			logger.Warn("deliver notification failed",
+				pigeon.Field("id", n.Id),
+				pigeon.Field("channel", n.Channel),
+				pigeon.Field("recipient", n.Recipient),
+				pigeon.Field("attempts", n.Attempts),
+				pigeon.Field("state", n.State),
+				pigeon.Field("error", err))
+		}
+		if err := storage.UpdateNotification(&n); err != nil {
+			logger.Error("update notification failed",
+				pigeon.Field("id", n.Id),
+				pigeon.Field("state", n.State),
+				pigeon.Field("error", err))
+		}
+	}
+}
+
+func clearExpiredNotification(expirationDays int, logger *pigeon.Logger) {
+	logger.Info("start clear expired notification",
+		pigeon.Field("interval seconds", CLEAR_NOTIFICATION_INTERVAL.Seconds()),
+		pigeon.Field("expired days", expirationDays))
+	timer := time.NewTimer(CLEAR_NOTIFICATION_INTERVAL)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			err := storage.DeleteNotification(time.Now().AddDate(0, 0, -expirationDays).UnixMilli())
+			if err != nil {
+				logger.Error("clear expired notification failed",
+					pigeon.Field("error", err))
+			}
+			timer.Reset(CLEAR_NOTIFICATION_INTERVAL)
+		}
+	}
+}
+
+func ListNotification(r *pigeon.Request, start, end int64, page, size uint32, state, channel string) (interface{}, errno.Errno) {
+	if start == 0 && end == 0 {
+		end = time.Now().UnixMilli()
+	}
+	userName := r.HeadersIn[comm.HEADER_AUTH_USER]
+	info, err := storage.ListNotification(start, end, size, (page-1)*size, state, channel, userName)
+	if err != nil {
+		r.Logger().Error("ListNotification failed",
+			pigeon.Field("start", start),
+			pigeon.Field("end", end),
+			pigeon.Field("state", state),
+			pigeon.Field("channel", channel),
+			pigeon.Field("page", page),
+			pigeon.Field("size", size),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_NOTIFICATION_FAILED
+	}
+	return info, errno.OK
+}
@@ -0,0 +1,75 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/auth"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/pigeon"
+)
+
+type OIDCLoginInfo struct {
+	AuthURL string `json:"authUrl"`
+}
+
+// OIDCLogin returns the url of issuer which the browser should be redirected to
+func OIDCLogin(r *pigeon.Request) (interface{}, errno.Errno) {
+	if !auth.OIDCEnabled() {
+		return nil, errno.OIDC_NOT_ENABLED
+	}
+	url, err := auth.OIDCAuthURL()
+	if err != nil {
+		r.Logger().Error("OIDCLogin failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.OIDC_LOGIN_FAILED
+	}
+	return OIDCLoginInfo{AuthURL: url}, errno.OK
+}
+
+// OIDCCallback exchange the code for the user and issue a session token the same as Login
+func OIDCCallback(r *pigeon.Request, code, state string, readOnly bool) (interface{}, errno.Errno) {
+	if !auth.OIDCEnabled() {
+		return nil, errno.OIDC_NOT_ENABLED
+	}
+	userInfo, err := auth.OIDCAuthenticate(code, state)
+	if err != nil {
+		r.Logger().Error("OIDCCallback failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		switch err {
+		case auth.ErrInvalidOIDCState:
+			return nil, errno.INVALID_OIDC_STATE
+		case auth.ErrNoPermission:
+			return nil, errno.USER_HAS_NO_PERMISSION
+		case auth.ErrBackendNotEnabled:
+			return nil, errno.OPERATION_IS_NOT_PERMIT
+		default:
+			return nil, errno.OIDC_LOGIN_FAILED
+		}
+	}
+	// the user is only known after the code exchanged
+	r.HeadersIn[comm.HEADER_LOG_USER] = userInfo.UserName
+	return newSession(r, userInfo, readOnly)
+}
@@ -0,0 +1,307 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/pigeon"
+)
+
+/*
+* Token bucket rate limit of requests, the limits are checked in order:
+* 1. ip: all requests from the client ip, checked before authentication
+* 2. user: all requests of the user, the client ip for anonymous requests
+* 3. method: the requests of the method by the user, e.g. the expensive status.cluster
+ */
+const (
+	RATE_LIMIT_ENABLE          = "access.rate_limit.enable"
+	RATE_LIMIT_IP_PER_MINUTE   = "access.rate_limit.ip.per_minute"
+	RATE_LIMIT_IP_BURST        = "access.rate_limit.ip.burst"
+	RATE_LIMIT_USER_PER_MINUTE = "access.rate_limit.user.per_minute"
+	RATE_LIMIT_USER_BURST      = "access.rate_limit.user.burst"
+	RATE_LIMIT_METHODS         = "access.rate_limit.methods"
+
+	RATE_LIMIT_SCOPE_IP     = "ip"
+	RATE_LIMIT_SCOPE_USER   = "user"
+	RATE_LIMIT_SCOPE_METHOD = "method"
+
+	// forget the full buckets when there are too many
+	MAX_RATE_LIMIT_BUCKETS = 100000
+)
+
+type RateLimit struct {
+	Scope     string `json:"scope"`
+	Method    string `json:"method,omitempty"`
+	PerMinute int    `json:"perMinute"`
+	Burst     int    `json:"burst"`
+	// the requests can be sent now by the caller
+	Remaining int `json:"remaining"`
+}
+
+type RateLimitInfo struct {
+	Enable bool        `json:"enable"`
+	Limits []RateLimit `json:"limits"`
+}
+
+type rateLimitRule struct {
+	perMinute int
+	burst     int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// the bucket refilled to burst can be forgotten
+	full time.Time
+}
+
+var (
+	rateLimitEnable bool
+	// 0 per minute means not limited
+	ipRateLimit     rateLimitRule
+	userRateLimit   rateLimitRule
+	methodRateLimit = map[string]rateLimitRule{}
+
+	rateLimitMutex sync.Mutex
+	rateBuckets    = map[string]*tokenBucket{}
+)
+
+func newRateLimitRule(perMinute, burst int) rateLimitRule {
+	if burst <= 0 {
+		burst = 1
+	}
+	return rateLimitRule{perMinute: perMinute, burst: burst}
+}
+
+// parseMethodRateLimit parse "method=per_minute:burst", the burst is optional
+func parseMethodRateLimit(item string) (string, rateLimitRule, bool) {
+	kv := strings.SplitN(item, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return "", rateLimitRule{}, false
+	}
+	vs := strings.SplitN(kv[1], ":", 2)
+	perMinute, err := strconv.Atoi(strings.TrimSpace(vs[0]))
+	if err != nil || perMinute < 0 {
+		return "", rateLimitRule{}, false
+	}
+	burst := 0
+	if len(vs) == 2 {
+		burst, err = strconv.Atoi(strings.TrimSpace(vs[1]))
+		if err != nil {
+			return "", rateLimitRule{}, false
+		}
+	}
+	return strings.TrimSpace(kv[0]), newRateLimitRule(perMinute, burst), true
+}
+
+func initRateLimit(cfg *pigeon.Configure, logger *pigeon.Logger) {
+	rateLimitEnable = cfg.GetConfig().GetBool(RATE_LIMIT_ENABLE)
+	ipRateLimit = newRateLimitRule(cfg.GetConfig().GetInt(RATE_LIMIT_IP_PER_MINUTE),
+		cfg.GetConfig().GetInt(RATE_LIMIT_IP_BURST))
+	userRateLimit = newRateLimitRule(cfg.GetConfig().GetInt(RATE_LIMIT_USER_PER_MINUTE),
+		cfg.GetConfig().GetInt(RATE_LIMIT_USER_BURST))
+	for _, item := range cfg.GetConfig().GetStringArray(RATE_LIMIT_METHODS) {
+		method, rule, ok := parseMethodRateLimit(item)
+		if !ok {
+			logger.Warn("ignore invalid method rate limit", pigeon.Field("item", item))
+			continue
+		}
+		methodRateLimit[method] = rule
+	}
+}
+
+// refill returns the tokens of bucket at now, the bucket of a new key is full
+func (rule rateLimitRule) refill(b *tokenBucket, now time.Time) float64 {
+	if b == nil {
+		return float64(rule.burst)
+	}
+	tokens := b.tokens + now.Sub(b.last).Seconds()*float64(rule.perMinute)/60
+	return math.Min(tokens, float64(rule.burst))
+}
+
+// wait returns the seconds until the next token
+func (rule rateLimitRule) wait(tokens float64) int {
+	return int(math.Ceil((1 - tokens) * 60 / float64(rule.perMinute)))
+}
+
+func purgeFullBuckets(now time.Time) {
+	for k, b := range rateBuckets {
+		if now.After(b.full) {
+			delete(rateBuckets, k)
+		}
+	}
+}
+
+// take consume a token of key, returns the tokens left and whether allowed
+func (rule rateLimitRule) take(key string, now time.Time) (float64, bool) {
+	b := rateBuckets[key]
+	tokens := rule.refill(b, now)
+	if tokens < 1 {
+		return tokens, false
+	}
+	if b == nil {
+		if len(rateBuckets) >= MAX_RATE_LIMIT_BUCKETS {
+			purgeFullBuckets(now)
+		}
+		b = &tokenBucket{}
+		rateBuckets[key] = b
+	}
+	b.tokens = tokens - 1
+	b.last = now
+	b.full = now.Add(time.Duration((float64(rule.burst) - b.tokens) * 60 / float64(rule.perMinute) * float64(time.Second)))
+	return b.tokens, true
+}
+
+func rateLimitKey(scope, identity, method string) string {
+	return scope + "/" + identity + "/" + method
+}
+
+// setRateLimitHeaders expose the limit which is closest to be exceeded
+func setRateLimitHeaders(r *pigeon.Request, rule rateLimitRule, tokens float64) {
+	remaining := int(math.Max(math.Floor(tokens), 0))
+	if old, err := strconv.Atoi(r.HeadersOut[comm.HEADER_RATE_LIMIT_REMAINING]); err == nil && old < remaining {
+		return
+	}
+	r.HeadersOut[comm.HEADER_RATE_LIMIT_LIMIT] = strconv.Itoa(rule.perMinute)
+	r.HeadersOut[comm.HEADER_RATE_LIMIT_BURST] = strconv.Itoa(rule.burst)
+	r.HeadersOut[comm.HEADER_RATE_LIMIT_REMAINING] = strconv.Itoa(remaining)
+	if tokens < 1 {
+		r.HeadersOut[comm.HEADER_RETRY_AFTER] = strconv.Itoa(rule.wait(tokens))
+	}
+}
+
+// rateLimitCheck is a bucket which the request takes a token from
+type rateLimitCheck struct {
+	scope    string
+	identity string
+	method   string
+	rule     rateLimitRule
+}
+
+// checkRateLimit take a token from every bucket only if all of them have one,
+// so the request rejected by a limit is not charged to the others
+func checkRateLimit(r *pigeon.Request, checks []rateLimitCheck, now time.Time) errno.Errno {
+	for _, c := range checks {
+		if c.rule.perMinute <= 0 {
+			continue
+		}
+		tokens := c.rule.refill(rateBuckets[rateLimitKey(c.scope, c.identity, c.method)], now)
+		if tokens < 1 {
+			setRateLimitHeaders(r, c.rule, tokens)
+			r.Logger().Warn("request is rate limited",
+				pigeon.Field("scope", c.scope),
+				pigeon.Field("identity", c.identity),
+				pigeon.Field("method", c.method),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.RATE_LIMIT_EXCEEDED
+		}
+	}
+	for _, c := range checks {
+		if c.rule.perMinute <= 0 {
+			continue
+		}
+		tokens, _ := c.rule.take(rateLimitKey(c.scope, c.identity, c.method), now)
+		setRateLimitHeaders(r, c.rule, tokens)
+	}
+	return errno.OK
+}
+
+// rateLimitUser returns the identity of user buckets, the client ip for anonymous requests
+func rateLimitUser(r *pigeon.Request, user string) string {
+	if user == "" {
+		return RATE_LIMIT_SCOPE_IP + ":" + r.Context.ClientIP()
+	}
+	return user
+}
+
+// CheckIPRateLimit limit the requests from client ip before authentication
+func CheckIPRateLimit(r *pigeon.Request) errno.Errno {
+	if !rateLimitEnable {
+		return errno.OK
+	}
+	rateLimitMutex.Lock()
+	defer rateLimitMutex.Unlock()
+	return checkRateLimit(r, []rateLimitCheck{
+		{scope: RATE_LIMIT_SCOPE_IP, identity: r.Context.ClientIP(), rule: ipRateLimit},
+	}, time.Now())
+}
+
+// CheckUserRateLimit limit the requests of user and the method requested by user,
+// the anonymous requests are limited by client ip
+func CheckUserRateLimit(r *pigeon.Request, user, method string) errno.Errno {
+	if !rateLimitEnable {
+		return errno.OK
+	}
+	user = rateLimitUser(r, user)
+	rateLimitMutex.Lock()
+	defer rateLimitMutex.Unlock()
+	return checkRateLimit(r, []rateLimitCheck{
+		{scope: RATE_LIMIT_SCOPE_USER, identity: user, rule: userRateLimit},
+		{scope: RATE_LIMIT_SCOPE_METHOD, identity: user, method: method, rule: methodRateLimit[method]},
+	}, time.Now())
+}
+
+// GetRateLimit returns the limits and how many requests the caller can send now
+func GetRateLimit(r *pigeon.Request) (interface{}, errno.Errno) {
+	info := RateLimitInfo{
+		Enable: rateLimitEnable,
+		Limits: []RateLimit{},
+	}
+	user := rateLimitUser(r, r.HeadersIn[comm.HEADER_AUTH_USER])
+	ip := r.Context.ClientIP()
+	now := time.Now()
+	rateLimitMutex.Lock()
+	defer rateLimitMutex.Unlock()
+	add := func(scope, identity, method string, rule rateLimitRule) {
+		if rule.perMinute <= 0 {
+			return
+		}
+		tokens := rule.refill(rateBuckets[rateLimitKey(scope, identity, method)], now)
+		info.Limits = append(info.Limits, RateLimit{
+			Scope:     scope,
+			Method:    method,
+			PerMinute: rule.perMinute,
+			Burst:     rule.burst,
+			Remaining: int(math.Max(math.Floor(tokens), 0)),
+		})
+	}
+	add(RATE_LIMIT_SCOPE_IP, ip, "", ipRateLimit)
+	add(RATE_LIMIT_SCOPE_USER, user, "", userRateLimit)
+	methods := []string{}
+	for method := range methodRateLimit {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		add(RATE_LIMIT_SCOPE_METHOD, user, method, methodRateLimit[method])
+	}
+	return info, errno.OK
+}
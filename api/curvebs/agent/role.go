@@ -0,0 +1,92 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+func ListRole(r *pigeon.Request) (interface{}, errno.Errno) {
+	return storage.ListRole(), errno.OK
+}
+
+func CreateRole(r *pigeon.Request, name string, methods []string) errno.Errno {
+	err := storage.CreateRole(name, methods)
+	if err != nil {
+		r.Logger().Error("CreateRole failed",
+			pigeon.Field("name", name),
+			pigeon.Field("methods", methods),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.CREATE_ROLE_FAILED
+	}
+	return errno.OK
+}
+
+func UpdateRole(r *pigeon.Request, name string, methods []string) errno.Errno {
+	err := storage.UpdateRole(name, methods)
+	if err != nil {
+		r.Logger().Error("UpdateRole failed",
+			pigeon.Field("name", name),
+			pigeon.Field("methods", methods),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_ROLE_FAILED
+	}
+	return errno.OK
+}
+
+func DeleteRole(r *pigeon.Request, name string) errno.Errno {
+	err := storage.DeleteRole(name)
+	if err != nil {
+		r.Logger().Error("DeleteRole failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_ROLE_FAILED
+	}
+	return errno.OK
+}
+
+func UpdateUserRole(r *pigeon.Request, name string, roles []string) errno.Errno {
+	if _, err := storage.GetUser(name); err != nil {
+		r.Logger().Error("UpdateUserRole get user failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_FAILED
+	}
+	err := storage.UpdateUserRoles(name, roles)
+	if err != nil {
+		r.Logger().Error("UpdateUserRole failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("roles", roles),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_ROLE_FAILED
+	}
+	return errno.OK
+}
@@ -0,0 +1,136 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"encoding/json"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+type KickWriteUserInfo struct {
+	// empty if there is no write user logined
+	UserName string `json:"userName"`
+}
+
+// sessionLogContent is the audit content of a revocation, which records whose session is revoked
+type sessionLogContent struct {
+	Id       string `json:"id"`
+	UserName string `json:"userName"`
+}
+
+func setSessionLogContent(r *pigeon.Request, id, name string) {
+	c, _ := json.Marshal(sessionLogContent{Id: id, UserName: name})
+	r.HeadersIn[comm.HEADER_LOG_CONTENT] = string(c)
+}
+
+// isSessionManager reports whether the login user manages the sessions of others, which is
+// the user with MANAGER permission or the methods of the built-in manager role
+func isSessionManager(r *pigeon.Request, user string) (bool, errno.Errno) {
+	userInfo, err := storage.GetUser(user)
+	if err != nil {
+		r.Logger().Error("get session user failed",
+			pigeon.Field("userName", user),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false, errno.GET_USER_FAILED
+	}
+	manager, err := storage.IsManagerUser(&userInfo)
+	if err != nil {
+		r.Logger().Error("get session user roles failed",
+			pigeon.Field("userName", user),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false, errno.GET_USER_ROLE_FAILED
+	}
+	return manager, errno.OK
+}
+
+// ListSession list the sessions of the login user, manager can list all sessions or filter by userName
+func ListSession(r *pigeon.Request, userName string) (interface{}, errno.Errno) {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	manager, e := isSessionManager(r, user)
+	if e != errno.OK {
+		return nil, e
+	}
+	if !manager {
+		userName = user
+	}
+	return storage.ListSession(userName), errno.OK
+}
+
+// RevokeSession delete the session, only owner of the session and manager are permitted
+func RevokeSession(r *pigeon.Request, id string) errno.Errno {
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	session, ok := storage.GetSession(id)
+	if !ok {
+		r.Logger().Error("RevokeSession failed, session not exist",
+			pigeon.Field("id", id),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.SESSION_NOT_EXIST
+	}
+	if session.UserName != user {
+		manager, e := isSessionManager(r, user)
+		if e != errno.OK {
+			return e
+		} else if !manager {
+			r.Logger().Error("RevokeSession failed, not the owner",
+				pigeon.Field("id", id),
+				pigeon.Field("owner", session.UserName),
+				pigeon.Field("userName", user),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.OPERATION_IS_NOT_PERMIT
+		}
+	}
+	setSessionLogContent(r, id, session.UserName)
+	err := storage.DeleteSession(id)
+	if err != nil {
+		r.Logger().Error("RevokeSession failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_USER_SESSION_FAILED
+	}
+	return errno.OK
+}
+
+// KickWriteUser revoke the session of the logined write user, so that another write user can login
+func KickWriteUser(r *pigeon.Request) (interface{}, errno.Errno) {
+	name := storage.GetLoginWriteUser()
+	if name == "" {
+		return KickWriteUserInfo{}, errno.OK
+	}
+	setSessionLogContent(r, "", name)
+	err := storage.Logout(name)
+	if err != nil {
+		r.Logger().Error("KickWriteUser failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.DELETE_USER_SESSION_FAILED
+	}
+	return KickWriteUserInfo{UserName: name}, errno.OK
+}
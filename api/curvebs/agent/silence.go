@@ -0,0 +1,195 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+type AlertSilence struct {
+	storage.Silence
+	// "HH:MM" of the maintenance window, empty if not recurring
+	WindowStart string `json:"windowStart"`
+	WindowEnd   string `json:"windowEnd"`
+	Active      bool   `json:"active"`
+}
+
+// silenceChecker loads the silences once when the first alert to notify is checked
+type silenceChecker struct {
+	logger   *pigeon.Logger
+	loaded   bool
+	silences []storage.Silence
+}
+
+// silenced reports whether the notification of alert is suppressed, the alert is
+// notified if the silences can not be loaded
+func (c *silenceChecker) silenced(alert *storage.Alert, now time.Time) bool {
+	if !c.loaded {
+		c.loaded = true
+		silences, err := storage.ListSilence(currentClusterId)
+		if err != nil {
+			c.logger.Error("list alert silence failed",
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", ALERT_REQUEST_ID))
+		}
+		c.silences = silences
+	}
+	return storage.IsSilenced(c.silences, alert, now)
+}
+
+func toAlertSilence(s *storage.Silence, now time.Time) AlertSilence {
+	silence := AlertSilence{
+		Silence: *s,
+		Active:  s.IsActive(now),
+	}
+	if s.IsRecurring() {
+		silence.WindowStart = storage.FormatClock(s.WindowStartMin)
+		silence.WindowEnd = storage.FormatClock(s.WindowEndMin)
+	}
+	return silence
+}
+
+// checkSilence validate the silence and parse the level and maintenance window
+func checkSilence(r *pigeon.Request, s *storage.Silence, windowStart, windowEnd string) errno.Errno {
+	var err error
+	s.Level = storage.GetLevel(s.LevelStr)
+	if s.StartTimeMs == 0 {
+		s.StartTimeMs = time.Now().UnixMilli()
+	}
+	for i := range s.Weekdays {
+		s.Weekdays[i] = strings.ToLower(s.Weekdays[i])
+	}
+	switch {
+	case s.Name == "" && s.LevelStr == "" && s.Target == "":
+		err = fmt.Errorf("at least one matcher is required")
+	case s.LevelStr != "" && s.Level == 0:
+		err = fmt.Errorf("invalid level")
+	case s.StartTimeMs < 0 || (s.EndTimeMs != 0 && s.EndTimeMs <= s.StartTimeMs):
+		err = fmt.Errorf("end time must be after start time")
+	case !s.IsRecurring() && s.EndTimeMs == 0:
+		err = fmt.Errorf("end time is required if not recurring")
+	}
+	if err == nil && s.IsRecurring() {
+		for _, w := range s.Weekdays {
+			if !storage.IsWeekday(w) {
+				err = fmt.Errorf("invalid weekday %s", w)
+			}
+		}
+		if err == nil {
+			s.WindowStartMin, err = storage.ParseClock(windowStart)
+		}
+		if err == nil {
+			s.WindowEndMin, err = storage.ParseClock(windowEnd)
+		}
+		if err == nil && s.WindowStartMin == s.WindowEndMin {
+			err = fmt.Errorf("empty maintenance window")
+		}
+	}
+	if err != nil {
+		r.Logger().Error("invalid alert silence",
+			pigeon.Field("name", s.Name),
+			pigeon.Field("level", s.LevelStr),
+			pigeon.Field("target", s.Target),
+			pigeon.Field("weekdays", s.Weekdays),
+			pigeon.Field("window", windowStart+"-"+windowEnd),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.INVALID_ALERT_SILENCE
+	}
+	return errno.OK
+}
+
+func ListAlertSilence(r *pigeon.Request) (interface{}, errno.Errno) {
+	silences, err := storage.ListSilence(currentClusterId)
+	if err != nil {
+		r.Logger().Error("ListAlertSilence failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_ALERT_SILENCE_FAILED
+	}
+	now := time.Now()
+	list := []AlertSilence{}
+	for i := range silences {
+		list = append(list, toAlertSilence(&silences[i], now))
+	}
+	return list, errno.OK
+}
+
+// CreateAlertSilence returns the id of the new silence
+func CreateAlertSilence(r *pigeon.Request, s *storage.Silence, windowStart, windowEnd string) (interface{}, errno.Errno) {
+	if e := checkSilence(r, s, windowStart, windowEnd); e != errno.OK {
+		return nil, e
+	}
+	s.ClusterId = currentClusterId
+	s.Creator = r.HeadersIn[comm.HEADER_AUTH_USER]
+	s.CreateTimeMs = time.Now().UnixMilli()
+	id, err := storage.CreateSilence(s)
+	if err != nil {
+		r.Logger().Error("CreateAlertSilence failed",
+			pigeon.Field("name", s.Name),
+			pigeon.Field("target", s.Target),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.CREATE_ALERT_SILENCE_FAILED
+	}
+	return id, errno.OK
+}
+
+func UpdateAlertSilence(r *pigeon.Request, s *storage.Silence, windowStart, windowEnd string) errno.Errno {
+	if e := checkSilence(r, s, windowStart, windowEnd); e != errno.OK {
+		return e
+	}
+	s.ClusterId = currentClusterId
+	exist, err := storage.UpdateSilence(s)
+	if err != nil {
+		r.Logger().Error("UpdateAlertSilence failed",
+			pigeon.Field("id", s.Id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_ALERT_SILENCE_FAILED
+	} else if !exist {
+		return errno.ALERT_SILENCE_NOT_EXIST
+	}
+	return errno.OK
+}
+
+func DeleteAlertSilence(r *pigeon.Request, id int64) errno.Errno {
+	exist, err := storage.DeleteSilence(currentClusterId, id)
+	if err != nil {
+		r.Logger().Error("DeleteAlertSilence failed",
+			pigeon.Field("id", id),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_ALERT_SILENCE_FAILED
+	} else if !exist {
+		return errno.ALERT_SILENCE_NOT_EXIST
+	}
+	return errno.OK
+}
@@ -36,7 +36,53 @@ type Snapshot struct {
 	VolumeName string `json:"volumeName" binding:"required"`
 }
 
+// getGrantedSnapshot list all the snapshots and page the readable ones
+func getGrantedSnapshot(r *pigeon.Request, access *volumeAccess, size, page uint32, uuid, user, fileName,
+	status string) (interface{}, errno.Errno) {
+	snapshots, err := snapshotclone.GetAllSnapshot(user, fileName, status)
+	if err != nil {
+		r.Logger().Error("GetSnapshot GetAllSnapshot failed",
+			pigeon.Field("user", user),
+			pigeon.Field("fileName", fileName),
+			pigeon.Field("status", status),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.LIST_SNAPSHOT_FAILED
+	}
+	if uuid != "" {
+		matched := []snapshotclone.SnapshotInfo{}
+		for _, snapshot := range snapshots {
+			if snapshot.UUID == uuid {
+				matched = append(matched, snapshot)
+			}
+		}
+		snapshots = matched
+	}
+	snapshots = access.filterSnapshot(snapshots)
+	info := snapshotclone.ListSnapshotInfo{
+		Total: len(snapshots),
+		Info:  []snapshotclone.SnapshotInfo{},
+	}
+	length := uint32(len(snapshots))
+	start := (page - 1) * size
+	end := common.MinUint32(page*size, length)
+	if start < length {
+		info.Info = snapshots[start:end]
+	}
+	return info, errno.OK
+}
+
 func GetSnapshot(r *pigeon.Request, size, page uint32, uuid, user, fileName, status string) (interface{}, errno.Errno) {
+	access, e := getVolumeAccess(r)
+	if e != nil {
+		r.Logger().Error("GetSnapshot getVolumeAccess failed",
+			pigeon.Field("error", e),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_VOLUME_GRANT_FAILED
+	}
+	if access != nil {
+		return getGrantedSnapshot(r, access, size, page, uuid, user, fileName, status)
+	}
 	snapshots, err := snapshotclone.GetSnapshot(size, page, uuid, user, fileName, status)
 	if err != nil {
 		r.Logger().Error("GetSnapshot failed",
@@ -54,6 +100,9 @@ func GetSnapshot(r *pigeon.Request, size, page uint32, uuid, user, fileName, sta
 }
 
 func CreateSnapshot(r *pigeon.Request, volumeName, user, snapshotName string) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, volumeName); errNo != errno.OK {
+		return errNo
+	}
 	err := snapshotclone.CreateSnapshot(volumeName, user, snapshotName)
 	if err != nil {
 		r.Logger().Error("CreateSnapshot failed",
@@ -72,6 +121,13 @@ func CancelSnapshot(r *pigeon.Request, snapshots []Snapshot) errno.Errno {
 	if size == 0 {
 		return errno.OK
 	}
+	names := []string{}
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.VolumeName)
+	}
+	if errNo := checkVolumeAccess(r, true, names...); errNo != errno.OK {
+		return errNo
+	}
 	ret := make(chan common.QueryResult, size)
 	for _, snapshot := range snapshots {
 		go func(uuid, user, volumeName string) {
@@ -154,6 +210,13 @@ func DeleteSnapshot(r *pigeon.Request, fileName, user string, uuids []string, fa
 		}
 		toDelete = append(toDelete, snapshots...)
 	}
+	names := []string{}
+	for _, s := range toDelete {
+		names = append(names, s.File)
+	}
+	if errNo := checkVolumeAccess(r, true, names...); errNo != errno.OK {
+		return errNo
+	}
 	for _, s := range toDelete {
 		err := snapshotclone.DeleteSnapshot(s.UUID, s.File, s.User)
 		if err != nil {
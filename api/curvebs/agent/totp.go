@@ -0,0 +1,329 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/auth"
+	"github.com/opencurve/curve-manager/internal/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	// the password verified login waits for the second factor
+	LOGIN_TICKET_EXPIRATION   = 5 * time.Minute
+	LOGIN_TICKET_MAX_ATTEMPTS = 5
+	LOGIN_TICKET_BYTES        = 32
+
+	RECOVERY_CODE_NUM   = 10
+	RECOVERY_CODE_BYTES = 5
+)
+
+type loginTicket struct {
+	userName string
+	readOnly bool
+	expire   time.Time
+	attempts int
+}
+
+var (
+	loginTickets     = map[string]*loginTicket{}
+	loginTicketMutex sync.Mutex
+)
+
+type LoginChallenge struct {
+	UserName     string `json:"userName"`
+	TOTPRequired bool   `json:"totpRequired"`
+	Ticket       string `json:"ticket"`
+}
+
+type TOTPEnrollInfo struct {
+	Secret string `json:"secret"`
+	// otpauth:// uri, which is the content of QR code
+	URI string `json:"uri"`
+}
+
+type RecoveryCodeInfo struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func newLoginTicket(name string, readOnly bool) (string, error) {
+	ticket, err := common.GetSecureRandString(LOGIN_TICKET_BYTES)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	loginTicketMutex.Lock()
+	defer loginTicketMutex.Unlock()
+	for k, v := range loginTickets {
+		if now.After(v.expire) {
+			delete(loginTickets, k)
+		}
+	}
+	loginTickets[ticket] = &loginTicket{
+		userName: name,
+		readOnly: readOnly,
+		expire:   now.Add(LOGIN_TICKET_EXPIRATION),
+	}
+	return ticket, nil
+}
+
+// useLoginTicket returns the ticket, which is dropped after too many attempts
+func useLoginTicket(ticket string) (loginTicket, bool) {
+	loginTicketMutex.Lock()
+	defer loginTicketMutex.Unlock()
+	t, ok := loginTickets[ticket]
+	if !ok {
+		return loginTicket{}, false
+	}
+	t.attempts++
+	if time.Now().After(t.expire) || t.attempts > LOGIN_TICKET_MAX_ATTEMPTS {
+		delete(loginTickets, ticket)
+		return loginTicket{}, false
+	}
+	return *t, true
+}
+
+func dropLoginTicket(ticket string) {
+	loginTicketMutex.Lock()
+	defer loginTicketMutex.Unlock()
+	delete(loginTickets, ticket)
+}
+
+func newRecoveryCodes() ([]string, error) {
+	codes := []string{}
+	for i := 0; i < RECOVERY_CODE_NUM; i++ {
+		code, err := common.GetSecureRandString(RECOVERY_CODE_BYTES)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code[:len(code)/2]+"-"+code[len(code)/2:])
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accept either a totp code or an unused recovery code
+func verifySecondFactor(totp *storage.UserTOTP, code string) (bool, error) {
+	if step, ok := auth.VerifyTOTP(totp.Secret, code, time.Now()); ok {
+		return storage.UseTOTPStep(totp.UserName, step)
+	}
+	return storage.UseRecoveryCode(totp.UserName, code)
+}
+
+func getEnabledTOTP(r *pigeon.Request, name string) (*storage.UserTOTP, errno.Errno) {
+	totp, err := storage.GetUserTOTP(name)
+	if err == sql.ErrNoRows || (err == nil && !totp.Enabled) {
+		return nil, errno.TOTP_NOT_ENROLLED
+	} else if err != nil {
+		r.Logger().Error("GetUserTOTP failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_TOTP_FAILED
+	}
+	return &totp, errno.OK
+}
+
+func checkSecondFactor(r *pigeon.Request, totp *storage.UserTOTP, code string) errno.Errno {
+	ok, err := verifySecondFactor(totp, code)
+	if err != nil {
+		r.Logger().Error("verify second factor failed",
+			pigeon.Field("userName", totp.UserName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_TOTP_FAILED
+	}
+	if !ok {
+		r.Logger().Error("verify second factor failed, code not match",
+			pigeon.Field("userName", totp.UserName),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.TOTP_CODE_NOT_MATCH
+	}
+	return errno.OK
+}
+
+// LoginTOTP is the second step of login for the users enrolled two-factor authentication
+func LoginTOTP(r *pigeon.Request, ticket, code string) (interface{}, errno.Errno) {
+	t, ok := useLoginTicket(ticket)
+	if !ok {
+		r.Logger().Error("LoginTOTP failed, invalid ticket",
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.INVALID_LOGIN_TICKET
+	}
+	name := t.userName
+	r.HeadersIn[comm.HEADER_LOG_USER] = name
+	if e := checkLoginAllowed(r, name); e != errno.OK {
+		dropLoginTicket(ticket)
+		return nil, e
+	}
+	totp, e := getEnabledTOTP(r, name)
+	if e != errno.OK {
+		return nil, e
+	}
+	if e = checkSecondFactor(r, totp, code); e != errno.OK {
+		if e == errno.TOTP_CODE_NOT_MATCH {
+			recordLoginFailure(r, name, true)
+		}
+		return nil, e
+	}
+	dropLoginTicket(ticket)
+	recordLoginSuccess(r, name)
+	userInfo, err := storage.GetUser(name)
+	if err != nil {
+		r.Logger().Error("LoginTOTP get user failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_FAILED
+	}
+	return newSession(r, &userInfo, t.readOnly)
+}
+
+// EnrollTOTP generate a secret for the login user, which takes effect after activated
+func EnrollTOTP(r *pigeon.Request) (interface{}, errno.Errno) {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	enabled, err := storage.IsTOTPEnabled(name)
+	if err != nil {
+		r.Logger().Error("EnrollTOTP get user totp failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_TOTP_FAILED
+	}
+	if enabled {
+		return nil, errno.TOTP_ALREADY_ENABLED
+	}
+	secret, err := auth.NewTOTPSecret()
+	if err == nil {
+		err = storage.SetUserTOTPSecret(name, secret)
+	}
+	if err != nil {
+		r.Logger().Error("EnrollTOTP failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.UPDATE_USER_TOTP_FAILED
+	}
+	return TOTPEnrollInfo{
+		Secret: secret,
+		URI:    auth.TOTPProvisioningURI(secret, name),
+	}, errno.OK
+}
+
+func setRecoveryCodes(r *pigeon.Request, name string) (interface{}, errno.Errno) {
+	codes, err := newRecoveryCodes()
+	if err == nil {
+		err = storage.SetRecoveryCode(name, codes)
+	}
+	if err != nil {
+		r.Logger().Error("set recovery code failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.UPDATE_USER_TOTP_FAILED
+	}
+	return RecoveryCodeInfo{RecoveryCodes: codes}, errno.OK
+}
+
+// ActivateTOTP verify the first code from the authenticator app and returns the recovery codes
+func ActivateTOTP(r *pigeon.Request, code string) (interface{}, errno.Errno) {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	totp, err := storage.GetUserTOTP(name)
+	if err == sql.ErrNoRows {
+		return nil, errno.TOTP_NOT_ENROLLED
+	} else if err != nil {
+		r.Logger().Error("ActivateTOTP get user totp failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_TOTP_FAILED
+	}
+	if totp.Enabled {
+		return nil, errno.TOTP_ALREADY_ENABLED
+	}
+	step, ok := auth.VerifyTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		r.Logger().Error("ActivateTOTP failed, code not match",
+			pigeon.Field("userName", name),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.TOTP_CODE_NOT_MATCH
+	}
+	info, e := setRecoveryCodes(r, name)
+	if e != errno.OK {
+		return nil, e
+	}
+	err = storage.EnableUserTOTP(name, step)
+	if err != nil {
+		r.Logger().Error("ActivateTOTP failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.UPDATE_USER_TOTP_FAILED
+	}
+	return info, errno.OK
+}
+
+// DisableTOTP remove the second factor of the login user, which requires a valid code
+func DisableTOTP(r *pigeon.Request, code string) errno.Errno {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	totp, e := getEnabledTOTP(r, name)
+	if e != errno.OK {
+		return e
+	}
+	if e = checkSecondFactor(r, totp, code); e != errno.OK {
+		return e
+	}
+	return ResetTOTP(r, name)
+}
+
+// RegenerateRecoveryCode replace all the recovery codes of the login user
+func RegenerateRecoveryCode(r *pigeon.Request, code string) (interface{}, errno.Errno) {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	totp, e := getEnabledTOTP(r, name)
+	if e != errno.OK {
+		return nil, e
+	}
+	if e = checkSecondFactor(r, totp, code); e != errno.OK {
+		return nil, e
+	}
+	return setRecoveryCodes(r, name)
+}
+
+// ResetTOTP remove the second factor of user, used by admin when the device lost
+func ResetTOTP(r *pigeon.Request, name string) errno.Errno {
+	err := storage.DeleteUserTOTP(name)
+	if err != nil {
+		r.Logger().Error("ResetTOTP failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_TOTP_FAILED
+	}
+	return errno.OK
+}
@@ -0,0 +1,82 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencurve/curve-manager/internal/auth"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/storage/storagetest"
+)
+
+func TestRecoveryCode(t *testing.T) {
+	storagetest.Init(t)
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate totp secret failed: %v", err)
+	}
+	totp := &storage.UserTOTP{UserName: "alice", Secret: secret, Enabled: true}
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generate recovery codes failed: %v", err)
+	}
+	if len(codes) != RECOVERY_CODE_NUM {
+		t.Fatalf("expect %d recovery codes, got %d", RECOVERY_CODE_NUM, len(codes))
+	}
+	if err = storage.SetRecoveryCode(totp.UserName, codes); err != nil {
+		t.Fatalf("set recovery codes failed: %v", err)
+	}
+
+	// the code is accepted as shown, or typed without separator and in upper case
+	inputs := []string{
+		codes[0],
+		strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")),
+		strings.ReplaceAll(codes[2], "-", " "),
+	}
+	for _, code := range inputs {
+		ok, err := verifySecondFactor(totp, code)
+		if err != nil || !ok {
+			t.Fatalf("recovery code %q rejected: %v", code, err)
+		}
+	}
+
+	// every code can only be used once
+	if ok, _ := verifySecondFactor(totp, codes[0]); ok {
+		t.Fatalf("used recovery code %q accepted again", codes[0])
+	}
+	if ok, _ := verifySecondFactor(totp, "0000-0000"); ok {
+		t.Fatalf("unknown recovery code accepted")
+	}
+	// regenerated codes replace the old ones
+	if err = storage.SetRecoveryCode(totp.UserName, codes[len(codes)-1:]); err != nil {
+		t.Fatalf("set recovery codes failed: %v", err)
+	}
+	if ok, _ := verifySecondFactor(totp, codes[3]); ok {
+		t.Fatalf("replaced recovery code %q accepted", codes[3])
+	}
+	if ok, err := verifySecondFactor(totp, codes[len(codes)-1]); err != nil || !ok {
+		t.Fatalf("regenerated recovery code rejected: %v", err)
+	}
+}
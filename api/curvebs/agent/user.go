@@ -23,9 +23,14 @@
 package agent
 
 import (
+	"database/sql"
+	"net/url"
 	"sort"
+	"strings"
+	"time"
 
 	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/auth"
 	"github.com/opencurve/curve-manager/internal/common"
 	"github.com/opencurve/curve-manager/internal/email"
 	"github.com/opencurve/curve-manager/internal/errno"
@@ -40,9 +45,10 @@ var (
 )
 
 type UserInfo struct {
-	UserName   string `json:"userName" binding:"required"`
-	Email      string `json:"email"`
-	Permission int    `json:"permission" binding:"required"`
+	UserName   string   `json:"userName" binding:"required"`
+	Email      string   `json:"email"`
+	Permission int      `json:"permission" binding:"required"`
+	Roles      []string `json:"roles"`
 }
 
 type ListUserInfo struct {
@@ -52,31 +58,146 @@ type ListUserInfo struct {
 
 const (
 	ENABLE_MULTIPLE_WRITER_USER_LOGIN = "enable.multiple.write.user.login"
+
+	LOGIN_EXPIRE_SECONDS         = "access.login.expire_seconds"
+	DEFAULT_LOGIN_EXPIRE_SECONDS = 1800
+	LOGIN_MAX_LIFETIME           = "access.login.max_lifetime_seconds"
+	DEFAULT_LOGIN_MAX_LIFETIME   = 43200
+
+	CLEAR_SESSION_INTERVAL = 10 * time.Minute
+
+	HEADER_USER_AGENT = "User-Agent"
+
+	PASSWORD_RESET_EXPIRATION         = "user.password.reset.expiration_seconds"
+	DEFAULT_PASSWORD_RESET_EXPIRATION = 1800
+	// a new token is not issued while the outstanding one is younger than it
+	PASSWORD_RESET_COOLDOWN         = "user.password.reset.cooldown_seconds"
+	DEFAULT_PASSWORD_RESET_COOLDOWN = 300
+	// the page of frontend which calls user.reset.password.confirm, the token is appended as query
+	PASSWORD_RESET_URL   = "user.password.reset.url"
+	PASSWORD_RESET_BYTES = 32
 )
 
-func Login(r *pigeon.Request, name, passwd string) (interface{}, errno.Errno) {
-	userInfo, err := storage.GetUser(name)
+func clearExpiredSession(idleSec, lifetimeSec int, logger *pigeon.Logger) {
+	logger.Info("start clear expired session",
+		pigeon.Field("interval seconds", CLEAR_SESSION_INTERVAL.Seconds()),
+		pigeon.Field("idle seconds", idleSec),
+		pigeon.Field("lifetime seconds", lifetimeSec))
+	timer := time.NewTimer(CLEAR_SESSION_INTERVAL)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			err := storage.DeleteExpiredSession(idleSec, lifetimeSec)
+			if err != nil {
+				logger.Error("clear expired session failed",
+					pigeon.Field("error", err))
+			}
+			timer.Reset(CLEAR_SESSION_INTERVAL)
+		}
+	}
+}
+
+// Login authenticate the user, readOnly logins a write user without the write lock,
+// which is used to wait for the lock held by another user
+func Login(r *pigeon.Request, name, passwd string, readOnly bool) (interface{}, errno.Errno) {
+	if e := checkLoginAllowed(r, name); e != errno.OK {
+		return nil, e
+	}
+	info, err := auth.Authenticate(name, passwd)
 	if err != nil {
 		r.Logger().Error("Login failed",
 			pigeon.Field("userName", name),
-			pigeon.Field("passWord", passwd),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
-		return nil, errno.GET_USER_FAILED
+		switch err {
+		case auth.ErrUserNotExist:
+			recordLoginFailure(r, name, false)
+			return nil, errno.GET_USER_FAILED
+		case auth.ErrPassWordNotMatch:
+			recordLoginFailure(r, name, true)
+			return nil, errno.USER_PASSWORD_NOT_MATCH
+		case auth.ErrNoPermission:
+			return nil, errno.USER_HAS_NO_PERMISSION
+		case auth.ErrBackendNotEnabled:
+			return nil, errno.OPERATION_IS_NOT_PERMIT
+		default:
+			return nil, errno.AUTHENTICATE_USER_FAILED
+		}
 	}
-	if passwd != userInfo.PassWord {
-		r.Logger().Error("Login failed",
+	userInfo := *info
+	// upgrade the legacy stored password to the current hash algorithm
+	if storage.NeedRehashPassWord(&userInfo) {
+		err = storage.RehashUserPassWord(name, passwd)
+		if err != nil {
+			r.Logger().Warn("Login rehash password failed",
+				pigeon.Field("userName", name),
+				pigeon.Field("algorithm", userInfo.Algorithm),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		}
+	}
+	// the deployments before password policy may still use the default admin password
+	if !userInfo.MustChangePassWord && storage.IsDefaultAdminPassWord(&userInfo) {
+		userInfo.MustChangePassWord = true
+		err = storage.SetMustChangePassWord(name)
+		if err != nil {
+			r.Logger().Warn("Login set must change password failed",
+				pigeon.Field("userName", name),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		}
+	}
+	// the session is issued after the second factor verified
+	enabled, err := storage.IsTOTPEnabled(name)
+	if err != nil {
+		r.Logger().Error("Login get user totp failed",
 			pigeon.Field("userName", name),
-			pigeon.Field("inPassWord", passwd),
-			pigeon.Field("storedPassword", userInfo.PassWord),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_TOTP_FAILED
+	}
+	if enabled {
+		ticket, err := newLoginTicket(name, readOnly)
+		if err != nil {
+			r.Logger().Error("Login new ticket failed",
+				pigeon.Field("userName", name),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return nil, errno.ADD_USER_SESSION_FAILED
+		}
+		return LoginChallenge{
+			UserName:     name,
+			TOTPRequired: true,
+			Ticket:       ticket,
+		}, errno.OK
+	}
+	recordLoginSuccess(r, name)
+	return newSession(r, &userInfo, readOnly)
+}
+
+// newSession add a login session of the authenticated user
+func newSession(r *pigeon.Request, userInfo *storage.UserInfo, readOnly bool) (interface{}, errno.Errno) {
+	name := userInfo.UserName
+	writer, err := storage.IsWriteUser(userInfo)
+	if err != nil {
+		r.Logger().Error("Login get user roles failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
-		return nil, errno.USER_PASSWORD_NOT_MATCH
+		return nil, errno.GET_USER_ROLE_FAILED
 	}
-	// check multiple write user login
+	// WRITE_PERM of session means holding the write lock, so the users write by roles hold it too
+	if readOnly || !writer {
+		userInfo.Permission &^= WRITE_PERM
+	} else {
+		userInfo.Permission |= WRITE_PERM
+	}
+	// check multiple write user login, the idle one gives up the write lock to the new login
 	if userInfo.Permission&WRITE_PERM == WRITE_PERM &&
 		!r.GetConfig().GetBool(ENABLE_MULTIPLE_WRITER_USER_LOGIN) {
 		user := storage.GetLoginWriteUser()
-		if user != "" && userInfo.UserName != user {
+		if user != "" && userInfo.UserName != user && !releaseIdleWriteUser(r, user) {
 			r.Logger().Error("Login failed, there is already a write user logined",
 				pigeon.Field("userName", name),
 				pigeon.Field("permission", userInfo.Permission),
@@ -84,33 +205,80 @@ func Login(r *pigeon.Request, name, passwd string) (interface{}, errno.Errno) {
 			return nil, errno.WRITE_USER_LOGIN_FAILED
 		}
 	}
-	storage.AddSession(&userInfo)
+	err = storage.AddSession(userInfo, r.Context.ClientIP(), r.HeadersIn[HEADER_USER_AGENT])
+	if err != nil {
+		r.Logger().Error("Login add session failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.ADD_USER_SESSION_FAILED
+	}
+	// tell the client to change password first, other operations are rejected until changed
+	userInfo.MustChangePassWord = storage.IsPassWordExpired(userInfo)
 	return userInfo, errno.OK
 }
 
+// checkPassWordPolicy check the new password of user name against the configured policy
+func checkPassWordPolicy(r *pigeon.Request, name, passwd string) errno.Errno {
+	err := storage.CheckPassWordPolicy(name, passwd)
+	if err == nil {
+		return errno.OK
+	}
+	r.Logger().Error("CheckPassWordPolicy failed",
+		pigeon.Field("userName", name),
+		pigeon.Field("error", err),
+		pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	if err == storage.ErrPassWordReused {
+		return errno.PASSWORD_REUSED
+	} else if err == storage.ErrPassWordPrehashed {
+		return errno.PASSWORD_PREHASHED
+	}
+	return errno.INVALID_PASSWORD
+}
+
 func Logout(r *pigeon.Request) errno.Errno {
-	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
-	user := storage.GetLoginUserByToken(token)
-	storage.Logout(user)
+	user := r.HeadersIn[comm.HEADER_AUTH_USER]
+	err := storage.Logout(user)
+	if err != nil {
+		r.Logger().Error("Logout failed",
+			pigeon.Field("userName", user),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_USER_SESSION_FAILED
+	}
 	return errno.OK
 }
 
 func CreateUser(r *pigeon.Request, name, passwd, email string, permission int) errno.Errno {
+	if e := checkPassWordPolicy(r, name, passwd); e != errno.OK {
+		return e
+	}
 	err := storage.CreateUser(name, passwd, email, permission)
 	if err != nil {
 		r.Logger().Error("Create user failed",
 			pigeon.Field("userName", name),
-			pigeon.Field("passWord", passwd),
 			pigeon.Field("email", email),
 			pigeon.Field("permission", permission),
+			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.CREATE_USER_FAILED
 	}
 	return errno.OK
 }
 
+// DeleteUser delete the user not notified by any escalation policy or on-call schedule
 func DeleteUser(r *pigeon.Request, name string) errno.Errno {
-	err := storage.DeleteUser(name)
+	escalated, err := storage.IsUserEscalated(name)
+	if err != nil {
+		r.Logger().Error("IsUserEscalated failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.LIST_ESCALATION_POLICY_FAILED
+	} else if escalated {
+		return errno.USER_IN_USE
+	}
+	err = storage.DeleteUser(name)
 	if err != nil {
 		r.Logger().Error("Delete user failed",
 			pigeon.Field("userName", name),
@@ -122,24 +290,32 @@ func DeleteUser(r *pigeon.Request, name string) errno.Errno {
 }
 
 func ChangePassWord(r *pigeon.Request, oldPassword, newPassword string) errno.Errno {
-	name := storage.GetLoginUserByToken(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
-	passwd, err := storage.GetUserPassword(name)
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	userInfo, err := storage.GetUser(name)
 	if err != nil {
-		r.Logger().Error("GetUserPassword failed",
+		r.Logger().Error("GetUser failed",
 			pigeon.Field("userName", name),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.GET_USER_PASSWORD_FAILED
 	}
-	if passwd != oldPassword {
+	if !storage.IsLocalUser(&userInfo) {
+		r.Logger().Error("ChangePassWord failed, password is managed externally",
+			pigeon.Field("userName", name),
+			pigeon.Field("algorithm", userInfo.Algorithm),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
+	if !storage.CheckUserPassWord(&userInfo, oldPassword) {
 		r.Logger().Error("ChangePassWord failed, old password not match",
 			pigeon.Field("userName", name),
-			pigeon.Field("inPassword", oldPassword),
-			pigeon.Field("stored password", passwd),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.USER_PASSWORD_NOT_MATCH
 	}
-	err = storage.UpdateUserPassWord(name, newPassword)
+	if e := checkPassWordPolicy(r, name, newPassword); e != errno.OK {
+		return e
+	}
+	err = storage.UpdateUserPassWord(name, newPassword, false)
 	if err != nil {
 		r.Logger().Error("UpdateUserPassWord failed",
 			pigeon.Field("userName", name),
@@ -150,7 +326,35 @@ func ChangePassWord(r *pigeon.Request, oldPassword, newPassword string) errno.Er
 	return errno.OK
 }
 
+// ResetPassWord always succeeds, so the anonymous caller can not tell whether the user exists,
+// is local or has an email, the failures are only logged
 func ResetPassWord(r *pigeon.Request, name string) errno.Errno {
+	if e := sendPassWordReset(r, name); e != errno.OK {
+		r.Logger().Warn("ResetPassWord failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("errno", e.Code()),
+			pigeon.Field("reason", e.Description()),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	}
+	return errno.OK
+}
+
+func sendPassWordReset(r *pigeon.Request, name string) errno.Errno {
+	userInfo, err := storage.GetUser(name)
+	if err != nil {
+		r.Logger().Error("ResetPassWord get user failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_FAILED
+	}
+	if !storage.IsLocalUser(&userInfo) {
+		r.Logger().Error("ResetPassWord failed, password is managed externally",
+			pigeon.Field("userName", name),
+			pigeon.Field("algorithm", userInfo.Algorithm),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
 	emailAddr, err := storage.GetUserEmail(name)
 	if err != nil {
 		r.Logger().Error("GetUserEmail failed",
@@ -166,23 +370,54 @@ func ResetPassWord(r *pigeon.Request, name string) errno.Errno {
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.USER_EMAIL_EMPTY
 	}
-	passwd := storage.GetNewPassWord()
-	err = storage.UpdateUserPassWord(name, common.GetMd5Sum32Little(passwd))
+	token, err := common.GetSecureRandString(PASSWORD_RESET_BYTES)
 	if err != nil {
-		r.Logger().Error("UpdateUserPassWord failed",
+		r.Logger().Error("ResetPassWord generate token failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_PASSWORD_FAILED
+	}
+	expirationSec := r.GetConfig().GetInt(PASSWORD_RESET_EXPIRATION)
+	if expirationSec <= 0 {
+		expirationSec = DEFAULT_PASSWORD_RESET_EXPIRATION
+	}
+	expiration := time.Duration(expirationSec) * time.Second
+	cooldownSec := r.GetConfig().GetInt(PASSWORD_RESET_COOLDOWN)
+	if cooldownSec <= 0 {
+		cooldownSec = DEFAULT_PASSWORD_RESET_COOLDOWN
+	}
+	cooldown := time.Duration(cooldownSec) * time.Second
+	// the password is not changed until the reset confirmed
+	issued, err := storage.AddPassWordReset(name, token, time.Now().Add(expiration), cooldown)
+	if err != nil {
+		r.Logger().Error("AddPassWordReset failed",
 			pigeon.Field("userName", name),
-			pigeon.Field("passWord", common.GetMd5Sum32Little(passwd)),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.UPDATE_USER_PASSWORD_FAILED
+	} else if !issued {
+		return errno.PASSWORD_RESET_TOO_OFTEN
 	}
 
-	err = email.SendNewPassWord(name, emailAddr, passwd)
+	link := r.GetConfig().GetString(PASSWORD_RESET_URL)
+	if link != "" {
+		sep := "?"
+		if strings.Contains(link, "?") {
+			sep = "&"
+		}
+		link += sep + "token=" + url.QueryEscape(token)
+	}
+	err = email.SendPassWordReset(emailAddr, &email.PassWordReset{
+		UserName:   name,
+		Token:      token,
+		Link:       link,
+		Expiration: expiration,
+	})
 	if err != nil {
-		r.Logger().Warn("Email sendNewPassWord failed",
+		r.Logger().Warn("Email SendPassWordReset failed",
 			pigeon.Field("userName", name),
 			pigeon.Field("emailAddr", emailAddr),
-			pigeon.Field("newPassword", passwd),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return errno.SEND_USER_PASSWORD_FAILED
@@ -190,8 +425,58 @@ func ResetPassWord(r *pigeon.Request, name string) errno.Errno {
 	return errno.OK
 }
 
+// ConfirmResetPassWord set the password of the user who received the reset token,
+// the token can only be used once and all the sessions of the user are revoked
+func ConfirmResetPassWord(r *pigeon.Request, token, passwd string) errno.Errno {
+	name, err := storage.GetPassWordReset(token)
+	if err == sql.ErrNoRows {
+		r.Logger().Error("ConfirmResetPassWord failed, invalid token",
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.INVALID_RESET_TOKEN
+	} else if err != nil {
+		r.Logger().Error("GetPassWordReset failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_PASSWORD_FAILED
+	}
+	r.HeadersIn[comm.HEADER_LOG_USER] = name
+	if e := checkPassWordPolicy(r, name, passwd); e != errno.OK {
+		return e
+	}
+	ok, err := storage.UsePassWordReset(token)
+	if err != nil {
+		r.Logger().Error("UsePassWordReset failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_PASSWORD_FAILED
+	}
+	if !ok {
+		r.Logger().Error("ConfirmResetPassWord failed, token already used",
+			pigeon.Field("userName", name),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.INVALID_RESET_TOKEN
+	}
+	err = storage.UpdateUserPassWord(name, passwd, false)
+	if err != nil {
+		r.Logger().Error("UpdateUserPassWord failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_USER_PASSWORD_FAILED
+	}
+	err = storage.Logout(name)
+	if err != nil {
+		r.Logger().Warn("ConfirmResetPassWord revoke sessions failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	}
+	return errno.OK
+}
+
 func UpdateUserEmail(r *pigeon.Request, email string) errno.Errno {
-	name := storage.GetLoginUserByToken(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
 	err := storage.UpdateUserEmail(name, email)
 	if err != nil {
 		r.Logger().Error("UpdateUserEmail failed",
@@ -248,22 +533,36 @@ func ListUser(r *pigeon.Request, size, page uint32, userName string) (interface{
 		item.UserName = user.UserName
 		item.Email = user.Email
 		item.Permission = user.Permission
+		item.Roles, err = storage.GetUserRoles(user.UserName)
+		if err != nil {
+			r.Logger().Error("ListUser get user roles failed",
+				pigeon.Field("userName", user.UserName),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return nil, errno.GET_USER_ROLE_FAILED
+		}
 		info.Info = append(info.Info, item)
 	}
 	return info, errno.OK
 }
 
 func GetUser(r *pigeon.Request) (interface{}, errno.Errno) {
-	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
-	userName := storage.GetLoginUserByToken(token)
+	userName := r.HeadersIn[comm.HEADER_AUTH_USER]
 	info, err := storage.GetUser(userName)
 	if err != nil {
 		r.Logger().Error("GetUser failed",
-			pigeon.Field("token", token),
 			pigeon.Field("userName", userName),
 			pigeon.Field("error", err),
 			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 		return nil, errno.GET_USER_FAILED
 	}
+	info.Roles, err = storage.GetUserRoles(userName)
+	if err != nil {
+		r.Logger().Error("GetUser get user roles failed",
+			pigeon.Field("userName", userName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_ROLE_FAILED
+	}
 	return info, errno.OK
 }
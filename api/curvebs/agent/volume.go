@@ -290,6 +290,20 @@ func ListVolume(r *pigeon.Request, size, page uint32, path, key string, directio
 	listVolumeInfo := ListVolumeInfo{
 		Info: []curvebs.FileInfo{},
 	}
+	access, e := getVolumeAccess(r)
+	if e != nil {
+		r.Logger().Error("ListVolume getVolumeAccess failed",
+			pigeon.Field("error", e),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_VOLUME_GRANT_FAILED
+	}
+	if !access.visible(path) {
+		r.Logger().Error("ListVolume failed, path not granted",
+			pigeon.Field("path", path),
+			pigeon.Field("userName", r.HeadersIn[comm.HEADER_AUTH_USER]),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.VOLUME_ACCESS_DENIED
+	}
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
 		r.Logger().Error("ListVolume getAuthInfoOfRoot failed",
@@ -319,6 +333,8 @@ func ListVolume(r *pigeon.Request, size, page uint32, path, key string, directio
 		}
 		fileInfos = tmpSlice
 	}
+	// only show the granted volumes and the directories lead to them
+	fileInfos = access.filterFile(path, fileInfos)
 
 	listVolumeInfo.Total = len(fileInfos)
 	if len(fileInfos) == 0 {
@@ -373,6 +389,9 @@ func ListVolume(r *pigeon.Request, size, page uint32, path, key string, directio
 }
 
 func GetVolume(r *pigeon.Request, volumeName string, start, end, interval uint64) (interface{}, errno.Errno) {
+	if errNo := checkVolumeAccess(r, false, volumeName); errNo != errno.OK {
+		return nil, errNo
+	}
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
 		r.Logger().Error("GetVolume getAuthInfoOfRoot failed",
@@ -450,6 +469,9 @@ func needDelete(file *curvebs.FileInfo, expiration uint64) bool {
 }
 
 func CleanRecycleBin(r *pigeon.Request, expiration uint64) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, RECYCLEBIN_DIR); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -487,6 +509,9 @@ func CleanRecycleBin(r *pigeon.Request, expiration uint64) errno.Errno {
 }
 
 func CreateNameSpace(r *pigeon.Request, name, user, passwrord string) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, name); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -512,6 +537,9 @@ func CreateNameSpace(r *pigeon.Request, name, user, passwrord string) errno.Errn
 }
 
 func CreateVolume(r *pigeon.Request, name, user string, passwrord string, length, stripUnit, stripCount uint64) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, name); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -540,6 +568,9 @@ func CreateVolume(r *pigeon.Request, name, user string, passwrord string, length
 }
 
 func ExtendVolume(r *pigeon.Request, name string, length uint64) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, name); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -561,6 +592,9 @@ func ExtendVolume(r *pigeon.Request, name string, length uint64) errno.Errno {
 }
 
 func VolumeThrottle(r *pigeon.Request, name, throttleType string, limit, burst, burstLength uint64) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, name); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -624,6 +658,13 @@ func DeleteVolume(r *pigeon.Request, volumes map[string]string, force bool) errn
 	if len(volumes) == 0 {
 		return errno.OK
 	}
+	names := []string{}
+	for name := range volumes {
+		names = append(names, name)
+	}
+	if errNo := checkVolumeAccess(r, true, names...); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -642,6 +683,13 @@ func RecoverVolume(r *pigeon.Request, ids map[string]uint64) errno.Errno {
 	if len(ids) == 0 {
 		return errno.OK
 	}
+	names := []string{}
+	for name := range ids {
+		names = append(names, name)
+	}
+	if errNo := checkVolumeAccess(r, true, names...); errNo != errno.OK {
+		return errNo
+	}
 	// get auth info
 	authInfo, err := getAuthInfoOfRoot()
 	if err != "" {
@@ -668,7 +716,33 @@ func RecoverVolume(r *pigeon.Request, ids map[string]uint64) errno.Errno {
 	return errno.OK
 }
 
+// cloneSourceVolume returns the volume of src, which is either a volume or the uuid of a snapshot
+func cloneSourceVolume(r *pigeon.Request, src string) (string, errno.Errno) {
+	if path.IsAbs(src) {
+		return src, errno.OK
+	}
+	info, err := snapshotclone.GetSnapshot(1, 1, src, "", "", "")
+	if err != nil || info.Total == 0 || len(info.Info) == 0 {
+		r.Logger().Error("CloneVolume GetSnapshot failed",
+			pigeon.Field("uuid", src),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return "", errno.GET_SNAPSHOT_FAILED
+	}
+	return info.Info[0].File, errno.OK
+}
+
 func CloneVolume(r *pigeon.Request, src, dest, user string, lazy bool) errno.Errno {
+	srcVolume, errNo := cloneSourceVolume(r, src)
+	if errNo != errno.OK {
+		return errNo
+	}
+	if errNo := checkVolumeAccess(r, false, srcVolume); errNo != errno.OK {
+		return errNo
+	}
+	if errNo := checkVolumeAccess(r, true, dest); errNo != errno.OK {
+		return errNo
+	}
 	err := snapshotclone.CreateClone(src, dest, user, lazy)
 	if err != nil {
 		r.Logger().Error("CloneVolume failed",
@@ -684,6 +758,9 @@ func CloneVolume(r *pigeon.Request, src, dest, user string, lazy bool) errno.Err
 }
 
 func Flatten(r *pigeon.Request, volumeName, user string) errno.Errno {
+	if errNo := checkVolumeAccess(r, true, volumeName); errNo != errno.OK {
+		return errNo
+	}
 	uuids, err := snapshotclone.GetCloneTaskNeedFlatten(r, volumeName, user)
 	if err != nil || len(uuids) == 0 {
 		r.Logger().Error("Flatten GetCloneTaskNeedFlatten failed",
@@ -0,0 +1,279 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/common"
+	"github.com/opencurve/curve-manager/internal/email"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/webhook"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	WEBHOOK_TEST_ALERT   = "webhook.test"
+	WEBHOOK_TEST_SUMMARY = "this is a test alert from curve-manager"
+)
+
+type WebhookTestResult struct {
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
+
+func toReceiver(w *storage.Webhook) *webhook.Receiver {
+	return &webhook.Receiver{
+		Type:               w.Type,
+		URL:                w.URL,
+		Secret:             w.Secret,
+		SecretHeader:       w.SecretHeader,
+		BodyTemplate:       w.BodyTemplate,
+		Timeout:            time.Duration(w.TimeoutSec) * time.Second,
+		InsecureSkipVerify: w.InsecureSkipVerify,
+		CACert:             w.CACert,
+	}
+}
+
+// setWebhookLogContent keep the secret and the token in url of chat robots out of system log
+func setWebhookLogContent(r *pigeon.Request, w *storage.Webhook) {
+	redacted := *w
+	redacted.URL = webhook.RedactURL(w.Type, w.URL)
+	c, _ := json.Marshal(&redacted)
+	r.HeadersIn[comm.HEADER_LOG_CONTENT] = string(c)
+}
+
+// redactWebhookError keep the token in url of chat robots out of the error, which may quote the url,
+// the error is saved as the last error of notification and logged
+func redactWebhookError(w *storage.Webhook, err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), w.URL, webhook.RedactURL(w.Type, w.URL)))
+}
+
+func sendWebhookNotification(n *storage.Notification) error {
+	w, err := storage.GetWebhook(n.Recipient)
+	if err != nil {
+		return err
+	}
+	alert := email.Alert{}
+	if err := json.Unmarshal([]byte(n.Payload), &alert); err != nil {
+		return err
+	}
+	return redactWebhookError(&w, toReceiver(&w).Send(&alert))
+}
+
+func checkWebhook(r *pigeon.Request, w *storage.Webhook) errno.Errno {
+	if w.TimeoutSec < 0 {
+		return errno.INVALID_WEBHOOK
+	}
+	if err := toReceiver(w).Check(); err != nil {
+		r.Logger().Error("checkWebhook failed",
+			pigeon.Field("name", w.Name),
+			pigeon.Field("error", redactWebhookError(w, err)),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.INVALID_WEBHOOK
+	}
+	return errno.OK
+}
+
+func getWebhook(r *pigeon.Request, name string) (storage.Webhook, errno.Errno) {
+	w, err := storage.GetWebhook(name)
+	if err == sql.ErrNoRows {
+		return w, errno.WEBHOOK_NOT_EXIST
+	} else if err != nil {
+		r.Logger().Error("GetWebhook failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return w, errno.GET_WEBHOOK_FAILED
+	}
+	return w, errno.OK
+}
+
+func ListWebhook(r *pigeon.Request) (interface{}, errno.Errno) {
+	webhooks, err := storage.ListWebhook()
+	if err != nil {
+		r.Logger().Error("ListWebhook failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_WEBHOOK_FAILED
+	}
+	// the url is the credential of chat robots, as the secret of generic webhook
+	for i := range webhooks {
+		webhooks[i].URL = webhook.RedactURL(webhooks[i].Type, webhooks[i].URL)
+	}
+	return webhooks, errno.OK
+}
+
+func CreateWebhook(r *pigeon.Request, w *storage.Webhook) errno.Errno {
+	w.HasSecret = w.Secret != ""
+	setWebhookLogContent(r, w)
+	if e := checkWebhook(r, w); e != errno.OK {
+		return e
+	}
+	err := storage.CreateWebhook(w)
+	if err != nil {
+		r.Logger().Error("CreateWebhook failed",
+			pigeon.Field("name", w.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.CREATE_WEBHOOK_FAILED
+	}
+	return errno.OK
+}
+
+// UpdateWebhook replace the webhook, the secret is kept if secret is nil,
+// and the url is kept if it is the redacted one returned by ListWebhook
+func UpdateWebhook(r *pigeon.Request, w *storage.Webhook, secret *string) errno.Errno {
+	w.HasSecret = secret != nil && *secret != ""
+	setWebhookLogContent(r, w)
+	old, e := getWebhook(r, w.Name)
+	if e != errno.OK {
+		return e
+	}
+	if w.Type == old.Type && w.URL == webhook.RedactURL(old.Type, old.URL) {
+		w.URL = old.URL
+	}
+	w.Secret = old.Secret
+	if secret != nil {
+		w.Secret = *secret
+	}
+	w.HasSecret = w.Secret != ""
+	setWebhookLogContent(r, w)
+	if e := checkWebhook(r, w); e != errno.OK {
+		return e
+	}
+	err := storage.UpdateWebhook(w)
+	if err != nil {
+		r.Logger().Error("UpdateWebhook failed",
+			pigeon.Field("name", w.Name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_WEBHOOK_FAILED
+	}
+	return errno.OK
+}
+
+// DeleteWebhook delete the webhook not used by any escalation policy
+func DeleteWebhook(r *pigeon.Request, name string) errno.Errno {
+	if _, e := getWebhook(r, name); e != errno.OK {
+		return e
+	}
+	escalated, err := storage.IsWebhookEscalated(name)
+	if err != nil {
+		r.Logger().Error("IsWebhookEscalated failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.LIST_ESCALATION_POLICY_FAILED
+	} else if escalated {
+		return errno.WEBHOOK_IN_USE
+	}
+	err = storage.DeleteWebhook(name)
+	if err != nil {
+		r.Logger().Error("DeleteWebhook failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.DELETE_WEBHOOK_FAILED
+	}
+	return errno.OK
+}
+
+// TestWebhook send a test alert to the webhook immediately, the failure is returned as result
+func TestWebhook(r *pigeon.Request, name string) (interface{}, errno.Errno) {
+	w, e := getWebhook(r, name)
+	if e != errno.OK {
+		return nil, e
+	}
+	err := toReceiver(&w).Send(&email.Alert{
+		ClusterId: currentClusterId,
+		Name:      WEBHOOK_TEST_ALERT,
+		Level:     storage.WARNING,
+		Summary:   WEBHOOK_TEST_SUMMARY,
+		Time:      common.Mill2TimeStr(time.Now().UnixMilli()),
+		State:     storage.ALERT_STATE_FIRING,
+	})
+	if err != nil {
+		err = redactWebhookError(&w, err)
+		r.Logger().Warn("TestWebhook failed",
+			pigeon.Field("name", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return WebhookTestResult{Error: err.Error()}, errno.OK
+	}
+	return WebhookTestResult{Delivered: true}, errno.OK
+}
+
+func UpdateAlertWebhook(r *pigeon.Request, alert string, webhooks []string) errno.Errno {
+	for _, name := range webhooks {
+		if _, e := getWebhook(r, name); e != errno.OK {
+			return e
+		}
+	}
+	olds, err := storage.GetAlertWebhook(currentClusterId, alert)
+	if err != nil {
+		r.Logger().Error("GetAlertWebhook failed",
+			pigeon.Field("alert", alert),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_ALERT_WEBHOOK_FAILED
+	}
+	var webhook2Add []string
+	var webhook2Del []string
+	var tmap = make(map[string]bool)
+	for _, v := range olds {
+		tmap[v] = true
+	}
+	for _, v := range webhooks {
+		if _, ok := tmap[v]; !ok {
+			webhook2Add = append(webhook2Add, v)
+		} else {
+			delete(tmap, v)
+		}
+	}
+	for k := range tmap {
+		webhook2Del = append(webhook2Del, k)
+	}
+
+	err = storage.AddAlertWebhook(currentClusterId, alert, webhook2Add)
+	if err == nil {
+		err = storage.DeleteAlertWebhook(currentClusterId, alert, webhook2Del)
+	}
+	if err != nil {
+		r.Logger().Error("UpdateAlertWebhook failed",
+			pigeon.Field("alert", alert),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.UPDATE_ALERT_WEBHOOK_FAILED
+	}
+	return errno.OK
+}
@@ -0,0 +1,253 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package agent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+/*
+* Only one write user can be logined when enable.multiple.write.user.login is false, the
+* other write users login read-only and request the write lock:
+* 1. the holder finds the requests by user.writelock.get and releases by user.writelock.release
+* 2. the holder sending no write request for more than idle_release_seconds loses the lock once
+*    requested, the polling by user.writelock.get and other read requests do not count
+* 3. the lock goes to the earliest requester still logined
+* The write users are the ones can call any write method, by the legacy permission or by roles.
+* The requests by api key are not restricted by the lock, they are limited by the key scopes
+* and audited by the key id, so the automations keep working while the users hand over the lock.
+ */
+const (
+	WRITE_LOCK_IDLE_RELEASE = "access.write_lock.idle_release_seconds"
+
+	WRITE_LOCK_LOG_MODULE = "user"
+	WRITE_LOCK_LOG_METHOD = "user.writelock.handover"
+)
+
+type WriteLockRequest struct {
+	UserName    string `json:"userName"`
+	RequestTime int64  `json:"requestTime"`
+}
+
+type WriteLockInfo struct {
+	// empty if the lock is free
+	Holder           string `json:"holder"`
+	HolderLastActive int64  `json:"holderLastActive"`
+	// the holder is idle by the last write request
+	HolderLastWrite int64              `json:"holderLastWrite"`
+	IdleRelease     int64              `json:"idleReleaseSeconds"`
+	Requests        []WriteLockRequest `json:"requests"`
+}
+
+var (
+	// 0 means the holder is never released automatically
+	writeLockIdleRelease int64
+	writeLockRequests    = []WriteLockRequest{}
+	writeLockMutex       sync.Mutex
+)
+
+func initWriteLock(cfg *pigeon.Configure) {
+	writeLockIdleRelease = int64(cfg.GetConfig().GetInt(WRITE_LOCK_IDLE_RELEASE))
+}
+
+func isExclusiveWriteUser(r *pigeon.Request) bool {
+	return !r.GetConfig().GetBool(ENABLE_MULTIPLE_WRITER_USER_LOGIN)
+}
+
+func writeHandOverLog(r *pigeon.Request, from, to, reason string) {
+	content := fmt.Sprintf("write lock is handed over from %q to %q, reason: %s", from, to, reason)
+	r.Logger().Info("write lock handed over",
+		pigeon.Field("from", from),
+		pigeon.Field("to", to),
+		pigeon.Field("reason", reason),
+		pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_AUTH_USER], "", WRITE_LOCK_LOG_MODULE,
+		WRITE_LOCK_LOG_METHOD, errno.OK.Description(), content, errno.OK.Code())
+}
+
+// isWriteUserIdle reports whether the holder has sent no write request long enough to lose the lock
+func isWriteUserIdle(holder string) bool {
+	session, ok := storage.GetSessionOfUser(holder)
+	if !ok {
+		return true
+	}
+	return writeLockIdleRelease > 0 && session.LastWrite+writeLockIdleRelease < time.Now().Unix()
+}
+
+// releaseIdleWriteUser take the lock from the idle holder, used when a write user logins
+func releaseIdleWriteUser(r *pigeon.Request, holder string) bool {
+	writeLockMutex.Lock()
+	defer writeLockMutex.Unlock()
+	if !isWriteUserIdle(holder) {
+		return false
+	}
+	if err := storage.TransferWriteUser(holder, ""); err != nil {
+		return false
+	}
+	writeHandOverLog(r, holder, "", "idle")
+	return true
+}
+
+func removeWriteLockRequest(name string) {
+	for i, req := range writeLockRequests {
+		if req.UserName == name {
+			writeLockRequests = append(writeLockRequests[:i], writeLockRequests[i+1:]...)
+			return
+		}
+	}
+}
+
+// handOverWriteLock give the lock to the earliest requester if the lock is free or the holder is idle,
+// the requesters logged out or logined with write permission are dropped, should hold writeLockMutex
+func handOverWriteLock(r *pigeon.Request) {
+	requests := []WriteLockRequest{}
+	for _, req := range writeLockRequests {
+		session, ok := storage.GetSessionOfUser(req.UserName)
+		if ok && session.Permission&WRITE_PERM == 0 {
+			requests = append(requests, req)
+		}
+	}
+	writeLockRequests = requests
+	if len(writeLockRequests) == 0 {
+		return
+	}
+	holder := storage.GetLoginWriteUser()
+	reason := "released"
+	if holder != "" {
+		if !isWriteUserIdle(holder) {
+			return
+		}
+		reason = "idle"
+	}
+	to := writeLockRequests[0].UserName
+	err := storage.TransferWriteUser(holder, to)
+	if err != nil {
+		r.Logger().Warn("hand over write lock failed",
+			pigeon.Field("from", holder),
+			pigeon.Field("to", to),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return
+	}
+	writeLockRequests = writeLockRequests[1:]
+	writeHandOverLog(r, holder, to, reason)
+}
+
+func getWriteLockInfo() WriteLockInfo {
+	info := WriteLockInfo{
+		Holder:      storage.GetLoginWriteUser(),
+		IdleRelease: writeLockIdleRelease,
+		Requests:    append([]WriteLockRequest{}, writeLockRequests...),
+	}
+	if session, ok := storage.GetSessionOfUser(info.Holder); ok {
+		info.HolderLastActive = session.LastActive
+		info.HolderLastWrite = session.LastWrite
+	}
+	return info
+}
+
+// GetWriteLock returns the holder and requests of the write lock, which is polled by
+// the holder to find the requests and by the requesters to find whether granted
+func GetWriteLock(r *pigeon.Request) (interface{}, errno.Errno) {
+	if !isExclusiveWriteUser(r) {
+		return nil, errno.OPERATION_IS_NOT_PERMIT
+	}
+	writeLockMutex.Lock()
+	defer writeLockMutex.Unlock()
+	handOverWriteLock(r)
+	return getWriteLockInfo(), errno.OK
+}
+
+// RequestWriteLock queue the login user for the write lock, granted at once if the lock is free
+func RequestWriteLock(r *pigeon.Request) (interface{}, errno.Errno) {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if !isExclusiveWriteUser(r) {
+		return nil, errno.OPERATION_IS_NOT_PERMIT
+	}
+	user, err := storage.GetUser(name)
+	if err != nil {
+		r.Logger().Error("RequestWriteLock get user failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_FAILED
+	}
+	writer, err := storage.IsWriteUser(&user)
+	if err != nil {
+		r.Logger().Error("RequestWriteLock get user roles failed",
+			pigeon.Field("userName", name),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.GET_USER_ROLE_FAILED
+	}
+	if !writer {
+		r.Logger().Error("RequestWriteLock failed, user has no write permission",
+			pigeon.Field("userName", name),
+			pigeon.Field("permission", user.Permission),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return nil, errno.USER_HAS_NO_PERMISSION
+	}
+	writeLockMutex.Lock()
+	defer writeLockMutex.Unlock()
+	if storage.GetLoginWriteUser() != name {
+		removeWriteLockRequest(name)
+		writeLockRequests = append(writeLockRequests, WriteLockRequest{
+			UserName:    name,
+			RequestTime: time.Now().Unix(),
+		})
+		handOverWriteLock(r)
+	}
+	return getWriteLockInfo(), errno.OK
+}
+
+// ReleaseWriteLock give up the lock held by the login user, which goes to the earliest requester,
+// or cancel the request of the login user
+func ReleaseWriteLock(r *pigeon.Request) (interface{}, errno.Errno) {
+	name := r.HeadersIn[comm.HEADER_AUTH_USER]
+	if !isExclusiveWriteUser(r) {
+		return nil, errno.OPERATION_IS_NOT_PERMIT
+	}
+	writeLockMutex.Lock()
+	defer writeLockMutex.Unlock()
+	removeWriteLockRequest(name)
+	if storage.GetLoginWriteUser() == name {
+		err := storage.TransferWriteUser(name, "")
+		if err != nil {
+			r.Logger().Error("ReleaseWriteLock failed",
+				pigeon.Field("userName", name),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return nil, errno.UPDATE_USER_SESSION_FAILED
+		}
+		writeHandOverLog(r, name, "", "released")
+		handOverWriteLock(r)
+	}
+	return getWriteLockInfo(), errno.OK
+}
@@ -29,6 +29,8 @@ import (
 	"time"
 
 	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/api/curvebs/agent"
+	"github.com/opencurve/curve-manager/internal/auth"
 	"github.com/opencurve/curve-manager/internal/common"
 	"github.com/opencurve/curve-manager/internal/errno"
 	"github.com/opencurve/curve-manager/internal/storage"
@@ -40,6 +42,8 @@ const (
 	ACCESS_API_ENABLE_CHECK     = "access.api.enable_check"
 	ACCESS_API_EXPIRE_SECONDS   = "access.api.expire_seconds"
 	ACCESS_LOGIN_EXPIRE_SECONDS = "access.login.expire_seconds"
+	ACCESS_LOGIN_MAX_LIFETIME   = "access.login.max_lifetime_seconds"
+	ACCESS_TOTP_REQUIRE         = "access.totp.require_for_privileged"
 
 	READ_PERM    = 4
 	WRITE_PERM   = 2
@@ -51,8 +55,12 @@ var (
 	enableCheck bool
 	// api expire time, default 60s
 	apiExpireSeconds int
-	// login expire time, default 1800s
+	// login expire time when idle, default 1800s
 	loginExpireSeconds int
+	// login expire time since created regardless of activity, default 43200s
+	loginMaxLifetimeSeconds int
+	// whether users with WRITE or MANAGER permission must enroll two-factor authentication, default false
+	requireTOTPForPrivileged bool
 
 	// whether allow multiple user with write permission logined at same time
 	enableMultipleWriteUserLogin bool
@@ -68,7 +76,36 @@ var (
 		USER_GET:                    READ_PERM,
 		USER_UPDATE_PASSWORD:        READ_PERM,
 		USER_RESET_PASSWORD:         READ_PERM,
+		USER_RESET_PASSWORD_CONFIRM: READ_PERM,
 		USER_UPDATE_EMAIL:           READ_PERM,
+		USER_API_KEY_CREATE:         READ_PERM,
+		USER_API_KEY_LIST:           READ_PERM,
+		USER_API_KEY_REVOKE:         READ_PERM,
+		USER_UPDATE_ROLE:            READ_PERM + MANAGER_PERM,
+		USER_ROLE_LIST:              READ_PERM + MANAGER_PERM,
+		USER_ROLE_CREATE:            READ_PERM + MANAGER_PERM,
+		USER_ROLE_UPDATE:            READ_PERM + MANAGER_PERM,
+		USER_ROLE_DELETE:            READ_PERM + MANAGER_PERM,
+		USER_VOLUME_GRANT:           READ_PERM + MANAGER_PERM,
+		USER_VOLUME_REVOKE:          READ_PERM + MANAGER_PERM,
+		USER_VOLUME_GRANT_LIST:      READ_PERM + MANAGER_PERM,
+		USER_OIDC_LOGIN:             READ_PERM,
+		USER_OIDC_CALLBACK:          READ_PERM,
+		USER_LOGIN_TOTP:             READ_PERM,
+		USER_TOTP_ENROLL:            READ_PERM,
+		USER_TOTP_ACTIVATE:          READ_PERM,
+		USER_TOTP_DISABLE:           READ_PERM,
+		USER_TOTP_RECOVERY:          READ_PERM,
+		USER_TOTP_RESET:             READ_PERM + MANAGER_PERM,
+		USER_UNLOCK:                 READ_PERM + MANAGER_PERM,
+		USER_LOCKED_LIST:            READ_PERM + MANAGER_PERM,
+		USER_SESSION_LIST:           READ_PERM,
+		USER_SESSION_REVOKE:         READ_PERM,
+		USER_SESSION_KICK_WRITER:    READ_PERM + MANAGER_PERM,
+		USER_WRITE_LOCK_GET:         READ_PERM,
+		USER_WRITE_LOCK_REQUEST:     READ_PERM,
+		USER_WRITE_LOCK_RELEASE:     READ_PERM,
+		USER_RATE_LIMIT_GET:         READ_PERM,
 		STATUS_ETCD:                 READ_PERM,
 		STATUS_MDS:                  READ_PERM,
 		STATUS_SNAPSHOTCLONESERVER:  READ_PERM,
@@ -102,10 +139,36 @@ var (
 		GET_SYSTEM_ALERT:            READ_PERM,
 		GET_UNREAD_SYSTEM_ALERT_NUM: READ_PERM,
 		UPDATE_READ_SYSTEM_ALERT_ID: READ_PERM,
+		ACK_SYSTEM_ALERT:            READ_PERM + MANAGER_PERM,
+		UNACK_SYSTEM_ALERT:          READ_PERM + MANAGER_PERM,
+		ASSIGN_SYSTEM_ALERT:         READ_PERM + MANAGER_PERM,
+		ADD_SYSTEM_ALERT_NOTE:       READ_PERM + MANAGER_PERM,
+		LIST_SYSTEM_ALERT_NOTE:      READ_PERM,
 		GET_ALERT_CONF:              READ_PERM,
 		UPDATE_ALERT_CONF:           READ_PERM + MANAGER_PERM,
+		CREATE_ALERT_CONF:           READ_PERM + MANAGER_PERM,
+		DELETE_ALERT_CONF:           READ_PERM + MANAGER_PERM,
 		GET_ALERT_CANDIDATE:         READ_PERM + MANAGER_PERM,
 		UPDATE_ALERT_USER:           READ_PERM + MANAGER_PERM,
+		UPDATE_ALERT_WEBHOOK:        READ_PERM + MANAGER_PERM,
+		LIST_ALERT_SILENCE:          READ_PERM,
+		CREATE_ALERT_SILENCE:        READ_PERM + MANAGER_PERM,
+		UPDATE_ALERT_SILENCE:        READ_PERM + MANAGER_PERM,
+		DELETE_ALERT_SILENCE:        READ_PERM + MANAGER_PERM,
+		LIST_ESCALATION_POLICY:      READ_PERM,
+		CREATE_ESCALATION_POLICY:    READ_PERM + MANAGER_PERM,
+		UPDATE_ESCALATION_POLICY:    READ_PERM + MANAGER_PERM,
+		DELETE_ESCALATION_POLICY:    READ_PERM + MANAGER_PERM,
+		LIST_ONCALL_SCHEDULE:        READ_PERM,
+		CREATE_ONCALL_SCHEDULE:      READ_PERM + MANAGER_PERM,
+		UPDATE_ONCALL_SCHEDULE:      READ_PERM + MANAGER_PERM,
+		DELETE_ONCALL_SCHEDULE:      READ_PERM + MANAGER_PERM,
+		LIST_NOTIFICATION:           READ_PERM,
+		LIST_WEBHOOK:                READ_PERM + MANAGER_PERM,
+		CREATE_WEBHOOK:              READ_PERM + MANAGER_PERM,
+		UPDATE_WEBHOOK:              READ_PERM + MANAGER_PERM,
+		DELETE_WEBHOOK:              READ_PERM + MANAGER_PERM,
+		TEST_WEBHOOK:                READ_PERM + MANAGER_PERM,
 		DEPLOY_HOST_LIST:            READ_PERM + MANAGER_PERM,
 		DEPLOY_HOST_COMMIT:          READ_PERM + MANAGER_PERM,
 		DEPLOY_DISK_LIST:            READ_PERM + MANAGER_PERM,
@@ -119,10 +182,52 @@ var (
 		DEPLOY_CLUSTER_LIST:         READ_PERM,
 		DEPLOY_CLUSTER_CHECKOUT:     READ_PERM,
 	}
+
+	// methods which can only be called by a logined user, not by api key
+	apiKeyForbidden = map[string]bool{
+		USER_LOGOUT:          true,
+		USER_UPDATE_PASSWORD: true,
+		USER_API_KEY_CREATE:  true,
+		USER_TOTP_ENROLL:     true,
+		USER_TOTP_ACTIVATE:   true,
+		USER_TOTP_DISABLE:    true,
+		USER_TOTP_RECOVERY:   true,
+	}
+
+	// methods which can be called without authentication
+	anonymousMethods = map[string]bool{
+		USER_LOGIN:                  true,
+		USER_LOGIN_TOTP:             true,
+		USER_RESET_PASSWORD:         true,
+		USER_RESET_PASSWORD_CONFIRM: true,
+		USER_OIDC_LOGIN:             true,
+		USER_OIDC_CALLBACK:          true,
+	}
+
+	// methods which can be called by the users required but not enrolled two-factor authentication,
+	// changing password is allowed so that the users must change password can go on enrolling
+	totpEnrollMethods = map[string]bool{
+		USER_LOGOUT:          true,
+		USER_GET:             true,
+		USER_UPDATE_PASSWORD: true,
+		USER_TOTP_ENROLL:     true,
+		USER_TOTP_ACTIVATE:   true,
+	}
+
+	// methods which can be called by the users whose password is expired or must be changed
+	passwordChangeMethods = map[string]bool{
+		USER_LOGOUT:          true,
+		USER_GET:             true,
+		USER_UPDATE_PASSWORD: true,
+	}
 )
 
 func InitAccess(cfg *pigeon.Configure) {
 	enableCheck = cfg.GetConfig().GetBool(ACCESS_API_ENABLE_CHECK)
+	disableSignV1 = cfg.GetConfig().GetBool(ACCESS_API_DISABLE_SIGN_V1)
+	if n := cfg.GetConfig().GetInt(ACCESS_API_MAX_BODY_BYTES); n > 0 {
+		maxBodyBytes = int64(n)
+	}
 	apiExpireSeconds = cfg.GetConfig().GetInt(ACCESS_API_EXPIRE_SECONDS)
 	if apiExpireSeconds <= 0 {
 		apiExpireSeconds = 60
@@ -131,6 +236,11 @@ func InitAccess(cfg *pigeon.Configure) {
 	if loginExpireSeconds <= 0 {
 		loginExpireSeconds = 1800
 	}
+	loginMaxLifetimeSeconds = cfg.GetConfig().GetInt(ACCESS_LOGIN_MAX_LIFETIME)
+	if loginMaxLifetimeSeconds <= 0 {
+		loginMaxLifetimeSeconds = 43200
+	}
+	requireTOTPForPrivileged = cfg.GetConfig().GetBool(ACCESS_TOTP_REQUIRE)
 }
 
 func IsLoginRequest(r *pigeon.Request) bool {
@@ -141,18 +251,155 @@ func IsResetPasswordRequest(r *pigeon.Request) bool {
 	return r.Args[METHOD] == USER_RESET_PASSWORD
 }
 
+func isAnonymousRequest(r *pigeon.Request) bool {
+	return anonymousMethods[r.Args[METHOD]]
+}
+
 func NeedRecordLog(r *pigeon.Request) bool {
 	method := r.Args[METHOD]
 	return method == USER_LOGIN || method == USER_LOGOUT || method == USER_RESET_PASSWORD ||
+		method == USER_OIDC_CALLBACK || method == USER_LOGIN_TOTP || method == USER_TOTP_ACTIVATE ||
+		method == USER_TOTP_DISABLE ||
+		method == USER_API_KEY_CREATE || method == USER_API_KEY_REVOKE || method == USER_SESSION_REVOKE ||
 		(method2permission[method]&(WRITE_PERM+MANAGER_PERM) > 0 && method != GET_SYSTEM_LOG)
 }
 
-func checkPermission(r *pigeon.Request, perm int) bool {
+// checkPermission check the method is allowed by the legacy permission or roles of user
+func checkPermission(r *pigeon.Request, user *storage.UserInfo) bool {
 	method := r.Args[METHOD]
-	if p, ok := method2permission[method]; ok {
-		return p&perm == p
+	if _, ok := method2permission[method]; !ok {
+		return false
+	}
+	ok, err := storage.IsMethodAllowed(user, method)
+	if err != nil {
+		r.Logger().Error("checkPermission get user roles failed",
+			pigeon.Field("userName", user.UserName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	return ok
+}
+
+// initBuiltinRole map the legacy permission bits onto built-in roles:
+// reader: methods only require READ_PERM
+// writer: methods require WRITE_PERM
+// manager: methods require MANAGER_PERM
+func initBuiltinRole() error {
+	roles := map[string][]string{
+		storage.ROLE_READER:  {},
+		storage.ROLE_WRITER:  {},
+		storage.ROLE_MANAGER: {},
+	}
+	for method, perm := range method2permission {
+		if perm == READ_PERM {
+			roles[storage.ROLE_READER] = append(roles[storage.ROLE_READER], method)
+		}
+		if perm&WRITE_PERM == WRITE_PERM {
+			roles[storage.ROLE_WRITER] = append(roles[storage.ROLE_WRITER], method)
+		}
+		if perm&MANAGER_PERM == MANAGER_PERM {
+			roles[storage.ROLE_MANAGER] = append(roles[storage.ROLE_MANAGER], method)
+		}
+	}
+	for name, methods := range roles {
+		sort.Strings(methods)
+		if err := storage.InitBuiltinRole(name, methods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckRoleMethods check the methods of role are all known, "prefix.*" should match at least one method
+func CheckRoleMethods(r *pigeon.Request, methods []string) errno.Errno {
+	for _, m := range methods {
+		role := storage.Role{Methods: []string{m}}
+		matched := false
+		for method := range method2permission {
+			if role.Allow(method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			r.Logger().Error("CheckRoleMethods failed, unknown method",
+				pigeon.Field("method", m),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.INVALID_ROLE_METHOD
+		}
+	}
+	return errno.OK
+}
+
+// checkTOTPRequired restrict the privileged users to enroll two-factor authentication
+// first if required, the users of oidc are left to the issuer. The users granted write
+// or manager methods by roles are privileged as well as the ones of legacy permission
+func checkTOTPRequired(r *pigeon.Request, user *storage.UserInfo) errno.Errno {
+	if !requireTOTPForPrivileged || user.Algorithm == auth.OIDC_AUTHENTICATOR_NAME ||
+		totpEnrollMethods[r.Args[METHOD]] {
+		return errno.OK
+	}
+	privileged, err := storage.IsPrivilegedUser(user)
+	if err != nil {
+		r.Logger().Error("checkTOTPRequired get user roles failed",
+			pigeon.Field("userName", user.UserName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_ROLE_FAILED
+	}
+	if !privileged {
+		return errno.OK
+	}
+	enabled, err := storage.IsTOTPEnabled(user.UserName)
+	if err != nil {
+		r.Logger().Error("checkTOTPRequired get user totp failed",
+			pigeon.Field("userName", user.UserName),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_TOTP_FAILED
+	}
+	if !enabled {
+		r.Logger().Error("checkTOTPRequired failed, two-factor authentication not enrolled",
+			pigeon.Field("userName", user.UserName),
+			pigeon.Field("method", r.Args[METHOD]),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.TOTP_REQUIRED
 	}
-	return false
+	return errno.OK
+}
+
+// checkWriteLock reject the write methods from a read-only session of the write user,
+// which waits for the write lock held by another user. checkPermission has allowed the
+// write method, so the user is a writer by either the legacy permission or roles.
+// The requests by api key are not restricted by the write lock
+func checkWriteLock(r *pigeon.Request, user *storage.UserInfo, sessionPerm int) errno.Errno {
+	if sessionPerm < 0 || method2permission[r.Args[METHOD]]&WRITE_PERM == 0 {
+		return errno.OK
+	}
+	if sessionPerm&WRITE_PERM == WRITE_PERM {
+		// the holder is idle by write requests, not by the polling ones
+		storage.TouchWriteUser(user.UserName)
+		return errno.OK
+	}
+	r.Logger().Error("checkWriteLock failed, session is read-only",
+		pigeon.Field("userName", user.UserName),
+		pigeon.Field("method", r.Args[METHOD]),
+		pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	return errno.WRITE_LOCK_NOT_HELD
+}
+
+// checkPassWordExpired restrict the users to change password first if expired or required,
+// the requests by api key are not affected
+func checkPassWordExpired(r *pigeon.Request, user *storage.UserInfo) errno.Errno {
+	if isApiKeyRequest(r) || passwordChangeMethods[r.Args[METHOD]] || !storage.IsPassWordExpired(user) {
+		return errno.OK
+	}
+	r.Logger().Error("checkPassWordExpired failed, password must be changed",
+		pigeon.Field("userName", user.UserName),
+		pigeon.Field("method", r.Args[METHOD]),
+		pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+	return errno.PASSWORD_EXPIRED
 }
 
 func checkTimeOut(r *pigeon.Request) bool {
@@ -177,14 +424,26 @@ func checkTimeOut(r *pigeon.Request) bool {
 }
 
 /*
-* algorithm：
+* algorithm v1, used if X-Pigeon-Auth-Sign-Version is absent：
 * 1. String-Items: HTTP-Method; URI; Args; Timestamp; Token
 * 2. Sorted-Items: sort String-Items based alphabetically
 * 3. Sign-String: join Sorted-Items with ":"
 * 4. Sign: MD532Little(Sign-String)
  */
 func checkSignature(r *pigeon.Request, data interface{}) bool {
+	version := r.HeadersIn[comm.HEADER_AUTH_SIGN_VERSION]
+	if version == SIGN_VERSION_V2 {
+		return checkSignatureV2(r)
+	} else if (version != "" && version != SIGN_VERSION_V1) || disableSignV1 {
+		r.Logger().Error("checkSignature failed, sign version not supported",
+			pigeon.Field("version", version),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
 	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
+	if isApiKeyRequest(r) {
+		token = r.HeadersIn[comm.HEADER_AUTH_API_KEY]
+	}
 	inSign := r.HeadersIn[comm.HEADER_AUTH_SIGN]
 	timeStamp := r.HeadersIn[comm.HEADER_AUTH_TIMESTAMP]
 	stringItems := []string{r.Method, r.Uri, timeStamp, token}
@@ -212,29 +471,129 @@ func checkSignature(r *pigeon.Request, data interface{}) bool {
 	return true
 }
 
+// checkToken returns the permission of the session, which lacks WRITE_PERM if the
+// session is logined read-only or has given up the write lock
 func checkToken(r *pigeon.Request) (bool, int) {
 	token := r.HeadersIn[comm.HEADER_AUTH_TOKEN]
-	return storage.CheckSession(token, loginExpireSeconds)
+	ok, perm := storage.CheckSession(token, loginExpireSeconds, loginMaxLifetimeSeconds)
+	if ok {
+		r.HeadersIn[comm.HEADER_AUTH_USER] = storage.GetLoginUserByToken(token)
+	}
+	return ok, perm
+}
+
+// request without session token is authenticated by api key if provided
+func isApiKeyRequest(r *pigeon.Request) bool {
+	return r.HeadersIn[comm.HEADER_AUTH_TOKEN] == "" && r.HeadersIn[comm.HEADER_AUTH_API_KEY] != ""
+}
+
+// IsValidApiKeyScope reports whether method can be granted to an api key of user
+func IsValidApiKeyScope(user *storage.UserInfo, method string) bool {
+	if _, ok := method2permission[method]; !ok || apiKeyForbidden[method] {
+		return false
+	}
+	ok, err := storage.IsMethodAllowed(user, method)
+	return err == nil && ok
+}
+
+// CheckApiKeyScopes check the scopes are all permitted to the login user
+func CheckApiKeyScopes(r *pigeon.Request, scopes []string) errno.Errno {
+	user, err := storage.GetUser(r.HeadersIn[comm.HEADER_AUTH_USER])
+	if err != nil {
+		r.Logger().Error("CheckApiKeyScopes get user failed",
+			pigeon.Field("userName", r.HeadersIn[comm.HEADER_AUTH_USER]),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.GET_USER_FAILED
+	}
+	for _, scope := range scopes {
+		if !IsValidApiKeyScope(&user, scope) {
+			r.Logger().Error("CheckApiKeyScopes failed",
+				pigeon.Field("userName", user.UserName),
+				pigeon.Field("scope", scope),
+				pigeon.Field("user perm", user.Permission),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.INVALID_API_KEY_SCOPE
+		}
+	}
+	return errno.OK
+}
+
+func checkApiKey(r *pigeon.Request) errno.Errno {
+	key, err := storage.CheckApiKey(r.HeadersIn[comm.HEADER_AUTH_API_KEY], r.Context.ClientIP())
+	if err != nil {
+		r.Logger().Error("checkApiKey failed",
+			pigeon.Field("id", key.Id),
+			pigeon.Field("ip", r.Context.ClientIP()),
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.USER_IS_UNAUTHORIZED
+	}
+	method := r.Args[METHOD]
+	if apiKeyForbidden[method] || !key.HasScope(method) {
+		r.Logger().Error("checkApiKey failed, method out of scope",
+			pigeon.Field("id", key.Id),
+			pigeon.Field("method", method),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.OPERATION_IS_NOT_PERMIT
+	}
+	// the key acts as its owner, so follows the current permission of owner
+	r.HeadersIn[comm.HEADER_AUTH_USER] = key.UserName
+	r.HeadersIn[comm.HEADER_LOG_API_KEY] = key.Id
+	return errno.OK
 }
 
 func AccessAllowed(r *pigeon.Request, data interface{}) errno.Errno {
-	if !IsLoginRequest(r) && !IsResetPasswordRequest(r) {
-		// check user token valied
-		ok, perm := checkToken(r)
-		if !ok {
+	// these headers are only filled by server
+	delete(r.HeadersIn, comm.HEADER_AUTH_USER)
+	delete(r.HeadersIn, comm.HEADER_LOG_API_KEY)
+	// the ip limit also counts the requests failed to authenticate
+	if e := agent.CheckIPRateLimit(r); e != errno.OK {
+		return e
+	}
+	if !isAnonymousRequest(r) {
+		// the requests by api key are not restricted by the write lock
+		sessionPerm := -1
+		if isApiKeyRequest(r) {
+			if e := checkApiKey(r); e != errno.OK {
+				return e
+			}
+		} else if ok, perm := checkToken(r); !ok {
+			// check user token valied
 			r.Logger().Error("checkToken failed",
 				pigeon.Field("token", r.HeadersIn[comm.HEADER_AUTH_TOKEN]),
 				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 			return errno.USER_IS_UNAUTHORIZED
+		} else {
+			sessionPerm = perm
+		}
+		// always get user from db, so the changes of permission and roles take effect immediately
+		user, err := storage.GetUser(r.HeadersIn[comm.HEADER_AUTH_USER])
+		if err != nil {
+			r.Logger().Error("AccessAllowed get user failed",
+				pigeon.Field("userName", r.HeadersIn[comm.HEADER_AUTH_USER]),
+				pigeon.Field("error", err),
+				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+			return errno.USER_IS_UNAUTHORIZED
 		}
 		// check request method is allowed to this user
-		if !checkPermission(r, perm) {
+		if !checkPermission(r, &user) {
 			r.Logger().Error("checkToken checkPermission failed",
 				pigeon.Field("method", r.Args[METHOD]),
-				pigeon.Field("user perm", perm),
+				pigeon.Field("userName", user.UserName),
+				pigeon.Field("user perm", user.Permission),
 				pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
 			return errno.OPERATION_IS_NOT_PERMIT
 		}
+		if e := checkWriteLock(r, &user, sessionPerm); e != errno.OK {
+			return e
+		}
+		if e := checkPassWordExpired(r, &user); e != errno.OK {
+			return e
+		}
+		if e := checkTOTPRequired(r, &user); e != errno.OK {
+			return e
+		}
 		if enableCheck {
 			// check request ttl and sigenatrue
 			if !checkTimeOut(r) || !checkSignature(r, data) {
@@ -242,5 +601,6 @@ func AccessAllowed(r *pigeon.Request, data interface{}) errno.Errno {
 			}
 		}
 	}
-	return errno.OK
+	// the anonymous requests are limited by client ip
+	return agent.CheckUserRateLimit(r, r.HeadersIn[comm.HEADER_AUTH_USER], r.Args[METHOD])
 }
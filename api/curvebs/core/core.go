@@ -40,16 +40,45 @@ const (
 	METHOD = "method"
 
 	// user
-	USER_LOGIN             = "user.login"
-	USER_LOGOUT            = "user.logout"
-	USER_CREATE            = "user.create"
-	USER_DELETE            = "user.delete"
-	USER_UPDATE_PASSWORD   = "user.update.password"
-	USER_RESET_PASSWORD    = "user.reset.password"
-	USER_UPDATE_EMAIL      = "user.update.email"
-	USER_UPDATE_PERMISSION = "user.update.permission"
-	USER_LIST              = "user.list"
-	USER_GET               = "user.get"
+	USER_LOGIN                  = "user.login"
+	USER_LOGOUT                 = "user.logout"
+	USER_CREATE                 = "user.create"
+	USER_DELETE                 = "user.delete"
+	USER_UPDATE_PASSWORD        = "user.update.password"
+	USER_RESET_PASSWORD         = "user.reset.password"
+	USER_RESET_PASSWORD_CONFIRM = "user.reset.password.confirm"
+	USER_UPDATE_EMAIL           = "user.update.email"
+	USER_UPDATE_PERMISSION      = "user.update.permission"
+	USER_LIST                   = "user.list"
+	USER_GET                    = "user.get"
+	USER_API_KEY_CREATE         = "user.apikey.create"
+	USER_API_KEY_LIST           = "user.apikey.list"
+	USER_API_KEY_REVOKE         = "user.apikey.revoke"
+	USER_UPDATE_ROLE            = "user.update.role"
+	USER_ROLE_LIST              = "user.role.list"
+	USER_ROLE_CREATE            = "user.role.create"
+	USER_ROLE_UPDATE            = "user.role.update"
+	USER_ROLE_DELETE            = "user.role.delete"
+	USER_VOLUME_GRANT           = "user.volume.grant"
+	USER_VOLUME_REVOKE          = "user.volume.revoke"
+	USER_VOLUME_GRANT_LIST      = "user.volume.grant.list"
+	USER_OIDC_LOGIN             = "user.oidc.login"
+	USER_OIDC_CALLBACK          = "user.oidc.callback"
+	USER_LOGIN_TOTP             = "user.login.totp"
+	USER_TOTP_ENROLL            = "user.totp.enroll"
+	USER_TOTP_ACTIVATE          = "user.totp.activate"
+	USER_TOTP_DISABLE           = "user.totp.disable"
+	USER_TOTP_RECOVERY          = "user.totp.recovery.regenerate"
+	USER_TOTP_RESET             = "user.totp.reset"
+	USER_UNLOCK                 = "user.unlock"
+	USER_LOCKED_LIST            = "user.locked.list"
+	USER_SESSION_LIST           = "user.session.list"
+	USER_SESSION_REVOKE         = "user.session.revoke"
+	USER_SESSION_KICK_WRITER    = "user.session.kick.writer"
+	USER_WRITE_LOCK_GET         = "user.writelock.get"
+	USER_WRITE_LOCK_REQUEST     = "user.writelock.request"
+	USER_WRITE_LOCK_RELEASE     = "user.writelock.release"
+	USER_RATE_LIMIT_GET         = "user.ratelimit.get"
 
 	// manager
 	STATUS_ETCD                 = "status.etcd"
@@ -85,10 +114,36 @@ const (
 	GET_SYSTEM_ALERT            = "alert.get"
 	GET_UNREAD_SYSTEM_ALERT_NUM = "alert.unread.num.get"
 	UPDATE_READ_SYSTEM_ALERT_ID = "alert.read.id.update"
+	ACK_SYSTEM_ALERT            = "alert.ack"
+	UNACK_SYSTEM_ALERT          = "alert.unack"
+	ASSIGN_SYSTEM_ALERT         = "alert.assign"
+	ADD_SYSTEM_ALERT_NOTE       = "alert.note.add"
+	LIST_SYSTEM_ALERT_NOTE      = "alert.note.list"
 	GET_ALERT_CONF              = "alert.conf.get"
 	UPDATE_ALERT_CONF           = "alert.conf.update"
+	CREATE_ALERT_CONF           = "alert.conf.create"
+	DELETE_ALERT_CONF           = "alert.conf.delete"
 	GET_ALERT_CANDIDATE         = "alert.candidate.get"
 	UPDATE_ALERT_USER           = "alert.user.update"
+	UPDATE_ALERT_WEBHOOK        = "alert.webhook.update"
+	LIST_ALERT_SILENCE          = "alert.silence.list"
+	CREATE_ALERT_SILENCE        = "alert.silence.create"
+	UPDATE_ALERT_SILENCE        = "alert.silence.update"
+	DELETE_ALERT_SILENCE        = "alert.silence.delete"
+	LIST_ESCALATION_POLICY      = "alert.escalation.list"
+	CREATE_ESCALATION_POLICY    = "alert.escalation.create"
+	UPDATE_ESCALATION_POLICY    = "alert.escalation.update"
+	DELETE_ESCALATION_POLICY    = "alert.escalation.delete"
+	LIST_ONCALL_SCHEDULE        = "alert.oncall.list"
+	CREATE_ONCALL_SCHEDULE      = "alert.oncall.create"
+	UPDATE_ONCALL_SCHEDULE      = "alert.oncall.update"
+	DELETE_ONCALL_SCHEDULE      = "alert.oncall.delete"
+	LIST_NOTIFICATION           = "notification.list"
+	LIST_WEBHOOK                = "webhook.list"
+	CREATE_WEBHOOK              = "webhook.create"
+	UPDATE_WEBHOOK              = "webhook.update"
+	DELETE_WEBHOOK              = "webhook.delete"
+	TEST_WEBHOOK                = "webhook.test"
 
 	// deploy
 	DEPLOY_HOST_LIST          = "deploy.host.list"
@@ -114,8 +169,8 @@ func Exit(r *pigeon.Request, code errno.Errno) bool {
 		r.HeadersOut[comm.HEADER_ERROR_CODE] = strconv.Itoa(code.Code())
 	}
 	if r.HeadersIn[comm.HEADER_LOG_ENABLE] == "true" {
-		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], BELONG[r.Args[METHOD]],
-			r.Args[METHOD], code.Description(), r.HeadersIn[comm.HEADER_LOG_CONTENT], code.Code())
+		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], r.HeadersIn[comm.HEADER_LOG_API_KEY],
+			BELONG[r.Args[METHOD]], r.Args[METHOD], code.Description(), r.HeadersIn[comm.HEADER_LOG_CONTENT], code.Code())
 	}
 	return r.Exit(code.HTTPCode())
 }
@@ -132,8 +187,8 @@ func ExitSuccessWithData(r *pigeon.Request, data interface{}) bool {
 		"errorMsg":  "success",
 	})
 	if r.HeadersIn[comm.HEADER_LOG_ENABLE] == "true" {
-		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], BELONG[r.Args[METHOD]],
-			r.Args[METHOD], "success", r.HeadersIn[comm.HEADER_LOG_CONTENT], 0)
+		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], r.HeadersIn[comm.HEADER_LOG_API_KEY],
+			BELONG[r.Args[METHOD]], r.Args[METHOD], "success", r.HeadersIn[comm.HEADER_LOG_CONTENT], 0)
 	}
 	return r.Exit(200)
 }
@@ -24,6 +24,7 @@ package core
 
 import (
 	"github.com/opencurve/curve-manager/api/curvebs/agent"
+	"github.com/opencurve/curve-manager/internal/auth"
 	"github.com/opencurve/curve-manager/internal/email"
 	"github.com/opencurve/curve-manager/internal/storage"
 	"github.com/opencurve/pigeon"
@@ -39,6 +40,18 @@ func Init(cfg *pigeon.Configure, logger *pigeon.Logger) error {
 		return err
 	}
 
+	// init external authenticator, e.g. ldap
+	err = auth.Init(cfg)
+	if err != nil {
+		return err
+	}
+
+	// init built-in roles of the legacy permissions
+	err = initBuiltinRole()
+	if err != nil {
+		return err
+	}
+
 	// init agent
 	err = agent.Init(cfg, logger)
 	if err != nil {
@@ -52,6 +65,5 @@ func Init(cfg *pigeon.Configure, logger *pigeon.Logger) error {
 	}
 
 	// init email which used to reset password and some system notifications
-	email.Init(cfg)
-	return nil
+	return email.Init(cfg)
 }
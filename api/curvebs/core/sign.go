@@ -0,0 +1,217 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package core
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	// reject the requests signed by the md5 scheme after all clients migrated
+	ACCESS_API_DISABLE_SIGN_V1 = "access.api.disable_sign_v1"
+	// the body is read into memory for the signature, the larger ones are rejected
+	ACCESS_API_MAX_BODY_BYTES = "access.api.max_body_bytes"
+	DEFAULT_MAX_BODY_BYTES    = 1 << 20
+
+	SIGN_VERSION_V1 = "v1"
+	SIGN_VERSION_V2 = "v2"
+
+	MODULE_CTX_BODY = "core.body"
+
+	MIN_NONCE_LENGTH = 16
+	MAX_NONCE_LENGTH = 128
+	// reject the requests rather than forget the nonces when there are too many
+	MAX_NONCE_CACHE_SIZE = 100000
+)
+
+var (
+	disableSignV1 bool
+	maxBodyBytes  int64 = DEFAULT_MAX_BODY_BYTES
+
+	// nonce -> unix seconds after which the timestamp of request is expired
+	nonceCache      = map[string]int64{}
+	nonceCacheMutex sync.Mutex
+)
+
+// ReadBody keep the raw body for signature, which is consumed by binding, the body is at most
+// maxBodyBytes
+func ReadBody(r *pigeon.Request) errno.Errno {
+	if r.Context.Request.Body == nil {
+		return errno.OK
+	}
+	if r.Context.Request.ContentLength > maxBodyBytes {
+		r.Logger().Error("request body is too large",
+			pigeon.Field("length", r.Context.Request.ContentLength),
+			pigeon.Field("limit", maxBodyBytes),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.REQUEST_BODY_TOO_LARGE
+	}
+	// the reader fails after reading exactly maxBodyBytes if the body is larger
+	body, err := io.ReadAll(http.MaxBytesReader(r.Context.Writer, r.Context.Request.Body, maxBodyBytes))
+	if err != nil && int64(len(body)) >= maxBodyBytes {
+		r.Logger().Error("request body is too large",
+			pigeon.Field("limit", maxBodyBytes),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.REQUEST_BODY_TOO_LARGE
+	} else if err != nil {
+		r.Logger().Error("read request body failed",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return errno.BAD_REQUEST_FORM_PARAM
+	}
+	r.Context.Request.Body = io.NopCloser(bytes.NewReader(body))
+	r.SetModuleCtx(MODULE_CTX_BODY, body)
+	return errno.OK
+}
+
+// canonicalBody compact the json body with sorted keys, other bodies are signed as they are
+func canonicalBody(r *pigeon.Request) ([]byte, error) {
+	body, _ := r.GetModuleCtx(MODULE_CTX_BODY).([]byte)
+	if len(body) == 0 || !strings.HasPrefix(r.Context.ContentType(), "application/json") {
+		return body, nil
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func canonicalArgs(r *pigeon.Request) string {
+	items := []string{}
+	for k, v := range r.Args {
+		items = append(items, k+"="+v)
+	}
+	sort.Strings(items)
+	return strings.Join(items, "&")
+}
+
+// useNonce record the nonce until expire, returns false if it is used before
+func useNonce(nonce string, expire, now int64) bool {
+	nonceCacheMutex.Lock()
+	defer nonceCacheMutex.Unlock()
+	if t, ok := nonceCache[nonce]; ok && t >= now {
+		return false
+	}
+	if len(nonceCache) >= MAX_NONCE_CACHE_SIZE {
+		for k, t := range nonceCache {
+			if t < now {
+				delete(nonceCache, k)
+			}
+		}
+		if len(nonceCache) >= MAX_NONCE_CACHE_SIZE {
+			return false
+		}
+	}
+	nonceCache[nonce] = expire
+	return true
+}
+
+/*
+* algorithm v2:
+* 1. String-To-Sign: join with "\n"
+*    "v2"; HTTP-Method; URI; Args sorted and joined as "k1=v1&k2=v2"; Timestamp; Nonce;
+*    hex(SHA256(Body)), the json body is compacted with sorted keys and no html escaping
+* 2. Sign: hex(HMAC-SHA256(Key, String-To-Sign)), the key is the sign secret returned by
+*    login, or the api key itself for the requests by api key
+* 3. the nonce can not be reused within access.api.expire_seconds
+ */
+func checkSignatureV2(r *pigeon.Request) bool {
+	var key string
+	if isApiKeyRequest(r) {
+		key = r.HeadersIn[comm.HEADER_AUTH_API_KEY]
+	} else {
+		key = storage.GetSessionSignSecret(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
+	}
+	nonce := r.HeadersIn[comm.HEADER_AUTH_NONCE]
+	timeStamp := r.HeadersIn[comm.HEADER_AUTH_TIMESTAMP]
+	if key == "" || len(nonce) < MIN_NONCE_LENGTH || len(nonce) > MAX_NONCE_LENGTH {
+		r.Logger().Error("checkSignatureV2 failed, no sign secret or invalid nonce",
+			pigeon.Field("nonce", nonce),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	// the timestamp from future is also rejected, so the nonce need not be kept for long
+	inTime, _ := strconv.ParseInt(timeStamp, 10, 64)
+	now := time.Now().Unix()
+	if inTime > now+int64(apiExpireSeconds) {
+		r.Logger().Error("checkSignatureV2 failed, time from future",
+			pigeon.Field("inTime", inTime),
+			pigeon.Field("now", now),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	body, err := canonicalBody(r)
+	if err != nil {
+		r.Logger().Error("checkSignatureV2 failed, invalid body",
+			pigeon.Field("error", err),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	bodyHash := sha256.Sum256(body)
+	signStr := strings.Join([]string{SIGN_VERSION_V2, r.Method, r.Uri, canonicalArgs(r), timeStamp, nonce,
+		hex.EncodeToString(bodyHash[:])}, "\n")
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(signStr))
+	sign := hex.EncodeToString(mac.Sum(nil))
+	inSign := r.HeadersIn[comm.HEADER_AUTH_SIGN]
+	if !hmac.Equal([]byte(inSign), []byte(sign)) {
+		r.Logger().Error("checkSignatureV2 failed",
+			pigeon.Field("signStr", signStr),
+			pigeon.Field("inSign", inSign),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	// only the verified requests consume the nonce
+	if !useNonce(nonce, inTime+int64(apiExpireSeconds), now) {
+		r.Logger().Error("checkSignatureV2 failed, nonce is reused",
+			pigeon.Field("nonce", nonce),
+			pigeon.Field("requestId", r.HeadersIn[comm.HEADER_REQUEST_ID]))
+		return false
+	}
+	return true
+}
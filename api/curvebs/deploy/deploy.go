@@ -79,8 +79,8 @@ func getAdmMethod(method string) string {
 func DealDeploy(r *pigeon.Request, ctx *Context) bool {
 	ret := agent.ProxyPass(r, ctx.Data, getAdmMethod(r.Args[core.METHOD]))
 	if core.NeedRecordLog(r) {
-		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], core.BELONG[r.Args[core.METHOD]],
-			r.Args[core.METHOD], "proxy", r.HeadersIn[comm.HEADER_LOG_CONTENT], r.Status)
+		agent.WriteSystemLog(r.Context.ClientIP(), r.HeadersIn[comm.HEADER_LOG_USER], r.HeadersIn[comm.HEADER_LOG_API_KEY],
+			core.BELONG[r.Args[core.METHOD]], r.Args[core.METHOD], "proxy", r.HeadersIn[comm.HEADER_LOG_CONTENT], r.Status)
 	}
 	return ret
 }
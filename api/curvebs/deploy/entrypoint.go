@@ -30,7 +30,6 @@ import (
 	comm "github.com/opencurve/curve-manager/api/common"
 	"github.com/opencurve/curve-manager/api/curvebs/core"
 	"github.com/opencurve/curve-manager/internal/errno"
-	"github.com/opencurve/curve-manager/internal/storage"
 	"github.com/opencurve/pigeon"
 )
 
@@ -53,6 +52,9 @@ func Entrypoint(r *pigeon.Request) bool {
 
 	vType := reflect.TypeOf(request.vType)
 	data := reflect.New(vType).Interface()
+	if e := core.ReadBody(r); e != errno.OK {
+		return core.Exit(r, e)
+	}
 	if err := r.BindBody(data); err != nil {
 		r.Logger().Error("bad request form param",
 			pigeon.Field("error", err),
@@ -69,7 +71,7 @@ func Entrypoint(r *pigeon.Request) bool {
 		c, _ := json.Marshal(data)
 		r.HeadersIn[comm.HEADER_LOG_ENABLE] = "true"
 		r.HeadersIn[comm.HEADER_LOG_CONTENT] = string(c)
-		r.HeadersIn[comm.HEADER_LOG_USER] = storage.GetLoginUserByToken(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
+		r.HeadersIn[comm.HEADER_LOG_USER] = r.HeadersIn[comm.HEADER_AUTH_USER]
 	}
 
 	return request.handler(r, &Context{data})
@@ -26,12 +26,14 @@ import (
 	"github.com/opencurve/curve-manager/api/curvebs/agent"
 	"github.com/opencurve/curve-manager/api/curvebs/core"
 	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
 	"github.com/opencurve/pigeon"
 )
 
 func GetSysAlert(r *pigeon.Request, ctx *Context) bool {
 	data := ctx.Data.(*GetSysAlertRequest)
-	logs, err := agent.GetSysAlert(r, data.Start, data.End, data.Page, data.Size, data.Name, data.Level, data.Content)
+	logs, err := agent.GetSysAlert(r, data.Start, data.End, data.Page, data.Size, data.Name, data.Level, data.State,
+		data.Status, data.Assignee, data.Content)
 	if err != errno.OK {
 		return core.Exit(r, err)
 	}
@@ -52,6 +54,42 @@ func UpdateReadSysAlertId(r *pigeon.Request, ctx *Context) bool {
 	return core.Exit(r, err)
 }
 
+func AckSysAlert(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*AckSysAlertRequest)
+	err := agent.AckSysAlert(r, data.Id, data.Note)
+	return core.Exit(r, err)
+}
+
+func UnackSysAlert(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UnackSysAlertRequest)
+	err := agent.UnackSysAlert(r, data.Id)
+	return core.Exit(r, err)
+}
+
+func AssignSysAlert(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*AssignSysAlertRequest)
+	err := agent.AssignSysAlert(r, data.Id, data.Assignee, core.ACK_SYSTEM_ALERT)
+	return core.Exit(r, err)
+}
+
+func AddSysAlertNote(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*AddSysAlertNoteRequest)
+	id, err := agent.AddSysAlertNote(r, data.Id, data.Content)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, id)
+}
+
+func ListSysAlertNote(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ListSysAlertNoteRequest)
+	notes, err := agent.ListSysAlertNote(r, data.Id)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, notes)
+}
+
 func GetAlertConf(r *pigeon.Request, ctx *Context) bool {
 	confs, err := agent.GetAlertConf(r)
 	if err != errno.OK {
@@ -62,8 +100,43 @@ func GetAlertConf(r *pigeon.Request, ctx *Context) bool {
 
 func UpdateAlertConf(r *pigeon.Request, ctx *Context) bool {
 	data := ctx.Data.(*UpdateAlertConfRequest)
-	err := agent.UpdateAlertConf(r, *data.Enable, data.Interval, data.Times,
-		data.Rule, data.Name, data.AlertUsers)
+	err := agent.UpdateAlertConf(r, &storage.AlertConf{
+		Name:       data.Name,
+		Level:      storage.GetLevel(data.Level),
+		Interval:   data.Interval,
+		Times:      data.Times,
+		EnableBool: *data.Enable,
+		Rule:       data.Rule,
+		Desc:       data.Desc,
+		Expr:       data.Expr,
+		Op:         data.Op,
+		Threshold:  data.Threshold,
+		ForSec:     data.For,
+		Summary:    data.Summary,
+	}, data.AlertUsers)
+	return core.Exit(r, err)
+}
+
+func CreateAlertConf(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateAlertConfRequest)
+	err := agent.CreateAlertConf(r, &storage.AlertConf{
+		Name:       data.Name,
+		Level:      storage.GetLevel(data.Level),
+		Interval:   data.Interval,
+		EnableBool: *data.Enable,
+		Desc:       data.Desc,
+		Expr:       data.Expr,
+		Op:         data.Op,
+		Threshold:  data.Threshold,
+		ForSec:     data.For,
+		Summary:    data.Summary,
+	}, data.AlertUsers)
+	return core.Exit(r, err)
+}
+
+func DeleteAlertConf(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteAlertConfRequest)
+	err := agent.DeleteAlertConf(r, data.Name)
 	return core.Exit(r, err)
 }
 
@@ -80,3 +153,136 @@ func UpdateAlertUser(r *pigeon.Request, ctx *Context) bool {
 	err := agent.UpdateAlertUser(r, data.Alert, data.User)
 	return core.Exit(r, err)
 }
+
+func ListAlertSilence(r *pigeon.Request, ctx *Context) bool {
+	silences, err := agent.ListAlertSilence(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, silences)
+}
+
+func CreateAlertSilence(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateAlertSilenceRequest)
+	id, err := agent.CreateAlertSilence(r, &storage.Silence{
+		Name:        data.Name,
+		LevelStr:    data.Level,
+		Target:      data.Target,
+		StartTimeMs: data.StartTime,
+		EndTimeMs:   data.EndTime,
+		Weekdays:    data.Weekdays,
+		Comment:     data.Comment,
+	}, data.WindowStart, data.WindowEnd)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, id)
+}
+
+func UpdateAlertSilence(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateAlertSilenceRequest)
+	err := agent.UpdateAlertSilence(r, &storage.Silence{
+		Id:          data.Id,
+		Name:        data.Name,
+		LevelStr:    data.Level,
+		Target:      data.Target,
+		StartTimeMs: data.StartTime,
+		EndTimeMs:   data.EndTime,
+		Weekdays:    data.Weekdays,
+		Comment:     data.Comment,
+	}, data.WindowStart, data.WindowEnd)
+	return core.Exit(r, err)
+}
+
+func DeleteAlertSilence(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteAlertSilenceRequest)
+	err := agent.DeleteAlertSilence(r, data.Id)
+	return core.Exit(r, err)
+}
+
+func toEscalationTiers(tiers []EscalationTier) []storage.EscalationTier {
+	ret := []storage.EscalationTier{}
+	for _, tier := range tiers {
+		ret = append(ret, storage.EscalationTier{
+			DelayMin: tier.Delay,
+			Users:    tier.Users,
+			Webhooks: tier.Webhooks,
+			Schedule: tier.Schedule,
+		})
+	}
+	return ret
+}
+
+func ListEscalationPolicy(r *pigeon.Request, ctx *Context) bool {
+	policies, err := agent.ListEscalationPolicy(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, policies)
+}
+
+func CreateEscalationPolicy(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateEscalationPolicyRequest)
+	err := agent.CreateEscalationPolicy(r, &storage.EscalationPolicy{
+		Name:     data.Name,
+		LevelStr: data.Level,
+		Alert:    data.Alert,
+		Enable:   *data.Enable,
+		Tiers:    toEscalationTiers(data.Tiers),
+	})
+	return core.Exit(r, err)
+}
+
+func UpdateEscalationPolicy(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateEscalationPolicyRequest)
+	err := agent.UpdateEscalationPolicy(r, &storage.EscalationPolicy{
+		Name:     data.Name,
+		LevelStr: data.Level,
+		Alert:    data.Alert,
+		Enable:   *data.Enable,
+		Tiers:    toEscalationTiers(data.Tiers),
+	})
+	return core.Exit(r, err)
+}
+
+func DeleteEscalationPolicy(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteEscalationPolicyRequest)
+	err := agent.DeleteEscalationPolicy(r, data.Name)
+	return core.Exit(r, err)
+}
+
+func ListOnCallSchedule(r *pigeon.Request, ctx *Context) bool {
+	schedules, err := agent.ListOnCallSchedule(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, schedules)
+}
+
+func CreateOnCallSchedule(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateOnCallScheduleRequest)
+	err := agent.CreateOnCallSchedule(r, &storage.OnCallSchedule{
+		Name:          data.Name,
+		Users:         data.Users,
+		StartTimeMs:   data.StartTime,
+		RotationHours: data.RotationHours,
+	})
+	return core.Exit(r, err)
+}
+
+func UpdateOnCallSchedule(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateOnCallScheduleRequest)
+	err := agent.UpdateOnCallSchedule(r, &storage.OnCallSchedule{
+		Name:          data.Name,
+		Users:         data.Users,
+		StartTimeMs:   data.StartTime,
+		RotationHours: data.RotationHours,
+	})
+	return core.Exit(r, err)
+}
+
+func DeleteOnCallSchedule(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteOnCallScheduleRequest)
+	err := agent.DeleteOnCallSchedule(r, data.Name)
+	return core.Exit(r, err)
+}
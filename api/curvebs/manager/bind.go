@@ -213,13 +213,18 @@ type GetSysLogRequest struct {
 }
 
 type GetSysAlertRequest struct {
-	Start   int64  `json:"start" default:"0"`
-	End     int64  `json:"end" default:"0"`
-	Page    uint32 `json:"page" binding:"required"`
-	Size    uint32 `json:"size" binding:"required"`
-	Name    string `json:"name"`
-	Level   string `json:"level"`
-	Content string `json:"content"`
+	Start int64  `json:"start" default:"0"`
+	End   int64  `json:"end" default:"0"`
+	Page  uint32 `json:"page" binding:"required"`
+	Size  uint32 `json:"size" binding:"required"`
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	// pending, firing or resolved
+	State string `json:"state"`
+	// acknowledged or unacknowledged
+	Status   string `json:"status"`
+	Assignee string `json:"assignee"`
+	Content  string `json:"content"`
 }
 
 type GetUnreadSysAlertNumRequest struct{}
@@ -228,15 +233,65 @@ type UpdateReadSysAlertIdRequest struct {
 	Id int64 `json:"id" binding:"required"`
 }
 
+type AckSysAlertRequest struct {
+	Id   int64  `json:"id" binding:"required"`
+	Note string `json:"note"`
+}
+
+type UnackSysAlertRequest struct {
+	Id int64 `json:"id" binding:"required"`
+}
+
+// empty assignee means unassign
+type AssignSysAlertRequest struct {
+	Id       int64  `json:"id" binding:"required"`
+	Assignee string `json:"assignee"`
+}
+
+type AddSysAlertNoteRequest struct {
+	Id      int64  `json:"id" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+type ListSysAlertNoteRequest struct {
+	Id int64 `json:"id" binding:"required"`
+}
+
 type GetAlertConfRequest struct{}
 
+// times and rule are required by the builtin alerts, and the others by the custom rules
 type UpdateAlertConfRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Interval uint32 `json:"interval" binding:"required"`
-	Times    uint32 `json:"times" binding:"required"`
-	Enable   *bool  `json:"enable" binding:"required"`
-	Rule     string `json:"rule" binding:"required"`
+	Name       string   `json:"name" binding:"required"`
+	Interval   uint32   `json:"interval" binding:"required"`
+	Times      uint32   `json:"times"`
+	Enable     *bool    `json:"enable" binding:"required"`
+	Rule       string   `json:"rule"`
 	AlertUsers []string `json:"alertUsers" binding:"required"`
+	Level      string   `json:"level"`
+	Desc       string   `json:"desc"`
+	Expr       string   `json:"expr"`
+	Op         string   `json:"op"`
+	Threshold  float64  `json:"threshold"`
+	For        uint32   `json:"for"`
+	Summary    string   `json:"summary"`
+}
+
+type CreateAlertConfRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Level      string   `json:"level" binding:"required"`
+	Interval   uint32   `json:"interval" binding:"required"`
+	Enable     *bool    `json:"enable" binding:"required"`
+	Desc       string   `json:"desc"`
+	Expr       string   `json:"expr" binding:"required"`
+	Op         string   `json:"op" binding:"required"`
+	Threshold  float64  `json:"threshold"`
+	For        uint32   `json:"for"`
+	Summary    string   `json:"summary"`
+	AlertUsers []string `json:"alertUsers"`
+}
+
+type DeleteAlertConfRequest struct {
+	Name string `json:"name" binding:"required"`
 }
 
 type GetAlertCandidateRequest struct{}
@@ -246,6 +301,143 @@ type UpdateAlertUserRequest struct {
 	User      []string `json:"user" binding:"required"`
 }
 
+type ListNotificationRequest struct {
+	Start   int64  `json:"start" default:"0"`
+	End     int64  `json:"end" default:"0"`
+	Page    uint32 `json:"page" binding:"required"`
+	Size    uint32 `json:"size" binding:"required"`
+	State   string `json:"state"`
+	Channel string `json:"channel"`
+}
+
+type UpdateAlertWebhookRequest struct {
+	Alert    string   `json:"alert" binding:"required"`
+	Webhooks []string `json:"webhooks" binding:"required"`
+}
+
+type ListAlertSilenceRequest struct{}
+
+// startTime and endTime are in milliseconds, startTime defaults to now and endTime 0 means
+// never expire, weekdays like ["tue"] with windowStart "02:00" and windowEnd "04:00"
+// make a recurring maintenance window
+type CreateAlertSilenceRequest struct {
+	Name        string   `json:"name"`
+	Level       string   `json:"level"`
+	Target      string   `json:"target"`
+	StartTime   int64    `json:"startTime"`
+	EndTime     int64    `json:"endTime"`
+	Weekdays    []string `json:"weekdays"`
+	WindowStart string   `json:"windowStart"`
+	WindowEnd   string   `json:"windowEnd"`
+	Comment     string   `json:"comment"`
+}
+
+type UpdateAlertSilenceRequest struct {
+	Id          int64    `json:"id" binding:"required"`
+	Name        string   `json:"name"`
+	Level       string   `json:"level"`
+	Target      string   `json:"target"`
+	StartTime   int64    `json:"startTime"`
+	EndTime     int64    `json:"endTime"`
+	Weekdays    []string `json:"weekdays"`
+	WindowStart string   `json:"windowStart"`
+	WindowEnd   string   `json:"windowEnd"`
+	Comment     string   `json:"comment"`
+}
+
+type DeleteAlertSilenceRequest struct {
+	Id int64 `json:"id" binding:"required"`
+}
+
+// the tier is notified if the alert is not acknowledged in delay minutes since it fires,
+// and the on-call user of schedule is notified by email
+type EscalationTier struct {
+	Delay    uint32   `json:"delay"`
+	Users    []string `json:"users"`
+	Webhooks []string `json:"webhooks"`
+	Schedule string   `json:"schedule"`
+}
+
+type ListEscalationPolicyRequest struct{}
+
+// the policy escalates all the alerts of the level if alert is empty
+type CreateEscalationPolicyRequest struct {
+	Name   string           `json:"name" binding:"required"`
+	Level  string           `json:"level" binding:"required"`
+	Alert  string           `json:"alert"`
+	Enable *bool            `json:"enable" binding:"required"`
+	Tiers  []EscalationTier `json:"tiers" binding:"required"`
+}
+
+type UpdateEscalationPolicyRequest struct {
+	Name   string           `json:"name" binding:"required"`
+	Level  string           `json:"level" binding:"required"`
+	Alert  string           `json:"alert"`
+	Enable *bool            `json:"enable" binding:"required"`
+	Tiers  []EscalationTier `json:"tiers" binding:"required"`
+}
+
+type DeleteEscalationPolicyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ListOnCallScheduleRequest struct{}
+
+// the users take turns every rotationHours since startTime in milliseconds, which defaults to now
+type CreateOnCallScheduleRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Users         []string `json:"users" binding:"required"`
+	StartTime     int64    `json:"startTime"`
+	RotationHours uint32   `json:"rotationHours" binding:"required"`
+}
+
+type UpdateOnCallScheduleRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Users         []string `json:"users" binding:"required"`
+	StartTime     int64    `json:"startTime"`
+	RotationHours uint32   `json:"rotationHours" binding:"required"`
+}
+
+type DeleteOnCallScheduleRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ListWebhookRequest struct{}
+
+type CreateWebhookRequest struct {
+	Name               string `json:"name" binding:"required"`
+	Type               string `json:"type" default:"generic"`
+	URL                string `json:"url" binding:"required"`
+	Secret             string `json:"secret"`
+	SecretHeader       string `json:"secretHeader"`
+	BodyTemplate       string `json:"bodyTemplate"`
+	Timeout            int    `json:"timeout"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	CACert             string `json:"caCert"`
+}
+
+// the secret is kept if absent, and cleared if empty; the url of chat robots is
+// redacted by webhook.list, and kept if the redacted one is sent back
+type UpdateWebhookRequest struct {
+	Name               string  `json:"name" binding:"required"`
+	Type               string  `json:"type" default:"generic"`
+	URL                string  `json:"url" binding:"required"`
+	Secret             *string `json:"secret"`
+	SecretHeader       string  `json:"secretHeader"`
+	BodyTemplate       string  `json:"bodyTemplate"`
+	Timeout            int     `json:"timeout"`
+	InsecureSkipVerify bool    `json:"insecureSkipVerify"`
+	CACert             string  `json:"caCert"`
+}
+
+type DeleteWebhookRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type TestWebhookRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
 var requests = []Request{
 	{
 		core.HTTP_GET,
@@ -445,6 +637,36 @@ var requests = []Request{
 		UpdateReadSysAlertIdRequest{},
 		UpdateReadSysAlertId,
 	},
+	{
+		core.HTTP_POST,
+		core.ACK_SYSTEM_ALERT,
+		AckSysAlertRequest{},
+		AckSysAlert,
+	},
+	{
+		core.HTTP_POST,
+		core.UNACK_SYSTEM_ALERT,
+		UnackSysAlertRequest{},
+		UnackSysAlert,
+	},
+	{
+		core.HTTP_POST,
+		core.ASSIGN_SYSTEM_ALERT,
+		AssignSysAlertRequest{},
+		AssignSysAlert,
+	},
+	{
+		core.HTTP_POST,
+		core.ADD_SYSTEM_ALERT_NOTE,
+		AddSysAlertNoteRequest{},
+		AddSysAlertNote,
+	},
+	{
+		core.HTTP_GET,
+		core.LIST_SYSTEM_ALERT_NOTE,
+		ListSysAlertNoteRequest{},
+		ListSysAlertNote,
+	},
 	{
 		core.HTTP_GET,
 		core.GET_ALERT_CONF,
@@ -457,6 +679,18 @@ var requests = []Request{
 		UpdateAlertConfRequest{},
 		UpdateAlertConf,
 	},
+	{
+		core.HTTP_POST,
+		core.CREATE_ALERT_CONF,
+		CreateAlertConfRequest{},
+		CreateAlertConf,
+	},
+	{
+		core.HTTP_POST,
+		core.DELETE_ALERT_CONF,
+		DeleteAlertConfRequest{},
+		DeleteAlertConf,
+	},
 	{
 		core.HTTP_GET,
 		core.GET_ALERT_CANDIDATE,
@@ -469,4 +703,118 @@ var requests = []Request{
 		UpdateAlertUserRequest{},
 		UpdateAlertUser,
 	},
+	{
+		core.HTTP_POST,
+		core.LIST_NOTIFICATION,
+		ListNotificationRequest{},
+		ListNotification,
+	},
+	{
+		core.HTTP_POST,
+		core.UPDATE_ALERT_WEBHOOK,
+		UpdateAlertWebhookRequest{},
+		UpdateAlertWebhook,
+	},
+	{
+		core.HTTP_GET,
+		core.LIST_ALERT_SILENCE,
+		ListAlertSilenceRequest{},
+		ListAlertSilence,
+	},
+	{
+		core.HTTP_POST,
+		core.CREATE_ALERT_SILENCE,
+		CreateAlertSilenceRequest{},
+		CreateAlertSilence,
+	},
+	{
+		core.HTTP_POST,
+		core.UPDATE_ALERT_SILENCE,
+		UpdateAlertSilenceRequest{},
+		UpdateAlertSilence,
+	},
+	{
+		core.HTTP_POST,
+		core.DELETE_ALERT_SILENCE,
+		DeleteAlertSilenceRequest{},
+		DeleteAlertSilence,
+	},
+	{
+		core.HTTP_GET,
+		core.LIST_ESCALATION_POLICY,
+		ListEscalationPolicyRequest{},
+		ListEscalationPolicy,
+	},
+	{
+		core.HTTP_POST,
+		core.CREATE_ESCALATION_POLICY,
+		CreateEscalationPolicyRequest{},
+		CreateEscalationPolicy,
+	},
+	{
+		core.HTTP_POST,
+		core.UPDATE_ESCALATION_POLICY,
+		UpdateEscalationPolicyRequest{},
+		UpdateEscalationPolicy,
+	},
+	{
+		core.HTTP_POST,
+		core.DELETE_ESCALATION_POLICY,
+		DeleteEscalationPolicyRequest{},
+		DeleteEscalationPolicy,
+	},
+	{
+		core.HTTP_GET,
+		core.LIST_ONCALL_SCHEDULE,
+		ListOnCallScheduleRequest{},
+		ListOnCallSchedule,
+	},
+	{
+		core.HTTP_POST,
+		core.CREATE_ONCALL_SCHEDULE,
+		CreateOnCallScheduleRequest{},
+		CreateOnCallSchedule,
+	},
+	{
+		core.HTTP_POST,
+		core.UPDATE_ONCALL_SCHEDULE,
+		UpdateOnCallScheduleRequest{},
+		UpdateOnCallSchedule,
+	},
+	{
+		core.HTTP_POST,
+		core.DELETE_ONCALL_SCHEDULE,
+		DeleteOnCallScheduleRequest{},
+		DeleteOnCallSchedule,
+	},
+	{
+		core.HTTP_GET,
+		core.LIST_WEBHOOK,
+		ListWebhookRequest{},
+		ListWebhook,
+	},
+	{
+		core.HTTP_POST,
+		core.CREATE_WEBHOOK,
+		CreateWebhookRequest{},
+		CreateWebhook,
+	},
+	{
+		core.HTTP_POST,
+		core.UPDATE_WEBHOOK,
+		UpdateWebhookRequest{},
+		UpdateWebhook,
+	},
+	{
+		core.HTTP_POST,
+		core.DELETE_WEBHOOK,
+		DeleteWebhookRequest{},
+		DeleteWebhook,
+	},
+	{
+		core.HTTP_POST,
+		core.TEST_WEBHOOK,
+		TestWebhookRequest{},
+		TestWebhook,
+	},
 }
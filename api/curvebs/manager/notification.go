@@ -0,0 +1,39 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package manager
+
+import (
+	"github.com/opencurve/curve-manager/api/curvebs/agent"
+	"github.com/opencurve/curve-manager/api/curvebs/core"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/pigeon"
+)
+
+func ListNotification(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ListNotificationRequest)
+	info, err := agent.ListNotification(r, data.Start, data.End, data.Page, data.Size, data.State, data.Channel)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
@@ -0,0 +1,91 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package manager
+
+import (
+	"github.com/opencurve/curve-manager/api/curvebs/agent"
+	"github.com/opencurve/curve-manager/api/curvebs/core"
+	"github.com/opencurve/curve-manager/internal/errno"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+func UpdateAlertWebhook(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateAlertWebhookRequest)
+	err := agent.UpdateAlertWebhook(r, data.Alert, data.Webhooks)
+	return core.Exit(r, err)
+}
+
+func ListWebhook(r *pigeon.Request, ctx *Context) bool {
+	webhooks, err := agent.ListWebhook(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, webhooks)
+}
+
+func CreateWebhook(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateWebhookRequest)
+	err := agent.CreateWebhook(r, &storage.Webhook{
+		Name:               data.Name,
+		Type:               data.Type,
+		URL:                data.URL,
+		Secret:             data.Secret,
+		SecretHeader:       data.SecretHeader,
+		BodyTemplate:       data.BodyTemplate,
+		TimeoutSec:         data.Timeout,
+		InsecureSkipVerify: data.InsecureSkipVerify,
+		CACert:             data.CACert,
+	})
+	return core.Exit(r, err)
+}
+
+func UpdateWebhook(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateWebhookRequest)
+	err := agent.UpdateWebhook(r, &storage.Webhook{
+		Name:               data.Name,
+		Type:               data.Type,
+		URL:                data.URL,
+		SecretHeader:       data.SecretHeader,
+		BodyTemplate:       data.BodyTemplate,
+		TimeoutSec:         data.Timeout,
+		InsecureSkipVerify: data.InsecureSkipVerify,
+		CACert:             data.CACert,
+	}, data.Secret)
+	return core.Exit(r, err)
+}
+
+func DeleteWebhook(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteWebhookRequest)
+	err := agent.DeleteWebhook(r, data.Name)
+	return core.Exit(r, err)
+}
+
+func TestWebhook(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*TestWebhookRequest)
+	result, err := agent.TestWebhook(r, data.Name)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, result)
+}
@@ -59,6 +59,8 @@ func init() {
 type LoginRequest struct {
 	UserName string `json:"userName" binding:"required"`
 	PassWord string `json:"passWord" binding:"required"`
+	// login a write user without the write lock
+	ReadOnly bool `json:"readOnly"`
 }
 
 type LogoutRequest struct {}
@@ -83,6 +85,11 @@ type ResetPassWordRequest struct {
 	UserName string `json:"userName" binding:"required"`
 }
 
+type ConfirmResetPassWordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	PassWord string `json:"passWord" binding:"required"`
+}
+
 type UpdateUserEmailRequest struct {
 	Email    string `json:"email" binding:"required"`
 }
@@ -100,6 +107,104 @@ type ListUserRequest struct {
 
 type GetUserRequest struct{}
 
+type CreateApiKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpireSeconds int64    `json:"expireSeconds"`
+	IPAllowlist   []string `json:"ipAllowlist"`
+}
+
+type UpdateUserRoleRequest struct {
+	UserName string   `json:"userName" binding:"required"`
+	Roles    []string `json:"roles"`
+}
+
+type ListRoleRequest struct{}
+
+type CreateRoleRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Methods []string `json:"methods" binding:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Methods []string `json:"methods" binding:"required"`
+}
+
+type DeleteRoleRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type GrantVolumeRequest struct {
+	UserName string `json:"userName" binding:"required"`
+	Dir      string `json:"dir" binding:"required"`
+	Mode     string `json:"mode" default:"ro"`
+}
+
+type RevokeVolumeRequest struct {
+	UserName string `json:"userName" binding:"required"`
+	Dir      string `json:"dir" binding:"required"`
+}
+
+type ListVolumeGrantRequest struct {
+	UserName string `json:"userName"`
+}
+
+type ListApiKeyRequest struct {
+	UserName string `json:"userName"`
+}
+
+type RevokeApiKeyRequest struct {
+	Id string `json:"id" binding:"required"`
+}
+
+type LoginTOTPRequest struct {
+	Ticket string `json:"ticket" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+}
+
+type EnrollTOTPRequest struct{}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type ResetTOTPRequest struct {
+	UserName string `json:"userName" binding:"required"`
+}
+
+type UnlockUserRequest struct {
+	UserName string `json:"userName" binding:"required"`
+}
+
+type ListLockedUserRequest struct{}
+
+type ListSessionRequest struct {
+	UserName string `json:"userName"`
+}
+
+type RevokeSessionRequest struct {
+	Id string `json:"id" binding:"required"`
+}
+
+type KickWriteUserRequest struct{}
+
+type GetWriteLockRequest struct{}
+
+type RequestWriteLockRequest struct{}
+
+type ReleaseWriteLockRequest struct{}
+
+type GetRateLimitRequest struct{}
+
+type OIDCLoginRequest struct{}
+
+type OIDCCallbackRequest struct {
+	Code     string `json:"code" binding:"required"`
+	State    string `json:"state" binding:"required"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
 var requests = []Request{
 	{
 		core.HTTP_POST,
@@ -137,6 +242,12 @@ var requests = []Request{
 		ResetPassWordRequest{},
 		ResetPassWord,
 	},
+	{
+		core.HTTP_POST,
+		core.USER_RESET_PASSWORD_CONFIRM,
+		ConfirmResetPassWordRequest{},
+		ConfirmResetPassWord,
+	},
 	{
 		core.HTTP_POST,
 		core.USER_UPDATE_EMAIL,
@@ -161,4 +272,172 @@ var requests = []Request{
 		GetUserRequest{},
 		GetUser,
 	},
+	{
+		core.HTTP_POST,
+		core.USER_API_KEY_CREATE,
+		CreateApiKeyRequest{},
+		CreateApiKey,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_API_KEY_LIST,
+		ListApiKeyRequest{},
+		ListApiKey,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_API_KEY_REVOKE,
+		RevokeApiKeyRequest{},
+		RevokeApiKey,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_UPDATE_ROLE,
+		UpdateUserRoleRequest{},
+		UpdateUserRole,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_ROLE_LIST,
+		ListRoleRequest{},
+		ListRole,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_ROLE_CREATE,
+		CreateRoleRequest{},
+		CreateRole,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_ROLE_UPDATE,
+		UpdateRoleRequest{},
+		UpdateRole,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_ROLE_DELETE,
+		DeleteRoleRequest{},
+		DeleteRole,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_VOLUME_GRANT,
+		GrantVolumeRequest{},
+		GrantVolume,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_VOLUME_REVOKE,
+		RevokeVolumeRequest{},
+		RevokeVolume,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_VOLUME_GRANT_LIST,
+		ListVolumeGrantRequest{},
+		ListVolumeGrant,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_OIDC_LOGIN,
+		OIDCLoginRequest{},
+		OIDCLogin,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_OIDC_CALLBACK,
+		OIDCCallbackRequest{},
+		OIDCCallback,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_LOGIN_TOTP,
+		LoginTOTPRequest{},
+		LoginTOTP,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_TOTP_ENROLL,
+		EnrollTOTPRequest{},
+		EnrollTOTP,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_TOTP_ACTIVATE,
+		TOTPCodeRequest{},
+		ActivateTOTP,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_TOTP_DISABLE,
+		TOTPCodeRequest{},
+		DisableTOTP,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_TOTP_RECOVERY,
+		TOTPCodeRequest{},
+		RegenerateRecoveryCode,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_TOTP_RESET,
+		ResetTOTPRequest{},
+		ResetTOTP,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_UNLOCK,
+		UnlockUserRequest{},
+		UnlockUser,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_LOCKED_LIST,
+		ListLockedUserRequest{},
+		ListLockedUser,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_SESSION_LIST,
+		ListSessionRequest{},
+		ListSession,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_SESSION_REVOKE,
+		RevokeSessionRequest{},
+		RevokeSession,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_SESSION_KICK_WRITER,
+		KickWriteUserRequest{},
+		KickWriteUser,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_WRITE_LOCK_GET,
+		GetWriteLockRequest{},
+		GetWriteLock,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_WRITE_LOCK_REQUEST,
+		RequestWriteLockRequest{},
+		RequestWriteLock,
+	},
+	{
+		core.HTTP_POST,
+		core.USER_WRITE_LOCK_RELEASE,
+		ReleaseWriteLockRequest{},
+		ReleaseWriteLock,
+	},
+	{
+		core.HTTP_GET,
+		core.USER_RATE_LIMIT_GET,
+		GetRateLimitRequest{},
+		GetRateLimit,
+	},
 }
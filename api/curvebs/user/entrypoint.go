@@ -30,7 +30,6 @@ import (
 	comm "github.com/opencurve/curve-manager/api/common"
 	"github.com/opencurve/curve-manager/api/curvebs/core"
 	"github.com/opencurve/curve-manager/internal/errno"
-	"github.com/opencurve/curve-manager/internal/storage"
 	"github.com/opencurve/pigeon"
 )
 
@@ -53,6 +52,9 @@ func Entrypoint(r *pigeon.Request) bool {
 
 	vType := reflect.TypeOf(request.vType)
 	data := reflect.New(vType).Interface()
+	if e := core.ReadBody(r); e != errno.OK {
+		return core.Exit(r, e)
+	}
 	if err := r.BindBody(data); err != nil {
 		r.Logger().Error("bad request form param",
 			pigeon.Field("error", err),
@@ -66,17 +68,47 @@ func Entrypoint(r *pigeon.Request) bool {
 	}
 
 	if core.NeedRecordLog(r) {
-		c, _ := json.Marshal(data)
-		r.HeadersIn[comm.HEADER_LOG_ENABLE] = "true"
-		r.HeadersIn[comm.HEADER_LOG_CONTENT] = string(c)
-		if core.IsLoginRequest(r) {
-			r.HeadersIn[comm.HEADER_LOG_USER] = data.(*LoginRequest).UserName
-		} else if core.IsResetPasswordRequest(r) {
-			r.HeadersIn[comm.HEADER_LOG_USER] = data.(*ResetPassWordRequest).UserName
-		} else {
-			r.HeadersIn[comm.HEADER_LOG_USER] = storage.GetLoginUserByToken(r.HeadersIn[comm.HEADER_AUTH_TOKEN])
-		}
+		setLogContent(r, data)
 	}
 
 	return request.handler(r, &Context{data})
 }
+
+// logContent returns the request kept in system log, without the secrets in it
+func logContent(data interface{}) string {
+	switch d := data.(type) {
+	case *LoginRequest:
+		// the ldap users login with plaintext password
+		redacted := *d
+		redacted.PassWord = ""
+		data = &redacted
+	case *CreateUserRequest:
+		redacted := *d
+		redacted.PassWord = ""
+		data = &redacted
+	case *LoginTOTPRequest:
+		// the ticket can be retried until the attempts exhausted
+		redacted := *d
+		redacted.Ticket = ""
+		redacted.Code = ""
+		data = &redacted
+	case *TOTPCodeRequest:
+		redacted := *d
+		redacted.Code = ""
+		data = &redacted
+	}
+	c, _ := json.Marshal(data)
+	return string(c)
+}
+
+func setLogContent(r *pigeon.Request, data interface{}) {
+	r.HeadersIn[comm.HEADER_LOG_ENABLE] = "true"
+	r.HeadersIn[comm.HEADER_LOG_CONTENT] = logContent(data)
+	if core.IsLoginRequest(r) {
+		r.HeadersIn[comm.HEADER_LOG_USER] = data.(*LoginRequest).UserName
+	} else if core.IsResetPasswordRequest(r) {
+		r.HeadersIn[comm.HEADER_LOG_USER] = data.(*ResetPassWordRequest).UserName
+	} else {
+		r.HeadersIn[comm.HEADER_LOG_USER] = r.HeadersIn[comm.HEADER_AUTH_USER]
+	}
+}
@@ -0,0 +1,112 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	comm "github.com/opencurve/curve-manager/api/common"
+	"github.com/opencurve/curve-manager/api/curvebs/core"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/storage/storagetest"
+	"github.com/opencurve/pigeon"
+)
+
+func TestLogContent(t *testing.T) {
+	storagetest.Init(t)
+	cases := []struct {
+		method  string
+		data    interface{}
+		secrets []string
+		kept    []string
+	}{
+		{
+			method:  core.USER_LOGIN,
+			data:    &LoginRequest{UserName: "alice", PassWord: "Login-Pass1"},
+			secrets: []string{"Login-Pass1"},
+			kept:    []string{`"userName":"alice"`},
+		},
+		{
+			method:  core.USER_CREATE,
+			data:    &CreateUserRequest{UserName: "bob", PassWord: "Create-Pass1", Email: "bob@example.com", Permission: 4},
+			secrets: []string{"Create-Pass1"},
+			kept:    []string{`"userName":"bob"`, `"email":"bob@example.com"`, `"permission":4`},
+		},
+		{
+			method:  core.USER_LOGIN_TOTP,
+			data:    &LoginTOTPRequest{Ticket: "login-ticket", Code: "123456"},
+			secrets: []string{"login-ticket", "123456"},
+		},
+		{
+			method:  core.USER_TOTP_ACTIVATE,
+			data:    &TOTPCodeRequest{Code: "654321"},
+			secrets: []string{"654321"},
+		},
+	}
+	now := time.Now().UnixMilli()
+	for _, c := range cases {
+		r := &pigeon.Request{
+			Args:      map[string]string{core.METHOD: c.method},
+			HeadersIn: map[string]string{comm.HEADER_AUTH_USER: storage.USER_ADMIN_NAME},
+		}
+		setLogContent(r, c.data)
+		err := storage.AddSystemLog(&storage.Log{
+			TimeMs:  now,
+			User:    r.HeadersIn[comm.HEADER_LOG_USER],
+			Module:  MODULE,
+			Method:  c.method,
+			Content: r.HeadersIn[comm.HEADER_LOG_CONTENT],
+		})
+		if err != nil {
+			t.Fatalf("add system log failed: %v", err)
+		}
+	}
+
+	logs, err := storage.GetSystemLog(now, now, uint32(len(cases)), 0, "", storage.USER_ADMIN_NAME)
+	if err != nil {
+		t.Fatalf("get system log failed: %v", err)
+	}
+	if len(logs.Info) != len(cases) {
+		t.Fatalf("expect %d logs, got %d", len(cases), len(logs.Info))
+	}
+	for _, c := range cases {
+		var content string
+		for _, l := range logs.Info {
+			if l.Method == c.method {
+				content = l.Content
+			}
+		}
+		for _, secret := range c.secrets {
+			if strings.Contains(content, secret) {
+				t.Errorf("log of %s contains secret %q: %s", c.method, secret, content)
+			}
+		}
+		for _, kept := range c.kept {
+			if !strings.Contains(content, kept) {
+				t.Errorf("log of %s does not contain %q: %s", c.method, kept, content)
+			}
+		}
+	}
+}
@@ -31,7 +31,7 @@ import (
 
 func Login(r *pigeon.Request, ctx *Context) bool {
 	data := ctx.Data.(*LoginRequest)
-	userInfo, err := agent.Login(r, data.UserName, data.PassWord)
+	userInfo, err := agent.Login(r, data.UserName, data.PassWord, data.ReadOnly)
 	if err != errno.OK {
 		return core.Exit(r, err)
 	}
@@ -67,6 +67,12 @@ func ResetPassWord(r *pigeon.Request, ctx *Context) bool {
 	return core.Exit(r, err)
 }
 
+func ConfirmResetPassWord(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ConfirmResetPassWordRequest)
+	err := agent.ConfirmResetPassWord(r, data.Token, data.PassWord)
+	return core.Exit(r, err)
+}
+
 func UpdateUserEmail(r *pigeon.Request, ctx *Context) bool {
 	data := ctx.Data.(*UpdateUserEmailRequest)
 	err := agent.UpdateUserEmail(r, data.Email)
@@ -94,4 +100,223 @@ func GetUser(r *pigeon.Request, ctx *Context) bool {
 		return core.Exit(r, err)
 	}
 	return core.ExitSuccessWithData(r, users)
-}
\ No newline at end of file
+}
+
+func CreateApiKey(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateApiKeyRequest)
+	if err := core.CheckApiKeyScopes(r, data.Scopes); err != errno.OK {
+		return core.Exit(r, err)
+	}
+	key, err := agent.CreateApiKey(r, data.Name, data.Scopes, data.ExpireSeconds, data.IPAllowlist)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, key)
+}
+
+func ListApiKey(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ListApiKeyRequest)
+	keys, err := agent.ListApiKey(r, data.UserName)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, keys)
+}
+
+func RevokeApiKey(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*RevokeApiKeyRequest)
+	err := agent.RevokeApiKey(r, data.Id)
+	return core.Exit(r, err)
+}
+
+func UpdateUserRole(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateUserRoleRequest)
+	err := agent.UpdateUserRole(r, data.UserName, data.Roles)
+	return core.Exit(r, err)
+}
+
+func ListRole(r *pigeon.Request, ctx *Context) bool {
+	roles, err := agent.ListRole(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, roles)
+}
+
+func CreateRole(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*CreateRoleRequest)
+	if err := core.CheckRoleMethods(r, data.Methods); err != errno.OK {
+		return core.Exit(r, err)
+	}
+	err := agent.CreateRole(r, data.Name, data.Methods)
+	return core.Exit(r, err)
+}
+
+func UpdateRole(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UpdateRoleRequest)
+	if err := core.CheckRoleMethods(r, data.Methods); err != errno.OK {
+		return core.Exit(r, err)
+	}
+	err := agent.UpdateRole(r, data.Name, data.Methods)
+	return core.Exit(r, err)
+}
+
+func DeleteRole(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*DeleteRoleRequest)
+	err := agent.DeleteRole(r, data.Name)
+	return core.Exit(r, err)
+}
+
+func GrantVolume(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*GrantVolumeRequest)
+	err := agent.GrantVolume(r, data.UserName, data.Dir, data.Mode)
+	return core.Exit(r, err)
+}
+
+func RevokeVolume(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*RevokeVolumeRequest)
+	err := agent.RevokeVolume(r, data.UserName, data.Dir)
+	return core.Exit(r, err)
+}
+
+func ListVolumeGrant(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ListVolumeGrantRequest)
+	grants, err := agent.ListVolumeGrant(r, data.UserName)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, grants)
+}
+
+func OIDCLogin(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.OIDCLogin(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func OIDCCallback(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*OIDCCallbackRequest)
+	userInfo, err := agent.OIDCCallback(r, data.Code, data.State, data.ReadOnly)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, userInfo)
+}
+
+func LoginTOTP(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*LoginTOTPRequest)
+	userInfo, err := agent.LoginTOTP(r, data.Ticket, data.Code)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, userInfo)
+}
+
+func EnrollTOTP(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.EnrollTOTP(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func ActivateTOTP(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*TOTPCodeRequest)
+	info, err := agent.ActivateTOTP(r, data.Code)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func DisableTOTP(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*TOTPCodeRequest)
+	err := agent.DisableTOTP(r, data.Code)
+	return core.Exit(r, err)
+}
+
+func RegenerateRecoveryCode(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*TOTPCodeRequest)
+	info, err := agent.RegenerateRecoveryCode(r, data.Code)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func ResetTOTP(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ResetTOTPRequest)
+	err := agent.ResetTOTP(r, data.UserName)
+	return core.Exit(r, err)
+}
+
+func UnlockUser(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*UnlockUserRequest)
+	err := agent.UnlockUser(r, data.UserName)
+	return core.Exit(r, err)
+}
+
+func ListLockedUser(r *pigeon.Request, ctx *Context) bool {
+	users, err := agent.ListLockedUser(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, users)
+}
+
+func ListSession(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*ListSessionRequest)
+	sessions, err := agent.ListSession(r, data.UserName)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, sessions)
+}
+
+func RevokeSession(r *pigeon.Request, ctx *Context) bool {
+	data := ctx.Data.(*RevokeSessionRequest)
+	err := agent.RevokeSession(r, data.Id)
+	return core.Exit(r, err)
+}
+
+func KickWriteUser(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.KickWriteUser(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func GetWriteLock(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.GetWriteLock(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func RequestWriteLock(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.RequestWriteLock(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func ReleaseWriteLock(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.ReleaseWriteLock(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
+
+func GetRateLimit(r *pigeon.Request, ctx *Context) bool {
+	info, err := agent.GetRateLimit(r)
+	if err != errno.OK {
+		return core.Exit(r, err)
+	}
+	return core.ExitSuccessWithData(r, info)
+}
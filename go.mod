@@ -5,16 +5,20 @@ go 1.18
 require (
 	github.com/SeanHai/curve-go-rpc v0.0.0-20230327062842-ff4a19bed139
 	github.com/deckarep/golang-set/v2 v2.1.0
+	github.com/go-asn1-ber/asn1-ber v1.5.4
+	github.com/go-ldap/ldap/v3 v3.4.4
 	github.com/go-resty/resty/v2 v2.7.0
 	github.com/google/uuid v1.3.0
 	github.com/jordan-wright/email v4.0.1-0.20210109023952-943e75fe5223+incompatible
 	github.com/mattn/go-sqlite3 v1.6.0
 	github.com/mcuadros/go-defaults v1.2.0
 	github.com/opencurve/pigeon v0.0.0-20230512031044-d5a430bb02a4
+	golang.org/x/crypto v0.8.0
 )
 
 require (
 	github.com/Azure/go-ansiterm v0.0.0-20230124172434-306776ec8161 // indirect
+	github.com/Azure/go-ntlmssp v0.0.0-20220621081337-cb9428e4ac1e // indirect
 	github.com/Microsoft/go-winio v0.6.0 // indirect
 	github.com/Wine93/grace v0.0.0-20221021033009-7d0348013a3c // indirect
 	github.com/benbjohnson/clock v1.3.0 // indirect
@@ -98,7 +102,6 @@ require (
 	go.uber.org/multierr v1.11.0 // indirect
 	go.uber.org/zap v1.24.0 // indirect
 	golang.org/x/arch v0.3.0 // indirect
-	golang.org/x/crypto v0.8.0 // indirect
 	golang.org/x/exp v0.0.0-20230321023759-10a507213a29 // indirect
 	golang.org/x/mod v0.10.0 // indirect
 	golang.org/x/net v0.9.0 // indirect
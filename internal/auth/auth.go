@@ -0,0 +1,129 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"errors"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+var (
+	ErrUserNotExist      = errors.New("user not exist")
+	ErrPassWordNotMatch  = errors.New("user password not match")
+	ErrNoPermission      = errors.New("user has no permission")
+	ErrBackendNotEnabled = errors.New("authenticator not enabled")
+)
+
+// Authenticator verify the name and password of a user
+type Authenticator interface {
+	// Name is recorded as the password algorithm of the users provisioned by the authenticator
+	Name() string
+	// Authenticate returns the user info with email and permission filled if passwd is correct
+	Authenticate(name, passwd string) (*storage.UserInfo, error)
+}
+
+var (
+	// authenticator of the users not stored locally, nil means local users only
+	external Authenticator
+)
+
+func Init(cfg *pigeon.Configure) error {
+	if cfg.GetConfig().GetBool(LDAP_ENABLE) {
+		ldap, err := newLDAPAuthenticator(cfg)
+		if err != nil {
+			return err
+		}
+		Register(ldap)
+	}
+	if cfg.GetConfig().GetBool(OIDC_ENABLE) {
+		provider, err := newOIDCProvider(cfg)
+		if err != nil {
+			return err
+		}
+		oidcProvider = provider
+	}
+	return nil
+}
+
+// Register set the external authenticator, which is tried for the users not stored locally
+func Register(a Authenticator) {
+	external = a
+}
+
+type localAuthenticator struct{}
+
+func (a *localAuthenticator) Name() string {
+	return storage.PASSWORD_ALGORITHM_BCRYPT
+}
+
+func (a *localAuthenticator) Authenticate(name, passwd string) (*storage.UserInfo, error) {
+	user, err := storage.GetUser(name)
+	if err != nil {
+		return nil, ErrUserNotExist
+	}
+	if !storage.CheckUserPassWord(&user, passwd) {
+		return nil, ErrPassWordNotMatch
+	}
+	return &user, nil
+}
+
+var local = &localAuthenticator{}
+
+// Authenticate verify the user by local password if the user is stored locally, which
+// always includes admin, otherwise by the external authenticator and provision the user
+func Authenticate(name, passwd string) (*storage.UserInfo, error) {
+	user, err := storage.GetUser(name)
+	if err == nil && storage.IsLocalUser(&user) {
+		return local.Authenticate(name, passwd)
+	}
+	if external == nil {
+		return nil, ErrUserNotExist
+	}
+	if err == nil && user.Algorithm != external.Name() {
+		return nil, ErrBackendNotEnabled
+	}
+	info, err := external.Authenticate(name, passwd)
+	if err != nil {
+		return nil, err
+	}
+	return provision(info, external.Name())
+}
+
+// provision create or update the user authenticated by source, the users
+// stored locally or provisioned by another source are never overwritten
+func provision(info *storage.UserInfo, source string) (*storage.UserInfo, error) {
+	err := storage.SyncExternalUser(info, source)
+	if err != nil {
+		return nil, err
+	}
+	user, err := storage.GetUser(info.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if user.Algorithm != source {
+		return nil, ErrBackendNotEnabled
+	}
+	return &user, nil
+}
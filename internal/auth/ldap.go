@@ -0,0 +1,215 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	LDAP_ENABLE               = "user.ldap.enable"
+	LDAP_URL                  = "user.ldap.url"
+	LDAP_START_TLS            = "user.ldap.start_tls"
+	LDAP_INSECURE_SKIP_VERIFY = "user.ldap.insecure_skip_verify"
+	LDAP_BIND_DN              = "user.ldap.bind_dn"
+	LDAP_BIND_PASSWORD        = "user.ldap.bind_password"
+	LDAP_BASE_DN              = "user.ldap.base_dn"
+	LDAP_USER_FILTER          = "user.ldap.user_filter"
+	LDAP_EMAIL_ATTRIBUTE      = "user.ldap.email_attribute"
+	LDAP_GROUP_ATTRIBUTE      = "user.ldap.group_attribute"
+	LDAP_READ_GROUPS          = "user.ldap.group.read"
+	LDAP_WRITE_GROUPS         = "user.ldap.group.write"
+	LDAP_MANAGER_GROUPS       = "user.ldap.group.manager"
+	LDAP_TIMEOUT_SECONDS      = "user.ldap.timeout_seconds"
+
+	LDAP_AUTHENTICATOR_NAME = "ldap"
+
+	DEFAULT_LDAP_USER_FILTER     = "(uid=%s)"
+	DEFAULT_LDAP_EMAIL_ATTRIBUTE = "mail"
+	DEFAULT_LDAP_GROUP_ATTRIBUTE = "memberOf"
+	DEFAULT_LDAP_TIMEOUT         = 5 * time.Second
+)
+
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// the account used to search users, empty means anonymous
+	BindDN       string
+	BindPassWord string
+	BaseDN       string
+	// e.g. (uid=%s) for OpenLDAP, (sAMAccountName=%s) for Active Directory
+	UserFilter     string
+	EmailAttribute string
+	GroupAttribute string
+	// group DNs map to the permission bits, empty ReadGroups means all users can read
+	ReadGroups    []string
+	WriteGroups   []string
+	ManagerGroups []string
+	Timeout       time.Duration
+}
+
+type ldapAuthenticator struct {
+	conf LDAPConfig
+}
+
+func NewLDAPAuthenticator(conf LDAPConfig) Authenticator {
+	if conf.UserFilter == "" {
+		conf.UserFilter = DEFAULT_LDAP_USER_FILTER
+	}
+	if conf.EmailAttribute == "" {
+		conf.EmailAttribute = DEFAULT_LDAP_EMAIL_ATTRIBUTE
+	}
+	if conf.GroupAttribute == "" {
+		conf.GroupAttribute = DEFAULT_LDAP_GROUP_ATTRIBUTE
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DEFAULT_LDAP_TIMEOUT
+	}
+	return &ldapAuthenticator{conf: conf}
+}
+
+func newLDAPAuthenticator(cfg *pigeon.Configure) (Authenticator, error) {
+	conf := LDAPConfig{
+		URL:                cfg.GetConfig().GetString(LDAP_URL),
+		StartTLS:           cfg.GetConfig().GetBool(LDAP_START_TLS),
+		InsecureSkipVerify: cfg.GetConfig().GetBool(LDAP_INSECURE_SKIP_VERIFY),
+		BindDN:             cfg.GetConfig().GetString(LDAP_BIND_DN),
+		BindPassWord:       cfg.GetConfig().GetString(LDAP_BIND_PASSWORD),
+		BaseDN:             cfg.GetConfig().GetString(LDAP_BASE_DN),
+		UserFilter:         cfg.GetConfig().GetString(LDAP_USER_FILTER),
+		EmailAttribute:     cfg.GetConfig().GetString(LDAP_EMAIL_ATTRIBUTE),
+		GroupAttribute:     cfg.GetConfig().GetString(LDAP_GROUP_ATTRIBUTE),
+		ReadGroups:         cfg.GetConfig().GetStringArray(LDAP_READ_GROUPS),
+		WriteGroups:        cfg.GetConfig().GetStringArray(LDAP_WRITE_GROUPS),
+		ManagerGroups:      cfg.GetConfig().GetStringArray(LDAP_MANAGER_GROUPS),
+		Timeout:            time.Duration(cfg.GetConfig().GetInt(LDAP_TIMEOUT_SECONDS)) * time.Second,
+	}
+	if conf.URL == "" || conf.BaseDN == "" {
+		return nil, fmt.Errorf("%s and %s are required when ldap enabled", LDAP_URL, LDAP_BASE_DN)
+	}
+	return NewLDAPAuthenticator(conf), nil
+}
+
+func (a *ldapAuthenticator) Name() string {
+	return LDAP_AUTHENTICATOR_NAME
+}
+
+func (a *ldapAuthenticator) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: a.conf.InsecureSkipVerify}
+	conn, err := ldap.DialURL(a.conf.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(a.conf.Timeout)
+	if a.conf.StartTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func inGroups(memberOf, groups []string) bool {
+	for _, m := range memberOf {
+		for _, g := range groups {
+			if strings.EqualFold(m, g) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// permission map the group membership to the legacy permission bits
+func (a *ldapAuthenticator) permission(memberOf []string) int {
+	perm := 0
+	if len(a.conf.ReadGroups) == 0 || inGroups(memberOf, a.conf.ReadGroups) {
+		perm |= storage.READ_PERM
+	}
+	if inGroups(memberOf, a.conf.WriteGroups) {
+		perm |= storage.WRITE_PERM
+	}
+	if inGroups(memberOf, a.conf.ManagerGroups) {
+		perm |= storage.MANAGER_PERM
+	}
+	return perm
+}
+
+// Authenticate search the user with the service account, then bind as the user to verify passwd
+func (a *ldapAuthenticator) Authenticate(name, passwd string) (*storage.UserInfo, error) {
+	// an empty password makes an unauthenticated bind which always succeeds
+	if passwd == "" {
+		return nil, ErrPassWordNotMatch
+	}
+	conn, err := a.dial()
+	if err != nil {
+		return nil, fmt.Errorf("dial ldap server failed: %v", err)
+	}
+	defer conn.Close()
+
+	if a.conf.BindDN != "" {
+		if err = conn.Bind(a.conf.BindDN, a.conf.BindPassWord); err != nil {
+			return nil, fmt.Errorf("bind service account failed: %v", err)
+		}
+	}
+	req := ldap.NewSearchRequest(a.conf.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(a.conf.UserFilter, ldap.EscapeFilter(name)),
+		[]string{a.conf.EmailAttribute, a.conf.GroupAttribute}, nil)
+	result, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("search user failed: %v", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrUserNotExist
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("more than one entry match user %s", name)
+	}
+	entry := result.Entries[0]
+
+	if err = conn.Bind(entry.DN, passwd); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrPassWordNotMatch
+		}
+		return nil, fmt.Errorf("bind user failed: %v", err)
+	}
+
+	perm := a.permission(entry.GetAttributeValues(a.conf.GroupAttribute))
+	if perm&storage.READ_PERM != storage.READ_PERM {
+		return nil, ErrNoPermission
+	}
+	return &storage.UserInfo{
+		UserName:   name,
+		Email:      entry.GetAttributeValue(a.conf.EmailAttribute),
+		Permission: perm,
+	}, nil
+}
@@ -0,0 +1,260 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/storage/storagetest"
+)
+
+const (
+	testBindDN       = "cn=svc,dc=example,dc=com"
+	testBindPassWord = "svc-secret"
+	testBaseDN       = "ou=people,dc=example,dc=com"
+	testReadGroup    = "cn=readers,dc=example,dc=com"
+	testWriteGroup   = "cn=writers,dc=example,dc=com"
+)
+
+type ldapEntry struct {
+	dn       string
+	passwd   string
+	mail     string
+	memberOf []string
+}
+
+// ldapServer is a minimal in-process ldap server which serves simple bind and the
+// equality filter search on uid, enough for the authenticator
+type ldapServer struct {
+	listener net.Listener
+	entries  map[string]*ldapEntry
+}
+
+func newLDAPServer(t *testing.T, entries map[string]*ldapEntry) *ldapServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &ldapServer{listener: listener, entries: entries}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *ldapServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *ldapServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func ldapResponse(id int64, tag ber.Tag, children ...*ber.Packet) []byte {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	for _, child := range children {
+		op.AppendChild(child)
+	}
+	msg.AppendChild(op)
+	return msg.Bytes()
+}
+
+func ldapResult(code int64) []*ber.Packet {
+	return []*ber.Packet{
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""),
+	}
+}
+
+func ldapAttribute(name string, values ...string) *ber.Packet {
+	attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+	vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+	for _, v := range values {
+		vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+	}
+	attr.AppendChild(vals)
+	return attr
+}
+
+func (s *ldapServer) bind(dn, passwd string) int64 {
+	if dn == testBindDN && passwd == testBindPassWord {
+		return ldap.LDAPResultSuccess
+	}
+	for _, e := range s.entries {
+		if e.dn == dn && e.passwd == passwd {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+func (s *ldapServer) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		var resp []byte
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			passwd := op.Children[2].Data.String()
+			resp = ldapResponse(id, ldap.ApplicationBindResponse, ldapResult(s.bind(dn, passwd))...)
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationSearchRequest:
+			// filter is (uid=name), i.e. equalityMatch [3] { attribute, value }
+			filter := op.Children[6]
+			if e, ok := s.entries[filter.Children[1].Data.String()]; ok {
+				entry := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				entry.AppendChild(ldapAttribute("mail", e.mail))
+				entry.AppendChild(ldapAttribute("memberOf", e.memberOf...))
+				dn := ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "")
+				if _, err = conn.Write(ldapResponse(id, ldap.ApplicationSearchResultEntry, dn, entry)); err != nil {
+					return
+				}
+			}
+			resp = ldapResponse(id, ldap.ApplicationSearchResultDone, ldapResult(ldap.LDAPResultSuccess)...)
+		default:
+			return
+		}
+		if _, err = conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func testLDAPEntries() map[string]*ldapEntry {
+	return map[string]*ldapEntry{
+		"alice": {
+			dn:       "uid=alice," + testBaseDN,
+			passwd:   "alice-secret",
+			mail:     "alice@example.com",
+			memberOf: []string{testReadGroup, testWriteGroup},
+		},
+		"bob": {
+			dn:       "uid=bob," + testBaseDN,
+			passwd:   "bob-secret",
+			mail:     "bob@example.com",
+			memberOf: []string{"cn=others,dc=example,dc=com"},
+		},
+	}
+}
+
+func newTestLDAPAuthenticator(url string) Authenticator {
+	return NewLDAPAuthenticator(LDAPConfig{
+		URL:          url,
+		BindDN:       testBindDN,
+		BindPassWord: testBindPassWord,
+		BaseDN:       testBaseDN,
+		ReadGroups:   []string{testReadGroup},
+		WriteGroups:  []string{testWriteGroup},
+	})
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	server := newLDAPServer(t, testLDAPEntries())
+	a := newTestLDAPAuthenticator(server.url())
+
+	user, err := a.Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("authenticate alice failed: %v", err)
+	}
+	if user.Email != "alice@example.com" || user.Permission != storage.READ_PERM|storage.WRITE_PERM {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	cases := []struct {
+		name   string
+		passwd string
+		err    error
+	}{
+		{"alice", "wrong", ErrPassWordNotMatch},
+		{"alice", "", ErrPassWordNotMatch},
+		{"carol", "carol-secret", ErrUserNotExist},
+		{"bob", "bob-secret", ErrNoPermission},
+	}
+	for _, c := range cases {
+		if _, err := a.Authenticate(c.name, c.passwd); err != c.err {
+			t.Errorf("authenticate %s: expect %v, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestLDAPProvision(t *testing.T) {
+	storagetest.Init(t)
+	entries := testLDAPEntries()
+	server := newLDAPServer(t, entries)
+	Register(newTestLDAPAuthenticator(server.url()))
+	defer Register(nil)
+
+	// the first login creates the user
+	user, err := Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("first login failed: %v", err)
+	}
+	if user.Algorithm != LDAP_AUTHENTICATOR_NAME || user.Permission != storage.READ_PERM|storage.WRITE_PERM {
+		t.Fatalf("unexpected provisioned user: %+v", user)
+	}
+
+	// the later login refreshes the email and permission
+	entries["alice"].mail = "alice@corp.example.com"
+	entries["alice"].memberOf = []string{testReadGroup}
+	user, err = Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+	if user.Email != "alice@corp.example.com" || user.Permission != storage.READ_PERM {
+		t.Fatalf("user not refreshed: %+v", user)
+	}
+
+	// the local user with the same name is verified by the local password only
+	if err = storage.CreateUser("bob", "local-secret", "bob@local", storage.READ_PERM); err != nil {
+		t.Fatalf("create local user failed: %v", err)
+	}
+	entries["bob"].memberOf = []string{testReadGroup}
+	if _, err = Authenticate("bob", "bob-secret"); err != ErrPassWordNotMatch {
+		t.Fatalf("local user authenticated by ldap: %v", err)
+	}
+	local, err := storage.GetUser("bob")
+	if err != nil || local.Email != "bob@local" || !strings.EqualFold(local.Algorithm, storage.PASSWORD_ALGORITHM_BCRYPT) {
+		t.Fatalf("local user overwritten: %+v, %v", local, err)
+	}
+}
@@ -0,0 +1,630 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	OIDC_ENABLE          = "user.oidc.enable"
+	OIDC_ISSUER          = "user.oidc.issuer"
+	OIDC_CLIENT_ID       = "user.oidc.client_id"
+	OIDC_CLIENT_SECRET   = "user.oidc.client_secret"
+	OIDC_REDIRECT_URL    = "user.oidc.redirect_url"
+	OIDC_SCOPES          = "user.oidc.scopes"
+	OIDC_USERNAME_CLAIM  = "user.oidc.username_claim"
+	OIDC_EMAIL_CLAIM     = "user.oidc.email_claim"
+	OIDC_ROLE_CLAIM      = "user.oidc.role_claim"
+	OIDC_ROLE_MAPPING    = "user.oidc.role_mapping"
+	OIDC_TIMEOUT_SECONDS = "user.oidc.timeout_seconds"
+
+	OIDC_AUTHENTICATOR_NAME = "oidc"
+
+	DEFAULT_OIDC_USERNAME_CLAIM = "preferred_username"
+	DEFAULT_OIDC_EMAIL_CLAIM    = "email"
+	DEFAULT_OIDC_ROLE_CLAIM     = "groups"
+	DEFAULT_OIDC_TIMEOUT        = 5 * time.Second
+
+	// "value=role" in role mapping, value "*" matches every user
+	OIDC_ROLE_MAPPING_DELIMITER = "="
+	OIDC_ROLE_MAPPING_WILDCARD  = "*"
+
+	OIDC_DISCOVERY_PATH = "/.well-known/openid-configuration"
+	// how long the user has to finish the login at the issuer
+	OIDC_STATE_EXPIRATION = 10 * time.Minute
+	// limit the pending logins since the login method is not authenticated
+	OIDC_MAX_PENDING_STATE = 10000
+	// refetch the keys at most once per minute for an unknown key id
+	OIDC_JWKS_REFRESH_INTERVAL = time.Minute
+	OIDC_CLOCK_SKEW            = time.Minute
+)
+
+var (
+	ErrOIDCNotEnabled   = errors.New("oidc not enabled")
+	ErrInvalidOIDCState = errors.New("invalid or expired oidc state")
+	ErrTooManyOIDCState = errors.New("too many pending oidc logins")
+)
+
+type OIDCConfig struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// the page of frontend which receives the code and state and calls user.oidc.callback
+	RedirectURL   string
+	Scopes        []string
+	UserNameClaim string
+	EmailClaim    string
+	RoleClaim     string
+	// claim value to role names, the built-in roles are mapped to the legacy permission bits
+	RoleMapping map[string][]string
+	Timeout     time.Duration
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcPending struct {
+	verifier string
+	nonce    string
+	expire   time.Time
+}
+
+// OIDCProvider runs the authorization code flow with PKCE against the issuer
+type OIDCProvider struct {
+	conf   OIDCConfig
+	client *http.Client
+
+	mutex       sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+	pending     map[string]oidcPending
+}
+
+var (
+	// nil means oidc login is disabled
+	oidcProvider *OIDCProvider
+)
+
+func NewOIDCProvider(conf OIDCConfig) *OIDCProvider {
+	conf.Issuer = strings.TrimSuffix(conf.Issuer, "/")
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+	if conf.UserNameClaim == "" {
+		conf.UserNameClaim = DEFAULT_OIDC_USERNAME_CLAIM
+	}
+	if conf.EmailClaim == "" {
+		conf.EmailClaim = DEFAULT_OIDC_EMAIL_CLAIM
+	}
+	if conf.RoleClaim == "" {
+		conf.RoleClaim = DEFAULT_OIDC_ROLE_CLAIM
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DEFAULT_OIDC_TIMEOUT
+	}
+	return &OIDCProvider{
+		conf:    conf,
+		client:  &http.Client{Timeout: conf.Timeout},
+		pending: map[string]oidcPending{},
+	}
+}
+
+func parseRoleMapping(mappings []string) (map[string][]string, error) {
+	roles := map[string][]string{}
+	for _, m := range mappings {
+		kv := strings.SplitN(m, OIDC_ROLE_MAPPING_DELIMITER, 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid oidc role mapping: %s", m)
+		}
+		roles[kv[0]] = append(roles[kv[0]], kv[1])
+	}
+	return roles, nil
+}
+
+func newOIDCProvider(cfg *pigeon.Configure) (*OIDCProvider, error) {
+	mapping, err := parseRoleMapping(cfg.GetConfig().GetStringArray(OIDC_ROLE_MAPPING))
+	if err != nil {
+		return nil, err
+	}
+	conf := OIDCConfig{
+		Issuer:        cfg.GetConfig().GetString(OIDC_ISSUER),
+		ClientId:      cfg.GetConfig().GetString(OIDC_CLIENT_ID),
+		ClientSecret:  cfg.GetConfig().GetString(OIDC_CLIENT_SECRET),
+		RedirectURL:   cfg.GetConfig().GetString(OIDC_REDIRECT_URL),
+		Scopes:        cfg.GetConfig().GetStringArray(OIDC_SCOPES),
+		UserNameClaim: cfg.GetConfig().GetString(OIDC_USERNAME_CLAIM),
+		EmailClaim:    cfg.GetConfig().GetString(OIDC_EMAIL_CLAIM),
+		RoleClaim:     cfg.GetConfig().GetString(OIDC_ROLE_CLAIM),
+		RoleMapping:   mapping,
+		Timeout:       time.Duration(cfg.GetConfig().GetInt(OIDC_TIMEOUT_SECONDS)) * time.Second,
+	}
+	if conf.Issuer == "" || conf.ClientId == "" || conf.RedirectURL == "" {
+		return nil, fmt.Errorf("%s, %s and %s are required when oidc enabled",
+			OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_REDIRECT_URL)
+	}
+	return NewOIDCProvider(conf), nil
+}
+
+func base64URLRandom(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (p *OIDCProvider) getJSON(uri string, v interface{}) error {
+	resp, err := p.client.Get(uri)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s failed, status: %s", uri, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getDiscovery fetch the issuer metadata once, the issuer may be down when we start
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	discovery := &oidcDiscovery{}
+	err := p.getJSON(p.conf.Issuer+OIDC_DISCOVERY_PATH, discovery)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.conf.Issuer {
+		return nil, fmt.Errorf("issuer mismatch, expect %s, got %s", p.conf.Issuer, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, fmt.Errorf("incomplete discovery document of %s", p.conf.Issuer)
+	}
+	p.discovery = discovery
+	return discovery, nil
+}
+
+// AuthURL start a login and returns the url of issuer to redirect the user to
+func (p *OIDCProvider) AuthURL() (string, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+	state, err := base64URLRandom(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := base64URLRandom(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := base64URLRandom(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	p.mutex.Lock()
+	if len(p.pending) >= OIDC_MAX_PENDING_STATE {
+		for k, v := range p.pending {
+			if now.After(v.expire) {
+				delete(p.pending, k)
+			}
+		}
+	}
+	if len(p.pending) >= OIDC_MAX_PENDING_STATE {
+		p.mutex.Unlock()
+		return "", ErrTooManyOIDCState
+	}
+	p.pending[state] = oidcPending{
+		verifier: verifier,
+		nonce:    nonce,
+		expire:   now.Add(OIDC_STATE_EXPIRATION),
+	}
+	p.mutex.Unlock()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.conf.ClientId)
+	query.Set("redirect_uri", p.conf.RedirectURL)
+	query.Set("scope", strings.Join(p.conf.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return discovery.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// takePending consume the state, every state can only be used once
+func (p *OIDCProvider) takePending(state string) (oidcPending, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	pending, ok := p.pending[state]
+	if !ok {
+		return pending, false
+	}
+	delete(p.pending, state)
+	return pending, time.Now().Before(pending.expire)
+}
+
+func (p *OIDCProvider) exchange(discovery *oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.conf.RedirectURL)
+	form.Set("client_id", p.conf.ClientId)
+	form.Set("code_verifier", verifier)
+	if p.conf.ClientSecret != "" {
+		form.Set("client_secret", p.conf.ClientSecret)
+	}
+	resp, err := p.client.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("invalid token response, status: %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("exchange code failed, status: %s, error: %s %s",
+			resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IdToken == "" {
+		return "", fmt.Errorf("no id_token in token response")
+	}
+	return token.IdToken, nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// getKey find the signing key by kid, refetch the key set if kid is unknown
+func (p *OIDCProvider) getKey(discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < OIDC_JWKS_REFRESH_INTERVAL {
+		return nil, fmt.Errorf("unknown key id %s", kid)
+	}
+	p.keysFetched = time.Now()
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := p.getJSON(discovery.JwksURI, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %s", kid)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	hash := sha256.Sum256(signed)
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type mismatch with %s", alg)
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig)
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return fmt.Errorf("key type or signature mismatch with %s", alg)
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, hash[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported alg %s", alg)
+}
+
+// verifyIdToken check the signature, issuer, audience, expiration and nonce of the id token
+func (p *OIDCProvider) verifyIdToken(discovery *oidcDiscovery, idToken, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.getKey(discovery, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	b, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]interface{}{}
+	if err = json.Unmarshal(b, &claims); err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != discovery.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", iss)
+	}
+	if !containsString(claimStrings(claims["aud"]), p.conf.ClientId) {
+		return nil, fmt.Errorf("audience mismatch")
+	}
+	exp, _ := claims["exp"].(float64)
+	if time.Unix(int64(exp), 0).Add(OIDC_CLOCK_SKEW).Before(time.Now()) {
+		return nil, fmt.Errorf("id token expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+	return claims, nil
+}
+
+// claimStrings accept both a string and an array of strings
+func claimStrings(v interface{}) []string {
+	switch c := v.(type) {
+	case string:
+		return []string{c}
+	case []interface{}:
+		values := []string{}
+		for _, item := range c {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// mapRoles map the role claim values to the legacy permission bits and the other roles
+func (p *OIDCProvider) mapRoles(values []string) (int, []string) {
+	perm := 0
+	roles := []string{}
+	values = append(values, OIDC_ROLE_MAPPING_WILDCARD)
+	for _, v := range values {
+		for _, role := range p.conf.RoleMapping[v] {
+			switch role {
+			case storage.ROLE_READER:
+				perm |= storage.READ_PERM
+			case storage.ROLE_WRITER:
+				perm |= storage.READ_PERM | storage.WRITE_PERM
+			case storage.ROLE_MANAGER:
+				perm |= storage.READ_PERM | storage.MANAGER_PERM
+			default:
+				if !containsString(roles, role) {
+					roles = append(roles, role)
+				}
+			}
+		}
+	}
+	return perm, roles
+}
+
+// hasCustomRole reports whether the roles of users are managed by the mapping
+func (p *OIDCProvider) hasCustomRole() bool {
+	for _, roles := range p.conf.RoleMapping {
+		for _, role := range roles {
+			if role != storage.ROLE_READER && role != storage.ROLE_WRITER && role != storage.ROLE_MANAGER {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Exchange finish the login by the code and state from the issuer, returns the user info mapped from claims
+func (p *OIDCProvider) Exchange(code, state string) (*storage.UserInfo, error) {
+	pending, ok := p.takePending(state)
+	if !ok {
+		return nil, ErrInvalidOIDCState
+	}
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := p.exchange(discovery, code, pending.verifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.verifyIdToken(discovery, idToken, pending.nonce)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := claims[p.conf.UserNameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("claim %s is empty", p.conf.UserNameClaim)
+	}
+	email, _ := claims[p.conf.EmailClaim].(string)
+	perm, roles := p.mapRoles(claimStrings(claims[p.conf.RoleClaim]))
+	if perm&storage.READ_PERM != storage.READ_PERM && len(roles) == 0 {
+		return nil, ErrNoPermission
+	}
+	return &storage.UserInfo{
+		UserName:   name,
+		Email:      email,
+		Permission: perm,
+		Roles:      roles,
+	}, nil
+}
+
+func OIDCEnabled() bool {
+	return oidcProvider != nil
+}
+
+// OIDCAuthURL start an oidc login
+func OIDCAuthURL() (string, error) {
+	if oidcProvider == nil {
+		return "", ErrOIDCNotEnabled
+	}
+	return oidcProvider.AuthURL()
+}
+
+// OIDCAuthenticate finish an oidc login and provision the user
+func OIDCAuthenticate(code, state string) (*storage.UserInfo, error) {
+	if oidcProvider == nil {
+		return nil, ErrOIDCNotEnabled
+	}
+	info, err := oidcProvider.Exchange(code, state)
+	if err != nil {
+		return nil, err
+	}
+	user, err := provision(info, OIDC_AUTHENTICATOR_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if oidcProvider.hasCustomRole() {
+		if err = storage.UpdateUserRoles(user.UserName, info.Roles); err != nil {
+			return nil, err
+		}
+		user.Roles = info.Roles
+	}
+	return user, nil
+}
@@ -0,0 +1,271 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/storage/storagetest"
+)
+
+const (
+	testClientId    = "curve-manager"
+	testRedirectURL = "https://manager.example.com/oidc/callback"
+	testKeyId       = "test-key"
+)
+
+// mockIssuer is an oidc issuer which issues one code per login, and checks the
+// PKCE verifier, client and redirect uri when the code is exchanged
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex sync.Mutex
+	// code -> the authorization request
+	logins map[string]url.Values
+	// modify the claims of the id token before signed
+	claims func(claims map[string]interface{})
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	m := &mockIssuer{t: t, key: key, logins: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(OIDC_DISCOVERY_PATH, m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.server.URL,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": testKeyId,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize play the user login at the issuer, returns the code and state sent back to redirect uri
+func (m *mockIssuer) authorize(authURL string) (string, string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("parse auth url failed: %v", err)
+	}
+	query := u.Query()
+	if query.Get("client_id") != testClientId || query.Get("redirect_uri") != testRedirectURL ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" ||
+		query.Get("state") == "" || query.Get("nonce") == "" {
+		m.t.Fatalf("invalid authorization request: %s", authURL)
+	}
+	code, err := base64URLRandom(16)
+	if err != nil {
+		m.t.Fatalf("generate code failed: %v", err)
+	}
+	m.mutex.Lock()
+	m.logins[code] = query
+	m.mutex.Unlock()
+	return code, query.Get("state")
+}
+
+func tokenError(w http.ResponseWriter, e string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": e})
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		tokenError(w, "invalid_request")
+		return
+	}
+	m.mutex.Lock()
+	login, ok := m.logins[r.PostForm.Get("code")]
+	delete(m.logins, r.PostForm.Get("code"))
+	m.mutex.Unlock()
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	if r.PostForm.Get("client_id") != testClientId || r.PostForm.Get("redirect_uri") != login.Get("redirect_uri") {
+		tokenError(w, "invalid_client")
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != login.Get("code_challenge") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":                m.server.URL,
+		"aud":                testClientId,
+		"sub":                "1001",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              login.Get("nonce"),
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"ops"},
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     m.sign(claims),
+	})
+}
+
+func (m *mockIssuer) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": testKeyId, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, hash[:])
+	if err != nil {
+		m.t.Fatalf("sign id token failed: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (m *mockIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Issuer:      m.server.URL,
+		ClientId:    testClientId,
+		RedirectURL: testRedirectURL,
+		RoleMapping: map[string][]string{
+			"ops": {storage.ROLE_WRITER},
+		},
+	})
+}
+
+// login runs the flow until the code is exchanged
+func (m *mockIssuer) login(p *OIDCProvider) (*storage.UserInfo, error) {
+	authURL, err := p.AuthURL()
+	if err != nil {
+		m.t.Fatalf("get auth url failed: %v", err)
+	}
+	code, state := m.authorize(authURL)
+	return p.Exchange(code, state)
+}
+
+func TestOIDCAuthenticate(t *testing.T) {
+	storagetest.Init(t)
+	issuer := newMockIssuer(t)
+	oidcProvider = issuer.provider()
+	defer func() { oidcProvider = nil }()
+
+	authURL, err := OIDCAuthURL()
+	if err != nil {
+		t.Fatalf("get auth url failed: %v", err)
+	}
+	code, state := issuer.authorize(authURL)
+	user, err := OIDCAuthenticate(code, state)
+	if err != nil {
+		t.Fatalf("oidc login failed: %v", err)
+	}
+	if user.UserName != "alice" || user.Email != "alice@example.com" ||
+		user.Algorithm != OIDC_AUTHENTICATOR_NAME || user.Permission != storage.READ_PERM|storage.WRITE_PERM {
+		t.Fatalf("unexpected provisioned user: %+v", user)
+	}
+
+	// the state is single-use
+	if _, err = OIDCAuthenticate(code, state); err != ErrInvalidOIDCState {
+		t.Fatalf("reused state: expect %v, got %v", ErrInvalidOIDCState, err)
+	}
+	if _, err = OIDCAuthenticate(code, "unknown"); err != ErrInvalidOIDCState {
+		t.Fatalf("unknown state: expect %v, got %v", ErrInvalidOIDCState, err)
+	}
+}
+
+func TestOIDCPKCE(t *testing.T) {
+	issuer := newMockIssuer(t)
+	p := issuer.provider()
+
+	authURL, err := p.AuthURL()
+	if err != nil {
+		t.Fatalf("get auth url failed: %v", err)
+	}
+	code, state := issuer.authorize(authURL)
+	// the code is bound to the challenge of another login
+	issuer.logins[code].Set("code_challenge", "another-challenge")
+	if _, err = p.Exchange(code, state); err == nil {
+		t.Fatalf("code exchanged with a mismatched verifier")
+	}
+}
+
+func TestOIDCRejectIdToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		claims func(claims map[string]interface{})
+	}{
+		{"issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{"audience", func(c map[string]interface{}) { c["aud"] = []string{"another-client"} }},
+		{"expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"nonce", func(c map[string]interface{}) { c["nonce"] = "replayed" }},
+		{"no permission", func(c map[string]interface{}) { c["groups"] = []string{"guests"} }},
+	}
+	issuer := newMockIssuer(t)
+	p := issuer.provider()
+	if _, err := issuer.login(p); err != nil {
+		t.Fatalf("valid id token rejected: %v", err)
+	}
+	for _, c := range cases {
+		issuer.claims = c.claims
+		if _, err := issuer.login(p); err == nil {
+			t.Errorf("id token with invalid %s accepted", c.name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238 with the parameters supported by all the authenticator apps
+const (
+	TOTP_ISSUER       = "Curve-Manager"
+	TOTP_PERIOD       = 30
+	TOTP_DIGITS       = 6
+	TOTP_SECRET_BYTES = 20
+	// accept the codes of previous and next period for clock skew
+	TOTP_SKEW_STEPS = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, TOTP_SECRET_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTP_PERIOD
+}
+
+// TOTPCode is the HOTP value of RFC 4226 at step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// VerifyTOTP returns the step which code matched, the caller should reject the
+// steps already used to prevent replay
+func VerifyTOTP(secret, code string, t time.Time) (int64, bool) {
+	if len(code) != TOTP_DIGITS {
+		return 0, false
+	}
+	now := TOTPStep(t)
+	for step := now - TOTP_SKEW_STEPS; step <= now+TOTP_SKEW_STEPS; step++ {
+		expect, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expect), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPProvisioningURI is the content of the QR code scanned by authenticator apps
+func TOTPProvisioningURI(secret, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTP_ISSUER)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	query.Set("period", fmt.Sprint(TOTP_PERIOD))
+	label := url.PathEscape(TOTP_ISSUER + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
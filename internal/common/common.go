@@ -24,6 +24,8 @@ package common
 
 import (
 	"crypto/md5"
+	crand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"net"
@@ -95,6 +97,15 @@ func GetRandString(n int) string {
 	return string(b)
 }
 
+// GetSecureRandString returns n random bytes from crypto/rand encoded in hex
+func GetSecureRandString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func GetIPFromEndpoint(endpoint string) (string, error) {
 	strs := strings.Split(endpoint, ":")
 	if len(strs) != 2 {
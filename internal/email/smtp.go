@@ -0,0 +1,154 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/jordan-wright/email"
+)
+
+const (
+	// how to secure the connection to server
+	// none: plain connection, which still upgrades by STARTTLS if offered by server
+	// starttls: plain connection which must be upgraded by STARTTLS
+	// tls: implicit tls, e.g. port 465
+	SMTP_TLS_NONE     = "none"
+	SMTP_TLS_STARTTLS = "starttls"
+	SMTP_TLS_IMPLICIT = "tls"
+
+	SMTP_AUTH_NONE     = "none"
+	SMTP_AUTH_PLAIN    = "plain"
+	SMTP_AUTH_LOGIN    = "login"
+	SMTP_AUTH_CRAM_MD5 = "cram-md5"
+)
+
+type smtpConfig struct {
+	server             string
+	port               int
+	tls                string
+	insecureSkipVerify bool
+	auth               string
+	userName           string
+	passWord           string
+	from               string
+}
+
+// loginAuth implements the LOGIN mechanism, which is not provided by net/smtp
+type loginAuth struct {
+	userName string
+	passWord string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// the same as PlainAuth, never send the password over an unencrypted connection
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" && server.Name != "::1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.userName), nil
+	case "password:":
+		return []byte(a.passWord), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+func (c *smtpConfig) check() error {
+	switch c.tls {
+	case SMTP_TLS_NONE, SMTP_TLS_STARTTLS, SMTP_TLS_IMPLICIT:
+	default:
+		return fmt.Errorf("unsupported email tls mode: %s", c.tls)
+	}
+	switch c.auth {
+	case SMTP_AUTH_NONE, SMTP_AUTH_PLAIN, SMTP_AUTH_LOGIN, SMTP_AUTH_CRAM_MD5:
+	default:
+		return fmt.Errorf("unsupported email auth mechanism: %s", c.auth)
+	}
+	if c.port <= 0 || c.port > 65535 {
+		return fmt.Errorf("invalid email port: %d", c.port)
+	}
+	return nil
+}
+
+func (c *smtpConfig) smtpAuth() smtp.Auth {
+	switch c.auth {
+	case SMTP_AUTH_PLAIN:
+		return smtp.PlainAuth("", c.userName, c.passWord, c.server)
+	case SMTP_AUTH_LOGIN:
+		return &loginAuth{userName: c.userName, passWord: c.passWord, host: c.server}
+	case SMTP_AUTH_CRAM_MD5:
+		return smtp.CRAMMD5Auth(c.userName, c.passWord)
+	}
+	return nil
+}
+
+func (c *smtpConfig) send(to []string, msg *message) error {
+	if c.from == "" || (c.auth != SMTP_AUTH_NONE && c.passWord == "") {
+		return fmt.Errorf("the manage email info not set")
+	}
+	e := email.Email{
+		From:    c.from,
+		To:      to,
+		Subject: msg.subject,
+		Text:    msg.text,
+		HTML:    msg.html,
+	}
+	addr := net.JoinHostPort(c.server, strconv.Itoa(c.port))
+	tlsConfig := &tls.Config{
+		ServerName:         c.server,
+		InsecureSkipVerify: c.insecureSkipVerify,
+	}
+	switch c.tls {
+	case SMTP_TLS_STARTTLS:
+		return e.SendWithStartTLS(addr, c.smtpAuth(), tlsConfig)
+	case SMTP_TLS_IMPLICIT:
+		return e.SendWithTLS(addr, c.smtpAuth(), tlsConfig)
+	}
+	return e.Send(addr, c.smtpAuth())
+}
+
+func formatSender(name, addr string) string {
+	if name == "" {
+		return addr
+	}
+	return (&mail.Address{Name: name, Address: addr}).String()
+}
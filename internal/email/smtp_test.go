@@ -0,0 +1,373 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	testSender   = "curve@example.com"
+	testPassWord = "auth-code"
+)
+
+// sinkMail is a mail received by smtpSink
+type sinkMail struct {
+	auth     string
+	userName string
+	passWord string
+	from     string
+	to       []string
+	data     []byte
+}
+
+// smtpSink is a minimal smtp server which offers PLAIN and LOGIN but not STARTTLS,
+// and keeps the received mails
+type smtpSink struct {
+	t        *testing.T
+	listener net.Listener
+
+	mutex sync.Mutex
+	mails []sinkMail
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	s := &smtpSink{t: t, listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) received() []sinkMail {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]sinkMail{}, s.mails...)
+}
+
+func decodeBase64(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	return string(b)
+}
+
+// smtpPath returns the address of "FROM:<addr> BODY=8BITMIME"
+func smtpPath(arg string) string {
+	_, path, _ := strings.Cut(arg, "<")
+	path, _, _ = strings.Cut(path, ">")
+	return path
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		return tp.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 localhost ESMTP sink") {
+		return
+	}
+	var m sinkMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			reply("250-localhost\r\n250-AUTH PLAIN LOGIN\r\n250 8BITMIME")
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			m.auth = strings.ToLower(mechanism)
+			switch m.auth {
+			case SMTP_AUTH_PLAIN:
+				// authzid \x00 authcid \x00 passwd
+				fields := strings.Split(decodeBase64(initial), "\x00")
+				if len(fields) == 3 {
+					m.userName, m.passWord = fields[1], fields[2]
+				}
+			case SMTP_AUTH_LOGIN:
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				line, _ = tp.ReadLine()
+				m.userName = decodeBase64(line)
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				line, _ = tp.ReadLine()
+				m.passWord = decodeBase64(line)
+			default:
+				reply("504 unrecognized authentication type")
+				continue
+			}
+			reply("235 authentication successful")
+		case "MAIL":
+			m.from = smtpPath(arg)
+			reply("250 OK")
+		case "RCPT":
+			m.to = append(m.to, smtpPath(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.data = data
+			s.mutex.Lock()
+			s.mails = append(s.mails, m)
+			s.mutex.Unlock()
+			m = sinkMail{auth: m.auth, userName: m.userName, passWord: m.passWord}
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// parsedMail is the decoded subject and bodies of a mail
+type parsedMail struct {
+	subject string
+	text    string
+	html    string
+}
+
+func parseParts(t *testing.T, contentType string, body io.Reader, parsed *parsedMail) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse content type %q failed: %v", contentType, err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				t.Fatalf("read mime part failed: %v", err)
+			}
+			parseParts(t, part.Header.Get("Content-Type"), part, parsed)
+		}
+	}
+	// the quoted-printable parts are decoded by multipart.Reader
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read mime body failed: %v", err)
+	}
+	switch mediaType {
+	case "text/plain":
+		parsed.text = string(content)
+	case "text/html":
+		parsed.html = string(content)
+	}
+}
+
+func parseMail(t *testing.T, data []byte) parsedMail {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse mail failed: %v", err)
+	}
+	var parsed parsedMail
+	parsed.subject, err = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject failed: %v", err)
+	}
+	parseParts(t, msg.Header.Get("Content-Type"), msg.Body, &parsed)
+	return parsed
+}
+
+func initTestEmail(t *testing.T, sink *smtpSink, auth, templateDir string) {
+	cfg := &pigeon.Configure{Config: map[string]interface{}{
+		EMAIL_ADDRESS:        testSender,
+		EMAIL_AUTH:           testPassWord,
+		EMAIL_SENDER_NAME:    "Curve-Manager",
+		EMAIL_SERVER:         "127.0.0.1",
+		EMAIL_PORT:           sink.port(),
+		EMAIL_TLS:            SMTP_TLS_NONE,
+		EMAIL_AUTH_MECHANISM: auth,
+		EMAIL_TEMPLATE_DIR:   templateDir,
+	}}
+	if err := Init(cfg); err != nil {
+		t.Fatalf("init email failed: %v", err)
+	}
+}
+
+func assertContains(t *testing.T, what, content string, subs ...string) {
+	for _, sub := range subs {
+		if !strings.Contains(content, sub) {
+			t.Errorf("%s does not contain %q:\n%s", what, sub, content)
+		}
+	}
+}
+
+func TestSendPassWordReset(t *testing.T) {
+	reset := &PassWordReset{
+		UserName:   "alice",
+		Token:      "reset-token",
+		Link:       "https://curve-manager.example.com/reset-password?token=reset-token",
+		Expiration: 30 * time.Minute,
+	}
+	for _, auth := range []string{SMTP_AUTH_NONE, SMTP_AUTH_PLAIN, SMTP_AUTH_LOGIN} {
+		t.Run(auth, func(t *testing.T) {
+			sink := newSMTPSink(t)
+			initTestEmail(t, sink, auth, "")
+			if err := SendPassWordReset("alice@example.com", reset); err != nil {
+				t.Fatalf("send password reset failed: %v", err)
+			}
+			mails := sink.received()
+			if len(mails) != 1 {
+				t.Fatalf("expect 1 mail, got %d", len(mails))
+			}
+			m := mails[0]
+			if auth == SMTP_AUTH_NONE {
+				if m.auth != "" {
+					t.Fatalf("expect no auth, got %s", m.auth)
+				}
+			} else if m.auth != auth || m.userName != testSender || m.passWord != testPassWord {
+				t.Fatalf("unexpected auth %s of %s:%s", m.auth, m.userName, m.passWord)
+			}
+			if m.from != testSender || len(m.to) != 1 || m.to[0] != "alice@example.com" {
+				t.Fatalf("unexpected envelope from %s to %v", m.from, m.to)
+			}
+
+			parsed := parseMail(t, m.data)
+			if parsed.subject != "Curve-Manager Reset Password" {
+				t.Errorf("unexpected subject %q", parsed.subject)
+			}
+			assertContains(t, "text", parsed.text, "UserName: alice", "ResetToken: reset-token",
+				"Reset the password at: "+reset.Link, "expires in 30m0s")
+			assertContains(t, "html", parsed.html, "<code>reset-token</code>",
+				`<a href="`+reset.Link+`">`, "expires in 30m0s")
+		})
+	}
+}
+
+func TestSendAlert(t *testing.T) {
+	sink := newSMTPSink(t)
+	initTestEmail(t, sink, SMTP_AUTH_PLAIN, "")
+	alert := &Alert{
+		ClusterId:   1,
+		Name:        "disk usage",
+		Level:       "critical",
+		DurationSec: 60,
+		Summary:     "disk usage > 90% & rising",
+		Time:        "2026-10-16 10:00:00",
+		State:       "resolved",
+		Target:      "chunkserver-1",
+		EndTime:     "2026-10-16 10:05:00",
+	}
+	tos := []string{"alice@example.com", "bob@example.com"}
+	if err := SendAlert2Users(alert, tos); err != nil {
+		t.Fatalf("send alert failed: %v", err)
+	}
+	mails := sink.received()
+	if len(mails) != len(tos) {
+		t.Fatalf("expect %d mails, got %d", len(tos), len(mails))
+	}
+	for i, m := range mails {
+		if len(m.to) != 1 || m.to[0] != tos[i] {
+			t.Fatalf("unexpected recipients %v, expect %s", m.to, tos[i])
+		}
+		parsed := parseMail(t, m.data)
+		if parsed.subject != "Curve-Manager Alert: [RESOLVED] disk usage" {
+			t.Errorf("unexpected subject %q", parsed.subject)
+		}
+		assertContains(t, "text", parsed.text, "Level: critical", "State: resolved", "Target: chunkserver-1",
+			"Summary: disk usage > 90% & rising", "End Time: 2026-10-16 10:05:00")
+		// the html body is escaped
+		assertContains(t, "html", parsed.html, "<td>Target</td><td>chunkserver-1</td>",
+			"<td>disk usage &gt; 90% &amp; rising</td>", "<td>End Time</td>")
+	}
+}
+
+func TestTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	// only the text template is overridden, the html one is still the built-in
+	content := `{{define "subject"}}[Storage] Alert {{.Name}}
+on cluster {{.ClusterId}}{{end}}{{.Name}} is {{.State}} on {{.Target}}`
+	if err := os.WriteFile(filepath.Join(dir, TEMPLATE_ALERT+TEMPLATE_TEXT_SUFFIX), []byte(content), 0644); err != nil {
+		t.Fatalf("write template failed: %v", err)
+	}
+	sink := newSMTPSink(t)
+	initTestEmail(t, sink, SMTP_AUTH_NONE, dir)
+	defer loadTemplates("")
+
+	alert := &Alert{ClusterId: 2, Name: "io latency", State: "firing", Target: "volume-1"}
+	if err := SendAlert2Users(alert, []string{"alice@example.com"}); err != nil {
+		t.Fatalf("send alert failed: %v", err)
+	}
+	mails := sink.received()
+	if len(mails) != 1 {
+		t.Fatalf("expect 1 mail, got %d", len(mails))
+	}
+	parsed := parseMail(t, mails[0].data)
+	// the subject is unfolded into one line
+	if parsed.subject != "[Storage] Alert io latency on cluster 2" {
+		t.Errorf("unexpected subject %q", parsed.subject)
+	}
+	if strings.TrimSpace(parsed.text) != "io latency is firing on volume-1" {
+		t.Errorf("unexpected text %q", parsed.text)
+	}
+	assertContains(t, "html", parsed.html, "<td>Target</td><td>volume-1</td>")
+
+	// the template without subject is rejected
+	if err := os.WriteFile(filepath.Join(dir, TEMPLATE_RESET_PASSWORD+TEMPLATE_TEXT_SUFFIX), []byte("{{.Token}}"), 0644); err != nil {
+		t.Fatalf("write template failed: %v", err)
+	}
+	if err := loadTemplates(dir); err == nil {
+		t.Fatalf("template without subject loaded")
+	}
+}
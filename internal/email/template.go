@@ -0,0 +1,147 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+/*
+* Every message is built from templates named by the message:
+* 1. <name>.txt.tmpl: text/template of the text body, which defines "subject"
+* 2. <name>.html.tmpl: html/template of the html body, optional
+* The templates in email.template_dir override the built-in ones with the same file name.
+ */
+const (
+	TEMPLATE_RESET_PASSWORD = "reset_password"
+	TEMPLATE_ALERT          = "alert"
+
+	TEMPLATE_TEXT_SUFFIX = ".txt.tmpl"
+	TEMPLATE_HTML_SUFFIX = ".html.tmpl"
+	TEMPLATE_SUBJECT     = "subject"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+type messageTemplate struct {
+	text *texttemplate.Template
+	// nil if no html body
+	html *htmltemplate.Template
+}
+
+type message struct {
+	subject string
+	text    []byte
+	html    []byte
+}
+
+var templates = map[string]*messageTemplate{}
+
+// readTemplate returns the template in dir if exists, otherwise the built-in one
+func readTemplate(dir, file string) (string, error) {
+	if dir != "" {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err == nil {
+			return string(content), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	content, err := builtinTemplates.ReadFile("templates/" + file)
+	if err != nil && os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(content), err
+}
+
+func loadTemplate(dir, name string) (*messageTemplate, error) {
+	t := &messageTemplate{}
+	content, err := readTemplate(dir, name+TEMPLATE_TEXT_SUFFIX)
+	if err != nil {
+		return nil, err
+	} else if content == "" {
+		return nil, fmt.Errorf("template %s not found", name+TEMPLATE_TEXT_SUFFIX)
+	}
+	t.text, err = texttemplate.New(name).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if t.text.Lookup(TEMPLATE_SUBJECT) == nil {
+		return nil, fmt.Errorf("template %s does not define %q", name+TEMPLATE_TEXT_SUFFIX, TEMPLATE_SUBJECT)
+	}
+	content, err = readTemplate(dir, name+TEMPLATE_HTML_SUFFIX)
+	if err != nil {
+		return nil, err
+	} else if content != "" {
+		t.html, err = htmltemplate.New(name).Parse(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func loadTemplates(dir string) error {
+	for _, name := range []string{TEMPLATE_RESET_PASSWORD, TEMPLATE_ALERT} {
+		t, err := loadTemplate(dir, name)
+		if err != nil {
+			return fmt.Errorf("load email template %s failed: %v", name, err)
+		}
+		templates[name] = t
+	}
+	return nil
+}
+
+func render(name string, data interface{}) (*message, error) {
+	t, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("email template %s not loaded", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, TEMPLATE_SUBJECT, data); err != nil {
+		return nil, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	msg := &message{
+		// the header can not be folded
+		subject: strings.Join(strings.Fields(subject.String()), " "),
+		text:    text.Bytes(),
+	}
+	if t.html != nil {
+		if err := t.html.Execute(&html, data); err != nil {
+			return nil, err
+		}
+		msg.html = html.Bytes()
+	}
+	return msg, nil
+}
@@ -24,63 +24,109 @@ package email
 
 import (
 	"fmt"
-	"net/smtp"
+	"time"
 
-	"github.com/jordan-wright/email"
 	"github.com/opencurve/pigeon"
 )
 
 const (
-	EMAIL_ADDRESS = "email.addr"
-	EMAIL_AUTH    = "email.auth"
+	EMAIL_ADDRESS         = "email.addr"
+	EMAIL_AUTH            = "email.auth"
+	EMAIL_SERVER          = "email.server"
+	EMAIL_PORT            = "email.port"
+	EMAIL_TLS             = "email.tls"
+	EMAIL_TLS_SKIP_VERIFY = "email.tls_insecure_skip_verify"
+	EMAIL_AUTH_MECHANISM  = "email.auth_mechanism"
+	EMAIL_USERNAME        = "email.username"
+	EMAIL_SENDER_NAME     = "email.sender_name"
+	EMAIL_TEMPLATE_DIR    = "email.template_dir"
 
-	EMAIL_SERVER_163             = "smtp.163.com"
-	EMAIL_SERVER_163_ENDPOINT    = "smtp.163.com:25"
-	EMAIL_SUBJECT_RESET_PASSWORD = "Curve-Manager Reset Password"
-	EMAIL_SUBJECT_ALERT          = "Curve-Manager Alert"
+	// keep the server used before it is configurable
+	DEFAULT_EMAIL_SERVER = "smtp.163.com"
+	DEFAULT_EMAIL_PORT   = 25
 )
 
-var (
-	email_addr string
-	email_auth string
-)
+type PassWordReset struct {
+	UserName   string
+	Token      string
+	Link       string
+	Expiration time.Duration
+}
 
-func Init(cfg *pigeon.Configure) {
-	email_addr = cfg.GetConfig().GetString(EMAIL_ADDRESS)
-	email_auth = cfg.GetConfig().GetString(EMAIL_AUTH)
+// Alert is the firing or resolved alert which is also sent by other notification channels,
+// e.g. webhook, Time is when it starts
+type Alert struct {
+	ClusterId   int    `json:"clusterId"`
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	DurationSec uint32 `json:"duration"`
+	Summary     string `json:"summary"`
+	Time        string `json:"time"`
+	State       string `json:"state"`
+	Target      string `json:"target,omitempty"`
+	EndTime     string `json:"endTime,omitempty"`
 }
 
-func SendNewPassWord(name, to, passwd string) error {
-	if email_addr == "" || email_auth == "" {
-		return fmt.Errorf("the manage email info not set")
+var smtpCfg smtpConfig
+
+func Init(cfg *pigeon.Configure) error {
+	smtpCfg = smtpConfig{
+		server:             cfg.GetConfig().GetString(EMAIL_SERVER),
+		port:               cfg.GetConfig().GetInt(EMAIL_PORT),
+		tls:                cfg.GetConfig().GetString(EMAIL_TLS),
+		insecureSkipVerify: cfg.GetConfig().GetBool(EMAIL_TLS_SKIP_VERIFY),
+		auth:               cfg.GetConfig().GetString(EMAIL_AUTH_MECHANISM),
+		userName:           cfg.GetConfig().GetString(EMAIL_USERNAME),
+		passWord:           cfg.GetConfig().GetString(EMAIL_AUTH),
+	}
+	if smtpCfg.server == "" {
+		smtpCfg.server = DEFAULT_EMAIL_SERVER
+	}
+	if smtpCfg.port == 0 {
+		smtpCfg.port = DEFAULT_EMAIL_PORT
+	}
+	if smtpCfg.tls == "" {
+		smtpCfg.tls = SMTP_TLS_NONE
 	}
-	content := fmt.Sprintf("The password of Curve-Manager has been reset successfully.\n"+
-		"UserName: %s\nNewPassWord: %s\n", name, passwd)
+	if smtpCfg.auth == "" {
+		smtpCfg.auth = SMTP_AUTH_PLAIN
+	}
+	addr := cfg.GetConfig().GetString(EMAIL_ADDRESS)
+	if smtpCfg.userName == "" {
+		smtpCfg.userName = addr
+	}
+	if addr != "" {
+		smtpCfg.from = formatSender(cfg.GetConfig().GetString(EMAIL_SENDER_NAME), addr)
+	}
+	if err := smtpCfg.check(); err != nil {
+		return err
+	}
+	return loadTemplates(cfg.GetConfig().GetString(EMAIL_TEMPLATE_DIR))
+}
 
-	e := email.Email{
-		From:    email_addr,
-		To:      []string{to},
-		Subject: EMAIL_SUBJECT_RESET_PASSWORD,
-		Text:    []byte(content),
+// SendPassWordReset send the reset token, link is the reset page with token if configured
+func SendPassWordReset(to string, reset *PassWordReset) error {
+	msg, err := render(TEMPLATE_RESET_PASSWORD, reset)
+	if err != nil {
+		return err
 	}
-	return e.Send(EMAIL_SERVER_163_ENDPOINT, smtp.PlainAuth("", email_addr, email_auth, EMAIL_SERVER_163))
+	return smtpCfg.send([]string{to}, msg)
 }
 
-func SendAlert2Users(content string, tos []string) error {
-	if email_addr == "" || email_auth == "" {
-		return fmt.Errorf("the manage email info not set")
+func SendAlert2Users(alert *Alert, tos []string) error {
+	msg, err := render(TEMPLATE_ALERT, alert)
+	if err != nil {
+		return err
 	}
 	var errors error
 	for _, to := range tos {
-		e := email.Email{
-			From:    email_addr,
-			To:      []string{to},
-			Subject: EMAIL_SUBJECT_ALERT,
-			Text:    []byte(content),
-		}
-		err := e.Send(EMAIL_SERVER_163_ENDPOINT, smtp.PlainAuth("", email_addr, email_auth, EMAIL_SERVER_163))
+		err := smtpCfg.send([]string{to}, msg)
 		if err != nil {
-			errors = fmt.Errorf("dest: %s, error: %s, %s", to, err.Error(), errors.Error())
+			if errors == nil {
+				errors = fmt.Errorf("dest: %s, error: %s", to, err.Error())
+			} else {
+				errors = fmt.Errorf("dest: %s, error: %s, %s", to, err.Error(), errors.Error())
+			}
 		}
 	}
 	return errors
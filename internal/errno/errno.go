@@ -45,9 +45,40 @@ var (
 	UNSUPPORT_METHOD_ARGUMENT = Errno{400002, "unsupport method argument"}
 	HTTP_METHOD_MISMATCHED    = Errno{400003, "http method mismatch"}
 	BAD_REQUEST_FORM_PARAM    = Errno{400004, "bad request form param"}
+	INVALID_API_KEY_SCOPE     = Errno{400005, "invalid api key scope"}
+	INVALID_IP_ALLOWLIST      = Errno{400006, "invalid ip allowlist"}
+	INVALID_ROLE_METHOD       = Errno{400007, "invalid role method"}
+	INVALID_OIDC_STATE        = Errno{400008, "invalid or expired oidc state"}
+	TOTP_ALREADY_ENABLED      = Errno{400009, "two-factor authentication already enabled"}
+	TOTP_NOT_ENROLLED         = Errno{400010, "two-factor authentication not enrolled"}
+	INVALID_PASSWORD          = Errno{400011, "password does not meet the policy"}
+	PASSWORD_REUSED           = Errno{400012, "password is used recently"}
+	INVALID_RESET_TOKEN       = Errno{400013, "password reset token is invalid or expired"}
+	SESSION_NOT_EXIST         = Errno{400014, "session not exist"}
+	INVALID_WEBHOOK           = Errno{400015, "invalid webhook type, url, template, timeout or ca cert"}
+	WEBHOOK_NOT_EXIST         = Errno{400016, "webhook not exist"}
+	INVALID_ALERT_RULE        = Errno{400017, "invalid alert rule name, level, expression, operator or summary"}
+	ALERT_CONF_NOT_EXIST      = Errno{400018, "alert conf not exist"}
+	ALERT_CONF_ALREADY_EXIST  = Errno{400019, "alert conf already exist"}
+	INVALID_ALERT_SILENCE     = Errno{400020, "invalid alert silence matchers, time range or maintenance window"}
+	ALERT_SILENCE_NOT_EXIST   = Errno{400021, "alert silence not exist"}
+	ALERT_NOT_EXIST           = Errno{400022, "alert not exist"}
+	INVALID_ALERT_ASSIGNEE    = Errno{400023, "assignee is not allowed to handle alerts"}
+	INVALID_ALERT_NOTE        = Errno{400024, "alert note is empty or too long"}
+	INVALID_ESCALATION_POLICY = Errno{400025, "invalid escalation policy name, level or tiers"}
+	ESCALATION_NOT_EXIST      = Errno{400026, "escalation policy not exist"}
+	ESCALATION_ALREADY_EXIST  = Errno{400027, "escalation policy already exist"}
+	INVALID_ONCALL_SCHEDULE   = Errno{400028, "invalid on-call schedule name, users or rotation"}
+	ONCALL_NOT_EXIST          = Errno{400029, "on-call schedule not exist"}
+	ONCALL_ALREADY_EXIST      = Errno{400030, "on-call schedule already exist"}
+	ONCALL_IN_USE             = Errno{400031, "on-call schedule is used by escalation policies"}
+	PASSWORD_PREHASHED        = Errno{400032, "password must be sent in plaintext to check the policy"}
+	WEBHOOK_IN_USE            = Errno{400033, "webhook is used by escalation policies"}
+	USER_IN_USE               = Errno{400034, "user is used by escalation policies or on-call schedules"}
 
 	// 401
 	USER_IS_UNAUTHORIZED = Errno{401001, "user is unauthorized"}
+	INVALID_LOGIN_TICKET = Errno{401002, "login ticket is invalid or expired"}
 
 	// 403
 	REQUEST_IS_DENIED_FOR_SIGNATURE = Errno{403000, "request is denied for signature"}
@@ -55,10 +86,25 @@ var (
 	USER_PASSWORD_NOT_MATCH         = Errno{403002, "user password not match"}
 	WRITE_USER_LOGIN_FAILED         = Errno{403003, "only permit one write user login"}
 	OPERATION_IS_NOT_PERMIT         = Errno{403004, "operation is not permitted"}
+	VOLUME_ACCESS_DENIED            = Errno{403005, "volume access is not granted"}
+	USER_HAS_NO_PERMISSION          = Errno{403006, "user has no permission"}
+	TOTP_CODE_NOT_MATCH             = Errno{403007, "two-factor code not match"}
+	TOTP_REQUIRED                   = Errno{403008, "two-factor authentication is required"}
+	USER_IS_LOCKED                  = Errno{403009, "user is locked"}
+	PASSWORD_EXPIRED                = Errno{403010, "password is expired and must be changed"}
+	WRITE_LOCK_NOT_HELD             = Errno{403011, "session is read-only, request the write lock first"}
 
 	// 405
 	UNSUPPORT_HTTP_METHOD = Errno{405001, "unsupport http method"}
 
+	// 413
+	REQUEST_BODY_TOO_LARGE = Errno{413001, "request body is too large"}
+
+	// 429
+	LOGIN_IS_THROTTLED       = Errno{429001, "too many failed logins, try again later"}
+	RATE_LIMIT_EXCEEDED      = Errno{429002, "too many requests, try again later"}
+	PASSWORD_RESET_TOO_OFTEN = Errno{429003, "password reset is requested too often, try again later"}
+
 	// 503
 	UNKNOW_ERROR = Errno{503001, "unknown error"}
 
@@ -73,6 +119,28 @@ var (
 	LIST_USER_FAILED              = Errno{503009, "list user failed"}
 	UPDATE_USER_EMAIL_FAILED      = Errno{503010, "update user email failed"}
 	UPDATE_USER_PERMISSION_FAILED = Errno{503011, "update user permission failed"}
+	ADD_USER_SESSION_FAILED       = Errno{503012, "add user session failed"}
+	DELETE_USER_SESSION_FAILED    = Errno{503013, "delete user session failed"}
+	CREATE_API_KEY_FAILED         = Errno{503014, "create api key failed"}
+	LIST_API_KEY_FAILED           = Errno{503015, "list api key failed"}
+	GET_API_KEY_FAILED            = Errno{503016, "api key not exist"}
+	REVOKE_API_KEY_FAILED         = Errno{503017, "revoke api key failed"}
+	CREATE_ROLE_FAILED            = Errno{503018, "create role failed"}
+	UPDATE_ROLE_FAILED            = Errno{503019, "update role failed"}
+	DELETE_ROLE_FAILED            = Errno{503020, "delete role failed"}
+	GET_USER_ROLE_FAILED          = Errno{503021, "get user role failed"}
+	UPDATE_USER_ROLE_FAILED       = Errno{503022, "update user role failed"}
+	GET_VOLUME_GRANT_FAILED       = Errno{503023, "get volume grant failed"}
+	ADD_VOLUME_GRANT_FAILED       = Errno{503024, "add volume grant failed"}
+	DELETE_VOLUME_GRANT_FAILED    = Errno{503025, "delete volume grant failed"}
+	AUTHENTICATE_USER_FAILED      = Errno{503026, "authenticate user failed"}
+	OIDC_NOT_ENABLED              = Errno{503027, "oidc login not enabled"}
+	OIDC_LOGIN_FAILED             = Errno{503028, "oidc login failed"}
+	GET_USER_TOTP_FAILED          = Errno{503029, "get user two-factor authentication failed"}
+	UPDATE_USER_TOTP_FAILED       = Errno{503030, "update user two-factor authentication failed"}
+	GET_USER_LOCKOUT_FAILED       = Errno{503031, "get user lockout failed"}
+	DELETE_USER_LOCKOUT_FAILED    = Errno{503032, "unlock user failed"}
+	UPDATE_USER_SESSION_FAILED    = Errno{503033, "update user session failed"}
 
 	// hadware/metric
 	GET_INSTANCE_BY_HOSTNAME_FAILED  = Errno{503101, "get instance by hostname failed"}
@@ -132,4 +200,29 @@ var (
 	ADD_ALERT_USER_FAILED             = Errno{503333, "add alert user failed"}
 	DELETE_ALERT_USER_FAILED          = Errno{503334, "delete alert user failed"}
 	LIST_USER_WITH_EMAIL_FAILED       = Errno{503335, "list user with email failed"}
+	GET_NOTIFICATION_FAILED           = Errno{503336, "get notification failed"}
+	GET_WEBHOOK_FAILED                = Errno{503337, "get webhook failed"}
+	CREATE_WEBHOOK_FAILED             = Errno{503338, "create webhook failed"}
+	UPDATE_WEBHOOK_FAILED             = Errno{503339, "update webhook failed"}
+	DELETE_WEBHOOK_FAILED             = Errno{503340, "delete webhook failed"}
+	GET_ALERT_WEBHOOK_FAILED          = Errno{503341, "get alert webhook failed"}
+	UPDATE_ALERT_WEBHOOK_FAILED       = Errno{503342, "update alert webhook failed"}
+	CREATE_ALERT_CONF_FAILED          = Errno{503343, "create alert conf failed"}
+	DELETE_ALERT_CONF_FAILED          = Errno{503344, "delete alert conf failed"}
+	LIST_ALERT_SILENCE_FAILED         = Errno{503345, "list alert silence failed"}
+	CREATE_ALERT_SILENCE_FAILED       = Errno{503346, "create alert silence failed"}
+	UPDATE_ALERT_SILENCE_FAILED       = Errno{503347, "update alert silence failed"}
+	DELETE_ALERT_SILENCE_FAILED       = Errno{503348, "delete alert silence failed"}
+	ACK_ALERT_FAILED                  = Errno{503349, "acknowledge alert failed"}
+	ASSIGN_ALERT_FAILED               = Errno{503350, "assign alert failed"}
+	ADD_ALERT_NOTE_FAILED             = Errno{503351, "add alert note failed"}
+	LIST_ALERT_NOTE_FAILED            = Errno{503352, "list alert note failed"}
+	LIST_ESCALATION_POLICY_FAILED     = Errno{503353, "list escalation policy failed"}
+	CREATE_ESCALATION_POLICY_FAILED   = Errno{503354, "create escalation policy failed"}
+	UPDATE_ESCALATION_POLICY_FAILED   = Errno{503355, "update escalation policy failed"}
+	DELETE_ESCALATION_POLICY_FAILED   = Errno{503356, "delete escalation policy failed"}
+	LIST_ONCALL_SCHEDULE_FAILED       = Errno{503357, "list on-call schedule failed"}
+	CREATE_ONCALL_SCHEDULE_FAILED     = Errno{503358, "create on-call schedule failed"}
+	UPDATE_ONCALL_SCHEDULE_FAILED     = Errno{503359, "update on-call schedule failed"}
+	DELETE_ONCALL_SCHEDULE_FAILED     = Errno{503360, "delete on-call schedule failed"}
 )
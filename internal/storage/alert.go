@@ -23,12 +23,26 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/opencurve/curve-manager/internal/common"
 	"github.com/opencurve/curve-manager/internal/email"
 )
 
+const (
+	// the condition holds but not long enough to notify
+	ALERT_STATE_PENDING  = "pending"
+	ALERT_STATE_FIRING   = "firing"
+	ALERT_STATE_RESOLVED = "resolved"
+
+	// the status filter of alerts by acknowledgement
+	ALERT_STATUS_ACKNOWLEDGED   = "acknowledged"
+	ALERT_STATUS_UNACKNOWLEDGED = "unacknowledged"
+)
+
+// Alert is the lifecycle of an alert instance identified by fingerprint,
+// TimeMs is the start time when the condition is observed firstly
 type Alert struct {
 	Id          int64  `json:"id"`
 	ClusterId   int    `json:"-"`
@@ -39,6 +53,32 @@ type Alert struct {
 	Name        string `json:"name"`
 	DurationSec uint32 `json:"duration"`
 	Summary     string `json:"summary"`
+	Fingerprint string `json:"fingerprint"`
+	// what the alert is about in the rule, e.g. the labels of a series, empty if the whole cluster
+	Target    string `json:"target"`
+	State     string `json:"state"`
+	EndTimeMs int64  `json:"-"`
+	EndTime   string `json:"endTime"`
+	// the time of the last notification
+	NotifyTimeMs int64 `json:"-"`
+	// who acknowledged the alert, the firing alert acknowledged is not notified again
+	AckUser   string `json:"ackUser"`
+	AckTimeMs int64  `json:"-"`
+	AckTime   string `json:"ackTime"`
+	// the manager user handling the alert
+	Assignee string `json:"assignee"`
+	// the time when the alert turns firing, which the escalation delays are from
+	FireTimeMs int64 `json:"-"`
+	// the number of escalation tiers notified
+	Escalation int `json:"escalation"`
+}
+
+type AlertNote struct {
+	Id      int64  `json:"id"`
+	User    string `json:"user"`
+	TimeMs  int64  `json:"-"`
+	Time    string `json:"time"`
+	Content string `json:"content"`
 }
 
 type AlertInfo struct {
@@ -47,40 +87,172 @@ type AlertInfo struct {
 }
 
 func AddAlert(alert *Alert) error {
-	return gStorage.execSQL(ADD_SYSTEM_ALERT, alert.ClusterId, alert.TimeMs, alert.Level, alert.Name, alert.DurationSec, alert.Summary)
+	return gStorage.execSQL(ADD_SYSTEM_ALERT, alert.ClusterId, alert.TimeMs, alert.Level, alert.Name, alert.DurationSec,
+		alert.Summary, alert.Fingerprint, alert.Target, alert.State, alert.EndTimeMs, alert.NotifyTimeMs,
+		alert.FireTimeMs, alert.Escalation)
+}
+
+func UpdateAlert(alert *Alert) error {
+	return gStorage.execSQL(UPDATE_SYSTEM_ALERT, alert.Level, alert.DurationSec, alert.Summary, alert.State,
+		alert.EndTimeMs, alert.NotifyTimeMs, alert.FireTimeMs, alert.Escalation, alert.Id)
+}
+
+// RemoveAlert delete the alert by id, used to drop the pending alert whose condition cleared
+func RemoveAlert(id int64) error {
+	err := gStorage.execSQL(REMOVE_SYSTEM_ALERT, id)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_ALERT_NOTE_OF_ALERT, id)
+}
+
+func scanAlert(rows *sql.Rows) (Alert, error) {
+	var alert Alert
+	err := rows.Scan(&alert.Id, &alert.ClusterId, &alert.TimeMs, &alert.Level, &alert.Name, &alert.DurationSec,
+		&alert.Summary, &alert.Fingerprint, &alert.Target, &alert.State, &alert.EndTimeMs, &alert.NotifyTimeMs,
+		&alert.AckUser, &alert.AckTimeMs, &alert.Assignee, &alert.FireTimeMs, &alert.Escalation)
+	if err != nil {
+		return alert, err
+	}
+	alert.Time = common.Mill2TimeStr(alert.TimeMs)
+	if alert.EndTimeMs > 0 {
+		alert.EndTime = common.Mill2TimeStr(alert.EndTimeMs)
+	}
+	if alert.AckTimeMs > 0 {
+		alert.AckTime = common.Mill2TimeStr(alert.AckTimeMs)
+	}
+	alert.LevelStr = getLevelStr(alert.Level)
+	return alert, nil
+}
+
+// GetActiveAlert returns the pending and firing alerts of the rule
+func GetActiveAlert(clusterId int, name string) ([]Alert, error) {
+	alerts := []Alert{}
+	rows, err := gStorage.querySQL(GET_ACTIVE_SYSTEM_ALERT, clusterId, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// GetAlertById returns sql.ErrNoRows if the alert not exist
+func GetAlertById(clusterId int, id int64) (Alert, error) {
+	rows, err := gStorage.querySQL(GET_SYSTEM_ALERT_BY_ID, id, clusterId)
+	if err != nil {
+		return Alert{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return Alert{}, sql.ErrNoRows
+	}
+	return scanAlert(rows)
 }
 
 func DeleteAlert(clusterId int, expirationMs int64) error {
-	return gStorage.execSQL(DELETE_SYSTEM_ALERT, clusterId, expirationMs)
+	err := gStorage.execSQL(DELETE_SYSTEM_ALERT, clusterId, expirationMs)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_EXPIRED_ALERT_NOTE)
+}
+
+// AckAlert set or clear (empty user) the acknowledgement of the alert, returns false if not exist
+func AckAlert(clusterId int, id int64, user string, timeMs int64) (bool, error) {
+	affected, err := gStorage.execSQLAffected(ACK_SYSTEM_ALERT, user, timeMs, id, clusterId)
+	return affected > 0, err
+}
+
+// AssignAlert set or clear (empty assignee) the assignee of the alert, returns false if not exist
+func AssignAlert(clusterId int, id int64, assignee string) (bool, error) {
+	affected, err := gStorage.execSQLAffected(ASSIGN_SYSTEM_ALERT, assignee, id, clusterId)
+	return affected > 0, err
+}
+
+func AddAlertNote(alertId int64, note *AlertNote) error {
+	id, err := gStorage.execSQLInsert(ADD_ALERT_NOTE, alertId, note.User, note.TimeMs, note.Content)
+	note.Id = id
+	return err
+}
+
+func ListAlertNote(alertId int64) ([]AlertNote, error) {
+	notes := []AlertNote{}
+	rows, err := gStorage.querySQL(LIST_ALERT_NOTE, alertId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var note AlertNote
+		err = rows.Scan(&note.Id, &note.User, &note.TimeMs, &note.Content)
+		if err != nil {
+			return nil, err
+		}
+		note.Time = common.Mill2TimeStr(note.TimeMs)
+		notes = append(notes, note)
+	}
+	return notes, nil
 }
 
+// SendAlert put the alert to the users and webhooks subscribed into notification outbox at
+// alert.NotifyTimeMs, which is delivered by the notification workers
 func SendAlert(clusterId int, alert *Alert) error {
 	users, err := GetAlertUser(clusterId, alert.Name)
 	if err != nil {
 		return err
 	}
-	emails := []string{}
+	webhooks, err := GetAlertWebhook(clusterId, alert.Name)
+	if err != nil {
+		return err
+	}
+	return SendAlertTo(alert, users, webhooks)
+}
+
+// SendAlertTo put the alert to the users and webhooks into notification outbox at alert.NotifyTimeMs
+func SendAlertTo(alert *Alert, users, webhooks []string) error {
+	message := &email.Alert{
+		ClusterId:   alert.ClusterId,
+		Name:        alert.Name,
+		Level:       getLevelStr(alert.Level),
+		DurationSec: alert.DurationSec,
+		Summary:     alert.Summary,
+		Time:        common.Mill2TimeStr(alert.TimeMs),
+		State:       alert.State,
+		Target:      alert.Target,
+	}
+	if alert.EndTimeMs > 0 {
+		message.EndTime = common.Mill2TimeStr(alert.EndTimeMs)
+	}
 	var errors error
 	for _, user := range users {
-		email, err := GetUserEmail(user)
+		addr, err := GetUserEmail(user)
+		if err == nil && addr == "" {
+			err = fmt.Errorf("email not set")
+		}
+		if err == nil {
+			err = AddNotification(NOTIFICATION_CHANNEL_EMAIL, NOTIFICATION_KIND_ALERT, user, addr, message, alert.NotifyTimeMs)
+		}
 		if err != nil {
-			errors = fmt.Errorf("user: %s, error: %s, %s", user, err.Error(), errors.Error())
-		} else {
-			emails = append(emails, email)
+			errors = joinError(errors, fmt.Errorf("user: %s, error: %s", user, err.Error()))
 		}
 	}
-	if len(emails) != 0 {
-		content := fmt.Sprintf("Alert Info:\nCluster ID: %d\nName: %s\nLevel: %s\nDuration Second: %d\nSummary: %s\nTime: %s\n",
-			alert.ClusterId, alert.Name, getLevelStr(alert.Level), alert.DurationSec, alert.Summary, common.Mill2TimeStr(alert.TimeMs))
-		err = email.SendAlert2Users(content, emails)
+	for _, webhook := range webhooks {
+		err := AddNotification(NOTIFICATION_CHANNEL_WEBHOOK, NOTIFICATION_KIND_ALERT, "", webhook, message, alert.NotifyTimeMs)
 		if err != nil {
-			errors = fmt.Errorf("send email error: %s, %s", err.Error(), errors.Error())
+			errors = joinError(errors, fmt.Errorf("webhook: %s, error: %s", webhook, err.Error()))
 		}
 	}
 	return errors
 }
 
-func GetAlert(clusterId int, start, end int64, limit, offset uint32, name, level, filter string) (AlertInfo, error) {
+func GetAlert(clusterId int, start, end int64, limit, offset uint32, name, level, state, status, assignee, filter string) (AlertInfo, error) {
 	alerts := AlertInfo{}
 	filter = fmt.Sprintf("%%%s%%", filter)
 	numSql := GET_SYSTEM_ALERT_NUM
@@ -103,6 +275,24 @@ func GetAlert(clusterId int, start, end int64, limit, offset uint32, name, level
 		contSql += " AND level = ?"
 		params = append(params, l)
 	}
+	if state != "" {
+		numSql += " AND state = ?"
+		contSql += " AND state = ?"
+		params = append(params, state)
+	}
+	switch status {
+	case ALERT_STATUS_ACKNOWLEDGED:
+		numSql += " AND ack_time > 0"
+		contSql += " AND ack_time > 0"
+	case ALERT_STATUS_UNACKNOWLEDGED:
+		numSql += " AND ack_time = 0"
+		contSql += " AND ack_time = 0"
+	}
+	if assignee != "" {
+		numSql += " AND assignee = ?"
+		contSql += " AND assignee = ?"
+		params = append(params, assignee)
+	}
 	if filter != "" {
 		numSql += " AND summary like ?"
 		contSql += " AND summary like ?"
@@ -127,13 +317,10 @@ func GetAlert(clusterId int, start, end int64, limit, offset uint32, name, level
 			return alerts, err
 		}
 		for rows.Next() {
-			var alert Alert
-			err = rows.Scan(&alert.Id, &alert.ClusterId, &alert.TimeMs, &alert.Level, &alert.Name, &alert.DurationSec, &alert.Summary)
+			alert, err := scanAlert(rows)
 			if err != nil {
 				return alerts, err
 			}
-			alert.Time = common.Mill2TimeStr(alert.TimeMs)
-			alert.LevelStr = getLevelStr(alert.Level)
 			alerts.Info = append(alerts.Info, alert)
 		}
 	}
@@ -186,3 +373,11 @@ func GetReadAlertId(userName string) (int64, error) {
 func AddReadAlertId(userName string) error {
 	return gStorage.execSQL(ADD_READ_ALERT_ID, userName, 0)
 }
+
+// joinError append err to errs, which may be nil
+func joinError(errs, err error) error {
+	if errs == nil {
+		return err
+	}
+	return fmt.Errorf("%s, %s", err.Error(), errs.Error())
+}
@@ -22,6 +22,10 @@
 
 package storage
 
+import (
+	"database/sql"
+)
+
 const (
 	ALERT_CRITICAL = 1
 	ALERT_WARNING  = 2
@@ -53,17 +57,81 @@ type AlertConf struct {
 	EnableBool bool
 	Rule       string
 	Desc       string
+	// the fields below are only used by the custom rules
+	Custom    bool
+	Creator   string
+	Expr      string
+	Op        string
+	Threshold float64
+	ForSec    uint32
+	Summary   string
+}
+
+// GetLevel returns the level of levelStr, 0 if invalid
+func GetLevel(levelStr string) int {
+	switch levelStr {
+	case CRITICAL:
+		return ALERT_CRITICAL
+	case WARNING:
+		return ALERT_WARNING
+	default:
+		return 0
+	}
 }
 
 func AddAlertConf(conf *AlertConf) error {
-	return gStorage.execSQL(ADD_ALERT_CONF, conf.ClusterId, conf.Name, conf.Level, conf.Interval, conf.Times,
-		conf.Enable, conf.Rule, conf.Desc)
+	_, err := addAlertConf(conf)
+	return err
+}
+
+// CreateAlertConf add the custom rule, returns false if the name exists
+func CreateAlertConf(conf *AlertConf) (bool, error) {
+	conf.Custom = true
+	affected, err := addAlertConf(conf)
+	return affected > 0, err
+}
+
+func addAlertConf(conf *AlertConf) (int64, error) {
+	return gStorage.execSQLAffected(ADD_ALERT_CONF, conf.ClusterId, conf.Name, conf.Level, conf.Interval, conf.Times,
+		conf.Enable, conf.Rule, conf.Desc, conf.Custom, conf.Creator, conf.Expr, conf.Op, conf.Threshold,
+		conf.ForSec, conf.Summary)
 }
 
 func UpdateAlertConf(conf *AlertConf) error {
 	return gStorage.execSQL(UPDATE_ALERT_CONF, conf.Interval, conf.Times, conf.Enable, conf.Rule, conf.ClusterId, conf.Name)
 }
 
+// UpdateCustomAlertConf update all fields of the custom rule except creator
+func UpdateCustomAlertConf(conf *AlertConf) error {
+	return gStorage.execSQL(UPDATE_CUSTOM_ALERT_CONF, conf.Level, conf.Interval, conf.Enable, conf.Rule, conf.Desc,
+		conf.Expr, conf.Op, conf.Threshold, conf.ForSec, conf.Summary, conf.ClusterId, conf.Name)
+}
+
+// DeleteCustomAlertConf delete the custom rule and its receivers
+func DeleteCustomAlertConf(clusterId int, name string) error {
+	err := gStorage.execSQL(DELETE_CUSTOM_ALERT_CONF, clusterId, name)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(DELETE_ALERT_USER_OF_ALERT, clusterId, name)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_ALERT_WEBHOOK_OF_ALERT, clusterId, name)
+}
+
+func scanAlertConf(rows *sql.Rows) (AlertConf, error) {
+	item := AlertConf{}
+	err := rows.Scan(&item.ClusterId, &item.Name, &item.Level, &item.Interval, &item.Times, &item.Enable, &item.Rule,
+		&item.Desc, &item.Custom, &item.Creator, &item.Expr, &item.Op, &item.Threshold, &item.ForSec, &item.Summary)
+	if err != nil {
+		return item, err
+	}
+	item.LevelStr = getLevelStr(item.Level)
+	item.EnableBool = item.Enable == 1
+	return item, nil
+}
+
 func GetAlertConf(clusterId int) ([]AlertConf, error) {
 	info := []AlertConf{}
 	rows, err := gStorage.querySQL(GET_ALERT_CONF, clusterId)
@@ -72,14 +140,24 @@ func GetAlertConf(clusterId int) ([]AlertConf, error) {
 	}
 	defer rows.Close()
 	for rows.Next() {
-		item := AlertConf{}
-		err := rows.Scan(&item.ClusterId, &item.Name, &item.Level, &item.Interval, &item.Times, &item.Enable, &item.Rule, &item.Desc)
+		item, err := scanAlertConf(rows)
 		if err != nil {
 			return nil, err
 		}
-		item.LevelStr = getLevelStr(item.Level)
-		item.EnableBool = item.Enable == 1
 		info = append(info, item)
 	}
 	return info, nil
 }
+
+// GetAlertConfByName returns sql.ErrNoRows if not exist
+func GetAlertConfByName(clusterId int, name string) (AlertConf, error) {
+	rows, err := gStorage.querySQL(GET_ALERT_CONF_BY_NAME, clusterId, name)
+	if err != nil {
+		return AlertConf{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return AlertConf{}, sql.ErrNoRows
+	}
+	return scanAlertConf(rows)
+}
@@ -0,0 +1,198 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/common"
+)
+
+const (
+	// an api key looks like <id>.<secret>, only the hash of secret is stored
+	API_KEY_DELIMITER    = "."
+	API_KEY_ID_BYTES     = 8
+	API_KEY_SECRET_BYTES = 32
+
+	API_KEY_LIST_DELIMITER = ","
+)
+
+type ApiKey struct {
+	Id       string `json:"id"`
+	UserName string `json:"userName"`
+	// sha256 of the secret part
+	secret string
+
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+	IPAllowlist []string `json:"ipAllowlist"`
+	// unix seconds, 0 means never expire
+	ExpireTime int64 `json:"expireTime"`
+	CreateTime int64 `json:"createTime"`
+	LastUsed   int64 `json:"lastUsed"`
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, API_KEY_LIST_DELIMITER)
+}
+
+func scanApiKey(rows *sql.Rows) (ApiKey, error) {
+	var key ApiKey
+	var scopes, ips string
+	err := rows.Scan(&key.Id, &key.secret, &key.UserName, &key.Name, &scopes, &ips, &key.ExpireTime,
+		&key.CreateTime, &key.LastUsed)
+	if err != nil {
+		return key, err
+	}
+	key.Scopes = splitList(scopes)
+	key.IPAllowlist = splitList(ips)
+	return key, nil
+}
+
+// ipAllowed reports whether ip matches one of the ips or cidrs in allowlist, empty allowlist allows all
+func ipAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, item := range allowlist {
+		if strings.Contains(item, "/") {
+			_, ipNet, err := net.ParseCIDR(item)
+			if err == nil && ipNet.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(item); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateApiKey save the key and return the plaintext of it, which can not be got again
+func CreateApiKey(key *ApiKey) (string, error) {
+	id, err := common.GetSecureRandString(API_KEY_ID_BYTES)
+	if err != nil {
+		return "", err
+	}
+	secret, err := common.GetSecureRandString(API_KEY_SECRET_BYTES)
+	if err != nil {
+		return "", err
+	}
+	key.Id = id
+	key.CreateTime = time.Now().Unix()
+	err = gStorage.execSQL(ADD_API_KEY, key.Id, hashToken(secret), key.UserName, key.Name,
+		strings.Join(key.Scopes, API_KEY_LIST_DELIMITER), strings.Join(key.IPAllowlist, API_KEY_LIST_DELIMITER),
+		key.ExpireTime, key.CreateTime, 0)
+	if err != nil {
+		return "", err
+	}
+	return id + API_KEY_DELIMITER + secret, nil
+}
+
+func GetApiKey(id string) (ApiKey, error) {
+	rows, err := gStorage.querySQL(GET_API_KEY, id)
+	if err != nil {
+		return ApiKey{}, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return scanApiKey(rows)
+	}
+	return ApiKey{}, fmt.Errorf("api key not exist")
+}
+
+// ListApiKey list the keys of userName, or all keys if userName is empty
+func ListApiKey(userName string) ([]ApiKey, error) {
+	keys := []ApiKey{}
+	var rows *sql.Rows
+	var err error
+	if userName == "" {
+		rows, err = gStorage.querySQL(LIST_API_KEY)
+	} else {
+		rows, err = gStorage.querySQL(LIST_API_KEY_OF_USER, userName)
+	}
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func DeleteApiKey(id string) error {
+	return gStorage.execSQL(DELETE_API_KEY, id)
+}
+
+// CheckApiKey check the key is valid, not expired and used from an allowed ip
+func CheckApiKey(s, ip string) (ApiKey, error) {
+	id, secret, ok := strings.Cut(s, API_KEY_DELIMITER)
+	if !ok {
+		return ApiKey{}, fmt.Errorf("invalid api key format")
+	}
+	key, err := GetApiKey(id)
+	if err != nil {
+		return key, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.secret), []byte(hashToken(secret))) != 1 {
+		return key, fmt.Errorf("api key secret not match")
+	}
+	now := time.Now().Unix()
+	if key.ExpireTime > 0 && key.ExpireTime < now {
+		return key, fmt.Errorf("api key expired")
+	}
+	if !ipAllowed(key.IPAllowlist, ip) {
+		return key, fmt.Errorf("ip %s not in allowlist", ip)
+	}
+	// avoid writing db on every request
+	if key.LastUsed+SESSION_TOUCH_INTERVAL_SEC <= now && gStorage.execSQL(UPDATE_API_KEY_LAST_USED, now, id) == nil {
+		key.LastUsed = now
+	}
+	return key, nil
+}
+
+// HasScope reports whether method is in the scopes of key
+func (key *ApiKey) HasScope(method string) bool {
+	for _, scope := range key.Scopes {
+		if scope == method {
+			return true
+		}
+	}
+	return false
+}
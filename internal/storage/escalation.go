@@ -0,0 +1,277 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// EscalationTier notifies its recipients if the alert is still firing without
+// acknowledgement in Delay minutes since it fires
+type EscalationTier struct {
+	DelayMin uint32   `json:"delay"`
+	Users    []string `json:"users"`
+	Webhooks []string `json:"webhooks"`
+	// the on-call user of the schedule is notified by email
+	Schedule string `json:"schedule"`
+}
+
+/*
+* EscalationPolicy escalates the unacknowledged firing alerts of the level through the tiers
+* in order, in addition to the users and webhooks subscribed:
+* 1. the policy of an alert only matches the alert, and is preferred to the one of the level
+* 2. each tier is notified once, and again when the alert resolves
+ */
+type EscalationPolicy struct {
+	ClusterId int              `json:"-"`
+	Name      string           `json:"name"`
+	Level     int              `json:"-"`
+	LevelStr  string           `json:"level"`
+	Alert     string           `json:"alert"`
+	Enable    bool             `json:"enable"`
+	Tiers     []EscalationTier `json:"tiers"`
+}
+
+// OnCallSchedule rotates the users every RotationHours since StartTime
+type OnCallSchedule struct {
+	ClusterId     int      `json:"-"`
+	Name          string   `json:"name"`
+	Users         []string `json:"users"`
+	StartTimeMs   int64    `json:"startTime"`
+	RotationHours uint32   `json:"rotationHours"`
+}
+
+func (p *EscalationPolicy) Matches(alert *Alert) bool {
+	return p.Enable && p.Level == alert.Level && (p.Alert == "" || p.Alert == alert.Name)
+}
+
+// MatchEscalationPolicy returns the policy escalating the alert, nil if none
+func MatchEscalationPolicy(policies []EscalationPolicy, alert *Alert) *EscalationPolicy {
+	var matched *EscalationPolicy
+	for i := range policies {
+		if !policies[i].Matches(alert) {
+			continue
+		}
+		if policies[i].Alert != "" {
+			return &policies[i]
+		}
+		if matched == nil {
+			matched = &policies[i]
+		}
+	}
+	return matched
+}
+
+// OnCall returns the user on call at now, the first one before the schedule starts
+func (s *OnCallSchedule) OnCall(now time.Time) string {
+	if len(s.Users) == 0 || s.RotationHours == 0 {
+		return ""
+	}
+	elapsed := now.UnixMilli() - s.StartTimeMs
+	if elapsed < 0 {
+		return s.Users[0]
+	}
+	turn := elapsed / (int64(s.RotationHours) * time.Hour.Milliseconds())
+	return s.Users[turn%int64(len(s.Users))]
+}
+
+func scanEscalationPolicy(rows *sql.Rows) (EscalationPolicy, error) {
+	var p EscalationPolicy
+	var tiers string
+	err := rows.Scan(&p.ClusterId, &p.Name, &p.Level, &p.Alert, &p.Enable, &tiers)
+	if err != nil {
+		return p, err
+	}
+	p.LevelStr = getLevelStr(p.Level)
+	err = json.Unmarshal([]byte(tiers), &p.Tiers)
+	return p, err
+}
+
+// CreateEscalationPolicy returns false if the policy already exist
+func CreateEscalationPolicy(p *EscalationPolicy) (bool, error) {
+	tiers, err := json.Marshal(p.Tiers)
+	if err != nil {
+		return false, err
+	}
+	affected, err := gStorage.execSQLAffected(CREATE_ESCALATION_POLICY, p.ClusterId, p.Name, p.Level, p.Alert,
+		p.Enable, string(tiers))
+	return affected > 0, err
+}
+
+// UpdateEscalationPolicy returns false if the policy not exist
+func UpdateEscalationPolicy(p *EscalationPolicy) (bool, error) {
+	tiers, err := json.Marshal(p.Tiers)
+	if err != nil {
+		return false, err
+	}
+	affected, err := gStorage.execSQLAffected(UPDATE_ESCALATION_POLICY, p.Level, p.Alert, p.Enable, string(tiers),
+		p.ClusterId, p.Name)
+	return affected > 0, err
+}
+
+// DeleteEscalationPolicy returns false if the policy not exist
+func DeleteEscalationPolicy(clusterId int, name string) (bool, error) {
+	affected, err := gStorage.execSQLAffected(DELETE_ESCALATION_POLICY, clusterId, name)
+	return affected > 0, err
+}
+
+func ListEscalationPolicy(clusterId int) ([]EscalationPolicy, error) {
+	return listEscalationPolicy(LIST_ESCALATION_POLICY, clusterId)
+}
+
+func listEscalationPolicy(query string, args ...interface{}) ([]EscalationPolicy, error) {
+	policies := []EscalationPolicy{}
+	rows, err := gStorage.querySQL(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p, err := scanEscalationPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func scanOnCallSchedule(rows *sql.Rows) (OnCallSchedule, error) {
+	var s OnCallSchedule
+	var users string
+	err := rows.Scan(&s.ClusterId, &s.Name, &users, &s.StartTimeMs, &s.RotationHours)
+	if err != nil {
+		return s, err
+	}
+	s.Users = strings.Split(users, ",")
+	return s, nil
+}
+
+// CreateOnCallSchedule returns false if the schedule already exist
+func CreateOnCallSchedule(s *OnCallSchedule) (bool, error) {
+	affected, err := gStorage.execSQLAffected(CREATE_ONCALL_SCHEDULE, s.ClusterId, s.Name, strings.Join(s.Users, ","),
+		s.StartTimeMs, s.RotationHours)
+	return affected > 0, err
+}
+
+// UpdateOnCallSchedule returns false if the schedule not exist
+func UpdateOnCallSchedule(s *OnCallSchedule) (bool, error) {
+	affected, err := gStorage.execSQLAffected(UPDATE_ONCALL_SCHEDULE, strings.Join(s.Users, ","), s.StartTimeMs,
+		s.RotationHours, s.ClusterId, s.Name)
+	return affected > 0, err
+}
+
+// DeleteOnCallSchedule returns false if the schedule not exist
+func DeleteOnCallSchedule(clusterId int, name string) (bool, error) {
+	affected, err := gStorage.execSQLAffected(DELETE_ONCALL_SCHEDULE, clusterId, name)
+	return affected > 0, err
+}
+
+// GetOnCallSchedule returns sql.ErrNoRows if the schedule not exist
+func GetOnCallSchedule(clusterId int, name string) (OnCallSchedule, error) {
+	rows, err := gStorage.querySQL(GET_ONCALL_SCHEDULE, clusterId, name)
+	if err != nil {
+		return OnCallSchedule{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return OnCallSchedule{}, sql.ErrNoRows
+	}
+	return scanOnCallSchedule(rows)
+}
+
+func ListOnCallSchedule(clusterId int) ([]OnCallSchedule, error) {
+	return listOnCallSchedule(LIST_ONCALL_SCHEDULE, clusterId)
+}
+
+func listOnCallSchedule(query string, args ...interface{}) ([]OnCallSchedule, error) {
+	schedules := []OnCallSchedule{}
+	rows, err := gStorage.querySQL(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanOnCallSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWebhookEscalated reports whether the webhook is notified by an escalation policy
+// of any cluster, as the webhooks are shared by clusters
+func IsWebhookEscalated(name string) (bool, error) {
+	policies, err := listEscalationPolicy(LIST_ALL_ESCALATION_POLICY)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range policies {
+		for _, tier := range p.Tiers {
+			if containsString(tier.Webhooks, name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// IsUserEscalated reports whether the user is notified by an escalation policy
+// or is on an on-call schedule of any cluster, as the users are shared by clusters
+func IsUserEscalated(name string) (bool, error) {
+	policies, err := listEscalationPolicy(LIST_ALL_ESCALATION_POLICY)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range policies {
+		for _, tier := range p.Tiers {
+			if containsString(tier.Users, name) {
+				return true, nil
+			}
+		}
+	}
+	schedules, err := listOnCallSchedule(LIST_ALL_ONCALL_SCHEDULE)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range schedules {
+		if containsString(s.Users, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,72 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+)
+
+const (
+	GRANT_MODE_READ_ONLY  = "ro"
+	GRANT_MODE_READ_WRITE = "rw"
+)
+
+// VolumeGrant permit the user to access the directory and everything under it
+type VolumeGrant struct {
+	UserName string `json:"userName"`
+	Dir      string `json:"dir"`
+	Mode     string `json:"mode"`
+}
+
+func AddVolumeGrant(grant *VolumeGrant) error {
+	return gStorage.execSQL(ADD_VOLUME_GRANT, grant.UserName, grant.Dir, grant.Mode)
+}
+
+func DeleteVolumeGrant(userName, dir string) error {
+	return gStorage.execSQL(DELETE_VOLUME_GRANT, userName, dir)
+}
+
+// ListVolumeGrant list the grants of userName, or all grants if userName is empty
+func ListVolumeGrant(userName string) ([]VolumeGrant, error) {
+	grants := []VolumeGrant{}
+	var rows *sql.Rows
+	var err error
+	if userName == "" {
+		rows, err = gStorage.querySQL(LIST_VOLUME_GRANT)
+	} else {
+		rows, err = gStorage.querySQL(LIST_VOLUME_GRANT_OF_USER, userName)
+	}
+	if err != nil {
+		return grants, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var grant VolumeGrant
+		err = rows.Scan(&grant.UserName, &grant.Dir, &grant.Mode)
+		if err != nil {
+			return grants, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
@@ -0,0 +1,81 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+// UserLockout is the failed logins of user since the last successful login
+type UserLockout struct {
+	UserName string `json:"userName"`
+	Failures int    `json:"failures"`
+	// unix seconds of the first failure in the current window
+	WindowStart int64 `json:"windowStart"`
+	// unix seconds, locked if later than now
+	LockedUntil int64 `json:"lockedUntil"`
+	// times of temporary lockout, locked permanently when reach the limit
+	Lockouts  int  `json:"lockouts"`
+	Permanent bool `json:"permanent"`
+}
+
+// GetUserLockout returns an empty record if the user never failed
+func GetUserLockout(name string) (UserLockout, error) {
+	lockout := UserLockout{UserName: name}
+	rows, err := gStorage.querySQL(GET_USER_LOCKOUT, name)
+	if err != nil {
+		return lockout, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&lockout.UserName, &lockout.Failures, &lockout.WindowStart,
+			&lockout.LockedUntil, &lockout.Lockouts, &lockout.Permanent)
+	}
+	return lockout, err
+}
+
+func SetUserLockout(lockout *UserLockout) error {
+	return gStorage.execSQL(SET_USER_LOCKOUT, lockout.UserName, lockout.Failures, lockout.WindowStart,
+		lockout.LockedUntil, lockout.Lockouts, lockout.Permanent)
+}
+
+// DeleteUserLockout clear the failures and unlock the user
+func DeleteUserLockout(name string) error {
+	return gStorage.execSQL(DELETE_USER_LOCKOUT, name)
+}
+
+// ListLockedUser list the users locked at now
+func ListLockedUser(now int64) ([]UserLockout, error) {
+	lockouts := []UserLockout{}
+	rows, err := gStorage.querySQL(LIST_LOCKED_USER, now)
+	if err != nil {
+		return lockouts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lockout UserLockout
+		err = rows.Scan(&lockout.UserName, &lockout.Failures, &lockout.WindowStart,
+			&lockout.LockedUntil, &lockout.Lockouts, &lockout.Permanent)
+		if err != nil {
+			return lockouts, err
+		}
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts, nil
+}
@@ -41,6 +41,7 @@ type Log struct {
 	ErrorCode int    `json:"errorCode"`
 	ErrorMsg  string `json:"errorMsg"`
 	Content   string `json:"content"`
+	ApiKey    string `json:"apiKey"`
 }
 
 type LogInfo struct {
@@ -50,7 +51,7 @@ type LogInfo struct {
 
 func AddSystemLog(log *Log) error {
 	return gStorage.execSQL(ADD_SYSTEM_LOG, log.TimeMs, log.IP, log.User, log.Module, log.Method, log.ErrorCode,
-		log.ErrorMsg, log.Content)
+		log.ErrorMsg, log.Content, log.ApiKey)
 }
 
 func DeleteSystemLog(expirationMs int64) error {
@@ -90,7 +91,7 @@ func GetSystemLog(start, end int64, limit, offset uint32, filter, userName strin
 		for rows.Next() {
 			var log Log
 			err = rows.Scan(&log.id, &log.TimeMs, &log.IP, &log.User, &log.Module, &log.Method, &log.ErrorCode,
-				&log.ErrorMsg, &log.Content)
+				&log.ErrorMsg, &log.Content, &log.ApiKey)
 			if err != nil {
 				return logs, err
 			}
@@ -0,0 +1,176 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencurve/curve-manager/internal/common"
+)
+
+const (
+	NOTIFICATION_CHANNEL_EMAIL   = "email"
+	NOTIFICATION_CHANNEL_WEBHOOK = "webhook"
+
+	NOTIFICATION_KIND_ALERT = "alert"
+
+	// pending -> sending -> delivered, or back to pending to retry until dead
+	NOTIFICATION_STATE_PENDING   = "pending"
+	NOTIFICATION_STATE_SENDING   = "sending"
+	NOTIFICATION_STATE_DELIVERED = "delivered"
+	NOTIFICATION_STATE_DEAD      = "dead"
+)
+
+// Notification is a message to one recipient in the outbox, times are in milliseconds
+type Notification struct {
+	Id         int64  `json:"id"`
+	CreateTime int64  `json:"-"`
+	Time       string `json:"time"`
+	Channel    string `json:"channel"`
+	Kind       string `json:"kind"`
+	UserName   string `json:"userName"`
+	Recipient  string `json:"recipient"`
+	// the json of message, rendered by the channel
+	Payload    string `json:"-"`
+	State      string `json:"state"`
+	Attempts   int    `json:"attempts"`
+	NextTime   int64  `json:"nextTime"`
+	LastError  string `json:"lastError"`
+	UpdateTime int64  `json:"updateTime"`
+}
+
+type NotificationInfo struct {
+	Total int64          `json:"total"`
+	Info  []Notification `json:"info"`
+}
+
+func scanNotification(rows interface{ Scan(...interface{}) error }) (Notification, error) {
+	var n Notification
+	err := rows.Scan(&n.Id, &n.CreateTime, &n.Channel, &n.Kind, &n.UserName, &n.Recipient, &n.Payload, &n.State,
+		&n.Attempts, &n.NextTime, &n.LastError, &n.UpdateTime)
+	n.Time = common.Mill2TimeStr(n.CreateTime)
+	return n, err
+}
+
+// AddNotification put the message of kind to recipient into outbox, payload is marshaled to json
+func AddNotification(channel, kind, userName, recipient string, payload interface{}, nowMs int64) error {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(ADD_NOTIFICATION, nowMs, channel, kind, userName, recipient, string(content),
+		NOTIFICATION_STATE_PENDING, nowMs, nowMs)
+}
+
+// ClaimNotification returns at most limit pending notifications which are due,
+// they are marked sending so that never delivered twice at the same time
+func ClaimNotification(nowMs int64, limit int) ([]Notification, error) {
+	due := []Notification{}
+	rows, err := gStorage.querySQL(LIST_DUE_NOTIFICATION, NOTIFICATION_STATE_PENDING, nowMs, limit)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, n)
+	}
+	rows.Close()
+	claimed := []Notification{}
+	for _, n := range due {
+		affected, err := gStorage.execSQLAffected(CLAIM_NOTIFICATION, NOTIFICATION_STATE_SENDING, nowMs, n.Id,
+			NOTIFICATION_STATE_PENDING)
+		if err != nil {
+			return claimed, err
+		}
+		if affected == 1 {
+			n.State = NOTIFICATION_STATE_SENDING
+			claimed = append(claimed, n)
+		}
+	}
+	return claimed, nil
+}
+
+func UpdateNotification(n *Notification) error {
+	return gStorage.execSQL(UPDATE_NOTIFICATION, n.State, n.Attempts, n.NextTime, n.LastError, n.UpdateTime, n.Id)
+}
+
+// ResetSendingNotification retry the notifications interrupted by restart
+func ResetSendingNotification() error {
+	return gStorage.execSQL(RESET_SENDING_NOTIFICATION, NOTIFICATION_STATE_PENDING, NOTIFICATION_STATE_SENDING)
+}
+
+// DeleteNotification remove the delivered and dead notifications updated before expirationMs
+func DeleteNotification(expirationMs int64) error {
+	return gStorage.execSQL(DELETE_NOTIFICATION, NOTIFICATION_STATE_DELIVERED, NOTIFICATION_STATE_DEAD, expirationMs)
+}
+
+// ListNotification returns the notifications created in [start, end], admin can get all,
+// others can only get their own
+func ListNotification(start, end int64, limit, offset uint32, state, channel, userName string) (NotificationInfo, error) {
+	info := NotificationInfo{Info: []Notification{}}
+	cond := " WHERE create_time>=? AND create_time<=?"
+	params := []interface{}{start, end}
+	if state != "" {
+		cond += " AND state=?"
+		params = append(params, state)
+	}
+	if channel != "" {
+		cond += " AND channel=?"
+		params = append(params, channel)
+	}
+	if userName != USER_ADMIN_NAME {
+		cond += " AND username=?"
+		params = append(params, userName)
+	}
+	rows, err := gStorage.querySQL(GET_NOTIFICATION_NUM+cond, params...)
+	if err != nil {
+		return info, err
+	}
+	if rows.Next() {
+		rows.Scan(&info.Total)
+	}
+	rows.Close()
+	if info.Total <= int64(offset) {
+		return info, nil
+	}
+	params = append(params, limit, offset)
+	rows, err = gStorage.querySQL(fmt.Sprintf("SELECT %s FROM notification%s ORDER BY id DESC LIMIT ? OFFSET ?",
+		NOTIFICATION_COLUMNS, cond), params...)
+	if err != nil {
+		return info, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return info, err
+		}
+		info.Info = append(info.Info, n)
+	}
+	return info, nil
+}
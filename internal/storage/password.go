@@ -0,0 +1,92 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"crypto/subtle"
+
+	"github.com/opencurve/pigeon"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	USER_PASSWORD_BCRYPT_COST = "user.password.bcrypt.cost"
+
+	// the algorithm used to store the password of a user, recorded per row in user table
+	PASSWORD_ALGORITHM_MD5    = "md5"
+	PASSWORD_ALGORITHM_BCRYPT = "bcrypt"
+)
+
+var (
+	// bcrypt cost, default bcrypt.DefaultCost
+	bcryptCost = bcrypt.DefaultCost
+)
+
+func initPassWordHash(cfg *pigeon.Configure) {
+	cost := cfg.GetConfig().GetInt(USER_PASSWORD_BCRYPT_COST)
+	if cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+		bcryptCost = cost
+	}
+}
+
+// hashPassWord returns the salted hash of passwd and the algorithm used to generate it
+func hashPassWord(passwd string) (string, string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(normalizePassWord(passwd)), bcryptCost)
+	if err != nil {
+		return "", "", err
+	}
+	return string(hash), PASSWORD_ALGORITHM_BCRYPT, nil
+}
+
+// CheckUserPassWord reports whether passwd matches the stored password of user
+func CheckUserPassWord(user *UserInfo, passwd string) bool {
+	passwd = normalizePassWord(passwd)
+	switch user.Algorithm {
+	case PASSWORD_ALGORITHM_BCRYPT:
+		return bcrypt.CompareHashAndPassword([]byte(user.PassWord), []byte(passwd)) == nil
+	case PASSWORD_ALGORITHM_MD5:
+		// legacy rows store the md5 sum sent by the web client as it is
+		return subtle.ConstantTimeCompare([]byte(user.PassWord), []byte(passwd)) == 1
+	default:
+		return false
+	}
+}
+
+// IsLocalUser reports whether user is authenticated by the password stored locally,
+// users provisioned by external authenticators record the authenticator name as algorithm
+func IsLocalUser(user *UserInfo) bool {
+	return user.Algorithm == PASSWORD_ALGORITHM_BCRYPT || user.Algorithm == PASSWORD_ALGORITHM_MD5
+}
+
+// NeedRehashPassWord reports whether the stored password of user should be upgraded,
+// which happens to legacy md5 rows and bcrypt rows generated with another cost
+func NeedRehashPassWord(user *UserInfo) bool {
+	if !IsLocalUser(user) {
+		return false
+	}
+	if user.Algorithm != PASSWORD_ALGORITHM_BCRYPT {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(user.PassWord))
+	return err != nil || cost != bcryptCost
+}
@@ -0,0 +1,209 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/opencurve/curve-manager/internal/common"
+	"github.com/opencurve/pigeon"
+)
+
+const (
+	PASSWORD_POLICY_MIN_LENGTH        = "user.password.policy.min_length"
+	PASSWORD_POLICY_REQUIRE_UPPERCASE = "user.password.policy.require_uppercase"
+	PASSWORD_POLICY_REQUIRE_LOWERCASE = "user.password.policy.require_lowercase"
+	PASSWORD_POLICY_REQUIRE_DIGIT     = "user.password.policy.require_digit"
+	PASSWORD_POLICY_REQUIRE_SPECIAL   = "user.password.policy.require_special"
+	PASSWORD_POLICY_HISTORY           = "user.password.policy.history"
+	PASSWORD_POLICY_MAX_AGE_DAYS      = "user.password.policy.max_age_days"
+	PASSWORD_POLICY_REJECT_PREHASHED  = "user.password.policy.reject_prehashed"
+
+	// the md5 sum sent by the web client
+	PREHASHED_PASSWORD_LENGTH = 32
+)
+
+var (
+	ErrPassWordPrehashed = errors.New("pre-hashed password is not allowed")
+	ErrPassWordReused    = errors.New("password is used recently")
+)
+
+type passWordPolicy struct {
+	minLength        int
+	requireUppercase bool
+	requireLowercase bool
+	requireDigit     bool
+	requireSpecial   bool
+	// number of the recent passwords which can not be reused, 0 means disabled
+	history int
+	// 0 means never expire
+	maxAge time.Duration
+	// reject the md5 sum sent by the web client, whose complexity can not be checked,
+	// enable it after the clients send the new passwords in plaintext over tls
+	rejectPrehashed bool
+}
+
+var policy passWordPolicy
+
+func initPassWordPolicy(cfg *pigeon.Configure) {
+	policy.minLength = cfg.GetConfig().GetInt(PASSWORD_POLICY_MIN_LENGTH)
+	policy.requireUppercase = cfg.GetConfig().GetBool(PASSWORD_POLICY_REQUIRE_UPPERCASE)
+	policy.requireLowercase = cfg.GetConfig().GetBool(PASSWORD_POLICY_REQUIRE_LOWERCASE)
+	policy.requireDigit = cfg.GetConfig().GetBool(PASSWORD_POLICY_REQUIRE_DIGIT)
+	policy.requireSpecial = cfg.GetConfig().GetBool(PASSWORD_POLICY_REQUIRE_SPECIAL)
+	policy.history = cfg.GetConfig().GetInt(PASSWORD_POLICY_HISTORY)
+	policy.maxAge = time.Duration(cfg.GetConfig().GetInt(PASSWORD_POLICY_MAX_AGE_DAYS)) * 24 * time.Hour
+	policy.rejectPrehashed = cfg.GetConfig().GetBool(PASSWORD_POLICY_REJECT_PREHASHED)
+}
+
+func isPrehashed(passwd string) bool {
+	if len(passwd) != PREHASHED_PASSWORD_LENGTH {
+		return false
+	}
+	for _, c := range passwd {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizePassWord returns the md5 sum of plaintext passwd, so the passwords
+// set in plaintext can still login from the web client which sends md5 sum
+func normalizePassWord(passwd string) string {
+	if isPrehashed(passwd) {
+		return passwd
+	}
+	return common.GetMd5Sum32Little(passwd)
+}
+
+func checkComplexity(name, passwd string) error {
+	if utf8.RuneCountInString(passwd) < policy.minLength {
+		return fmt.Errorf("password is shorter than %d", policy.minLength)
+	}
+	if strings.EqualFold(passwd, name) {
+		return fmt.Errorf("password is the same as user name")
+	}
+	var upper, lower, digit, special bool
+	for _, c := range passwd {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	if (policy.requireUppercase && !upper) || (policy.requireLowercase && !lower) ||
+		(policy.requireDigit && !digit) || (policy.requireSpecial && !special) {
+		return fmt.Errorf("password lacks the required character classes")
+	}
+	return nil
+}
+
+// CheckPassWordPolicy check passwd is allowed to be the new password of user name
+func CheckPassWordPolicy(name, passwd string) error {
+	if isPrehashed(passwd) {
+		if policy.rejectPrehashed {
+			return ErrPassWordPrehashed
+		}
+	} else if err := checkComplexity(name, passwd); err != nil {
+		return err
+	}
+	if name == USER_ADMIN_NAME && normalizePassWord(passwd) == common.GetMd5Sum32Little(USER_ADMIN_PASSWORD) {
+		return ErrPassWordReused
+	}
+	if policy.history <= 0 {
+		return nil
+	}
+	// the current password is always in history unless stored by an older version
+	user, err := GetUser(name)
+	if err == nil && CheckUserPassWord(&user, passwd) {
+		return ErrPassWordReused
+	}
+	history, err := listPassWordHistory(name, policy.history)
+	if err != nil {
+		return err
+	}
+	for _, h := range history {
+		if CheckUserPassWord(&h, passwd) {
+			return ErrPassWordReused
+		}
+	}
+	return nil
+}
+
+// IsPassWordExpired reports whether user must change the password before other operations
+func IsPassWordExpired(user *UserInfo) bool {
+	if !IsLocalUser(user) {
+		return false
+	}
+	if user.MustChangePassWord {
+		return true
+	}
+	return policy.maxAge > 0 && time.Unix(user.PassWordTime, 0).Add(policy.maxAge).Before(time.Now())
+}
+
+// IsDefaultAdminPassWord reports whether the admin still uses the initial password
+func IsDefaultAdminPassWord(user *UserInfo) bool {
+	return user.UserName == USER_ADMIN_NAME &&
+		CheckUserPassWord(user, common.GetMd5Sum32Little(USER_ADMIN_PASSWORD))
+}
+
+func listPassWordHistory(name string, limit int) ([]UserInfo, error) {
+	history := []UserInfo{}
+	rows, err := gStorage.querySQL(LIST_PASSWORD_HISTORY, name, limit)
+	if err != nil {
+		return history, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		h := UserInfo{UserName: name}
+		err = rows.Scan(&h.PassWord, &h.Algorithm)
+		if err != nil {
+			return history, err
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+// addPassWordHistory record the hash of new password and keep the recent ones
+func addPassWordHistory(name, hash, algorithm string, now int64) error {
+	if policy.history <= 0 {
+		return gStorage.execSQL(DELETE_PASSWORD_HISTORY_OF_USER, name)
+	}
+	err := gStorage.execSQL(ADD_PASSWORD_HISTORY, name, hash, algorithm, now)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(TRIM_PASSWORD_HISTORY, name, name, policy.history)
+}
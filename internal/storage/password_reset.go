@@ -0,0 +1,78 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AddPassWordReset issue a reset token of user, which replaces the outstanding ones, returns false
+// and keeps the outstanding one if it is issued within cooldown, so the mailbox is not flooded
+func AddPassWordReset(name, token string, expire time.Time, cooldown time.Duration) (bool, error) {
+	now := time.Now()
+	err := gStorage.execSQL(DELETE_EXPIRED_PASSWORD_RESET, now.Unix())
+	if err != nil {
+		return false, err
+	}
+	rows, err := gStorage.querySQL(GET_RECENT_PASSWORD_RESET_NUM, name, now.Add(-cooldown).Unix(), now.Unix())
+	if err != nil {
+		return false, err
+	}
+	var recent int
+	if rows.Next() {
+		err = rows.Scan(&recent)
+	}
+	rows.Close()
+	if err != nil {
+		return false, err
+	} else if recent > 0 {
+		return false, nil
+	}
+	err = gStorage.execSQL(DELETE_PASSWORD_RESET_OF_USER, name)
+	if err != nil {
+		return false, err
+	}
+	return true, gStorage.execSQL(ADD_PASSWORD_RESET, hashToken(token), name, expire.Unix(), now.Unix())
+}
+
+// GetPassWordReset returns the user of an unexpired token, or sql.ErrNoRows
+func GetPassWordReset(token string) (string, error) {
+	rows, err := gStorage.querySQL(GET_PASSWORD_RESET, hashToken(token), time.Now().Unix())
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", sql.ErrNoRows
+	}
+	var name string
+	err = rows.Scan(&name)
+	return name, err
+}
+
+// UsePassWordReset consume the token, returns false if it is already used
+func UsePassWordReset(token string) (bool, error) {
+	n, err := gStorage.execSQLAffected(DELETE_PASSWORD_RESET, hashToken(token))
+	return n == 1, err
+}
@@ -0,0 +1,67 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/curve-manager/internal/storage/storagetest"
+)
+
+func TestPassWordResetCooldown(t *testing.T) {
+	storagetest.Init(t)
+	expire := time.Now().Add(time.Hour)
+
+	issued, err := storage.AddPassWordReset("alice", "first", expire, time.Hour)
+	if err != nil || !issued {
+		t.Fatalf("first token: issued=%v err=%v", issued, err)
+	}
+	issued, err = storage.AddPassWordReset("alice", "second", expire, time.Hour)
+	if err != nil || issued {
+		t.Fatalf("token within cooldown: issued=%v err=%v", issued, err)
+	}
+	if name, err := storage.GetPassWordReset("first"); err != nil || name != "alice" {
+		t.Fatalf("outstanding token should stay valid: name=%q err=%v", name, err)
+	}
+	if _, err := storage.GetPassWordReset("second"); err == nil {
+		t.Fatal("token within cooldown should not be stored")
+	}
+
+	issued, err = storage.AddPassWordReset("bob", "other", expire, time.Hour)
+	if err != nil || !issued {
+		t.Fatalf("cooldown should be per user: issued=%v err=%v", issued, err)
+	}
+
+	issued, err = storage.AddPassWordReset("alice", "third", expire, 0)
+	if err != nil || !issued {
+		t.Fatalf("token after cooldown: issued=%v err=%v", issued, err)
+	}
+	if _, err := storage.GetPassWordReset("first"); err == nil {
+		t.Fatal("replaced token should be invalid")
+	}
+	if name, err := storage.GetPassWordReset("third"); err != nil || name != "alice" {
+		t.Fatalf("new token: name=%q err=%v", name, err)
+	}
+}
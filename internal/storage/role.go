@@ -0,0 +1,265 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// built-in roles which the legacy permission bits map onto
+	ROLE_READER  = "reader"
+	ROLE_WRITER  = "writer"
+	ROLE_MANAGER = "manager"
+
+	ROLE_METHOD_DELIMITER = ","
+	// "snapshot.*" matches all methods start with "snapshot."
+	ROLE_METHOD_WILDCARD = "*"
+)
+
+type Role struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods"`
+	Builtin bool     `json:"builtin"`
+}
+
+// Allow reports whether method is one of the methods of role
+func (role *Role) Allow(method string) bool {
+	for _, m := range role.Methods {
+		if m == method {
+			return true
+		}
+		if strings.HasSuffix(m, ROLE_METHOD_WILDCARD) && strings.HasPrefix(method, strings.TrimSuffix(m, ROLE_METHOD_WILDCARD)) {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyRoles returns the built-in roles of the legacy permission bits,
+// methods always require read permission in the legacy bitmask
+func legacyRoles(perm int) []string {
+	roles := []string{}
+	if perm&READ_PERM != READ_PERM {
+		return roles
+	}
+	roles = append(roles, ROLE_READER)
+	if perm&WRITE_PERM == WRITE_PERM {
+		roles = append(roles, ROLE_WRITER)
+	}
+	if perm&MANAGER_PERM == MANAGER_PERM {
+		roles = append(roles, ROLE_MANAGER)
+	}
+	return roles
+}
+
+func loadRole() error {
+	rows, err := gStorage.querySQL(LIST_ROLE)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	gStorage.roleMutex.Lock()
+	defer gStorage.roleMutex.Unlock()
+	for rows.Next() {
+		var role Role
+		var methods string
+		err = rows.Scan(&role.Name, &methods, &role.Builtin)
+		if err != nil {
+			return err
+		}
+		role.Methods = strings.Split(methods, ROLE_METHOD_DELIMITER)
+		gStorage.roles[role.Name] = role
+	}
+	return nil
+}
+
+// InitBuiltinRole create or refresh the built-in role, which can not be changed by users
+func InitBuiltinRole(name string, methods []string) error {
+	gStorage.roleMutex.Lock()
+	defer gStorage.roleMutex.Unlock()
+	err := gStorage.execSQL(SET_BUILTIN_ROLE, name, strings.Join(methods, ROLE_METHOD_DELIMITER))
+	if err != nil {
+		return err
+	}
+	gStorage.roles[name] = Role{Name: name, Methods: methods, Builtin: true}
+	return nil
+}
+
+func CreateRole(name string, methods []string) error {
+	gStorage.roleMutex.Lock()
+	defer gStorage.roleMutex.Unlock()
+	if _, ok := gStorage.roles[name]; ok {
+		return fmt.Errorf("role %s already exist", name)
+	}
+	err := gStorage.execSQL(CREATE_ROLE, name, strings.Join(methods, ROLE_METHOD_DELIMITER))
+	if err != nil {
+		return err
+	}
+	gStorage.roles[name] = Role{Name: name, Methods: methods}
+	return nil
+}
+
+func UpdateRole(name string, methods []string) error {
+	gStorage.roleMutex.Lock()
+	defer gStorage.roleMutex.Unlock()
+	if role, ok := gStorage.roles[name]; !ok {
+		return fmt.Errorf("role %s not exist", name)
+	} else if role.Builtin {
+		return fmt.Errorf("built-in role %s can not be changed", name)
+	}
+	err := gStorage.execSQL(UPDATE_ROLE, strings.Join(methods, ROLE_METHOD_DELIMITER), name)
+	if err != nil {
+		return err
+	}
+	gStorage.roles[name] = Role{Name: name, Methods: methods}
+	return nil
+}
+
+func DeleteRole(name string) error {
+	gStorage.roleMutex.Lock()
+	defer gStorage.roleMutex.Unlock()
+	if role, ok := gStorage.roles[name]; !ok {
+		return fmt.Errorf("role %s not exist", name)
+	} else if role.Builtin {
+		return fmt.Errorf("built-in role %s can not be deleted", name)
+	}
+	err := gStorage.execSQL(DELETE_ROLE, name)
+	if err != nil {
+		return err
+	}
+	delete(gStorage.roles, name)
+	return gStorage.execSQL(DELETE_ROLE_OF_ALL_USER, name)
+}
+
+func ListRole() []Role {
+	gStorage.roleMutex.RLock()
+	defer gStorage.roleMutex.RUnlock()
+	roles := []Role{}
+	for _, role := range gStorage.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles
+}
+
+// GetUserRoles returns the roles assigned to user, not including the ones of legacy permission
+func GetUserRoles(name string) ([]string, error) {
+	roles := []string{}
+	rows, err := gStorage.querySQL(GET_USER_ROLE, name)
+	if err != nil {
+		return roles, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		if err = rows.Scan(&role); err != nil {
+			return roles, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// UpdateUserRoles replace the roles assigned to user
+func UpdateUserRoles(name string, roles []string) error {
+	gStorage.roleMutex.RLock()
+	for _, role := range roles {
+		if _, ok := gStorage.roles[role]; !ok {
+			gStorage.roleMutex.RUnlock()
+			return fmt.Errorf("role %s not exist", role)
+		}
+	}
+	gStorage.roleMutex.RUnlock()
+	err := gStorage.execSQL(DELETE_USER_ROLE, name)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		err = gStorage.execSQL(ADD_USER_ROLE, name, role)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsMethodAllowed reports whether user can call method by its legacy permission or assigned roles
+func IsMethodAllowed(user *UserInfo, method string) (bool, error) {
+	return IsAnyMethodAllowed(user, method)
+}
+
+// IsAnyMethodAllowed reports whether user can call one of methods by its legacy permission or assigned roles
+func IsAnyMethodAllowed(user *UserInfo, methods ...string) (bool, error) {
+	roles, err := GetUserRoles(user.UserName)
+	if err != nil {
+		return false, err
+	}
+	roles = append(roles, legacyRoles(user.Permission)...)
+	gStorage.roleMutex.RLock()
+	defer gStorage.roleMutex.RUnlock()
+	for _, name := range roles {
+		role, ok := gStorage.roles[name]
+		if !ok {
+			continue
+		}
+		for _, method := range methods {
+			if role.Allow(method) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func builtinRoleMethods(names ...string) []string {
+	gStorage.roleMutex.RLock()
+	defer gStorage.roleMutex.RUnlock()
+	methods := []string{}
+	for _, name := range names {
+		methods = append(methods, gStorage.roles[name].Methods...)
+	}
+	return methods
+}
+
+// IsWriteUser reports whether user can call any method of the built-in writer role, either by the
+// legacy permission or by the methods granted with other roles, such users share the write lock
+func IsWriteUser(user *UserInfo) (bool, error) {
+	return IsAnyMethodAllowed(user, builtinRoleMethods(ROLE_WRITER)...)
+}
+
+// IsManagerUser reports whether user can call any method of the built-in manager role, either by the
+// legacy permission or by the methods granted with other roles
+func IsManagerUser(user *UserInfo) (bool, error) {
+	return IsAnyMethodAllowed(user, builtinRoleMethods(ROLE_MANAGER)...)
+}
+
+// IsPrivilegedUser reports whether user can call any method of the built-in writer or manager role,
+// either by the legacy permission or by the methods granted with other roles
+func IsPrivilegedUser(user *UserInfo) (bool, error) {
+	return IsAnyMethodAllowed(user, builtinRoleMethods(ROLE_WRITER, ROLE_MANAGER)...)
+}
@@ -23,82 +23,337 @@
 package storage
 
 import (
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/opencurve/curve-manager/internal/common"
 )
 
+const (
+	// the minimum interval to persist the last active time of a session
+	SESSION_TOUCH_INTERVAL_SEC = 60
+
+	// bytes of random data in a session token
+	SESSION_TOKEN_BYTES = 32
+)
+
+var (
+	ErrWriteUserChanged = errors.New("the logined write user has changed")
+	ErrNotLogined       = errors.New("user is not logined")
+)
+
 type sessionItem struct {
 	userName   string
 	permission int
+	createTime int64
 	timestamp  int64
+	// the last active time persisted in db
+	touchTime int64
+	// the last write request, the write user idles by it as the polling requests keep timestamp fresh
+	writeTime int64
+	ip        string
+	userAgent string
+	// the key of v2 request signature
+	signSecret string
+}
+
+// Session is a login session, identified by the hash of its token
+type Session struct {
+	Id         string `json:"id"`
+	UserName   string `json:"userName"`
+	Permission int    `json:"permission"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	LoginTime  int64  `json:"loginTime"`
+	LastActive int64  `json:"lastActive"`
+	LastWrite  int64  `json:"lastWrite"`
+}
+
+// only the hash of token is kept in memory and db
+func hashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+func (s *storage) removeSession(tokenHash string, item *sessionItem) {
+	delete(s.session, tokenHash)
+	if s.loginOnce[item.userName] == tokenHash {
+		delete(s.loginOnce, item.userName)
+	}
+	if item.userName == s.loginedWriteUser {
+		s.loginedWriteUser = ""
+	}
+}
+
+// loadSession fill the session cache with sessions persisted in db
+func loadSession() error {
+	rows, err := gStorage.querySQL(LIST_SESSION)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	for rows.Next() {
+		var tokenHash string
+		var item sessionItem
+		err = rows.Scan(&tokenHash, &item.userName, &item.permission, &item.createTime, &item.timestamp,
+			&item.ip, &item.userAgent, &item.signSecret)
+		if err != nil {
+			return err
+		}
+		item.touchTime = item.timestamp
+		item.writeTime = item.timestamp
+		gStorage.session[tokenHash] = item
+		gStorage.loginOnce[item.userName] = tokenHash
+		if item.permission&WRITE_PERM == WRITE_PERM {
+			gStorage.loginedWriteUser = item.userName
+		}
+	}
+	return nil
 }
 
-func AddSession(userInfo *UserInfo) {
+func AddSession(userInfo *UserInfo, ip, userAgent string) error {
 	now := time.Now().Unix()
-	tokenStr := fmt.Sprintf("username=%s&password=%s&timestamp=%d", userInfo.UserName, userInfo.PassWord, now)
-	token := common.GetMd5Sum32Little(tokenStr)
+	token, err := common.GetSecureRandString(SESSION_TOKEN_BYTES)
+	if err != nil {
+		return err
+	}
+	signSecret, err := common.GetSecureRandString(SESSION_TOKEN_BYTES)
+	if err != nil {
+		return err
+	}
+	tokenHash := hashToken(token)
 	gStorage.mutex.Lock()
 	defer gStorage.mutex.Unlock()
 	// check if have logined, if so will disconnect old one
+	err = gStorage.execSQL(DELETE_SESSION_OF_USER, userInfo.UserName)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(ADD_SESSION, tokenHash, userInfo.UserName, userInfo.Permission, now, now,
+		ip, userAgent, signSecret)
+	if err != nil {
+		return err
+	}
 	if oldToken, ok := gStorage.loginOnce[userInfo.UserName]; ok {
-		delete(gStorage.session, oldToken)
+		oldItem := gStorage.session[oldToken]
+		gStorage.removeSession(oldToken, &oldItem)
 	}
-	gStorage.session[token] = sessionItem{
+	gStorage.session[tokenHash] = sessionItem{
 		userName:   userInfo.UserName,
 		permission: userInfo.Permission,
+		createTime: now,
 		timestamp:  now,
+		touchTime:  now,
+		writeTime:  now,
+		ip:         ip,
+		userAgent:  userAgent,
+		signSecret: signSecret,
 	}
-	gStorage.loginOnce[userInfo.UserName] = token
+	gStorage.loginOnce[userInfo.UserName] = tokenHash
 	if userInfo.Permission&WRITE_PERM == WRITE_PERM {
 		gStorage.loginedWriteUser = userInfo.UserName
 	}
 	userInfo.Token = token
+	userInfo.SignSecret = signSecret
+	return nil
+}
+
+// GetSessionSignSecret returns the sign secret of session s, empty if not logined
+func GetSessionSignSecret(s string) string {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	return gStorage.session[hashToken(s)].signSecret
 }
 
-func CheckSession(s string, expireSec int) (bool, int) {
+// CheckSession check the session is neither idle for more than idleSec
+// nor created for more than lifetimeSec
+func CheckSession(s string, idleSec, lifetimeSec int) (bool, int) {
 	now := time.Now().Unix()
+	tokenHash := hashToken(s)
 	gStorage.mutex.Lock()
 	defer gStorage.mutex.Unlock()
-	if item, ok := gStorage.session[s]; ok {
-		if item.timestamp+int64(expireSec) < now {
-			delete(gStorage.session, s)
-			delete(gStorage.loginOnce, item.userName)
-			if item.permission&WRITE_PERM == WRITE_PERM {
-				gStorage.loginedWriteUser = ""
-			}
+	if item, ok := gStorage.session[tokenHash]; ok {
+		if item.timestamp+int64(idleSec) < now || item.createTime+int64(lifetimeSec) < now {
+			gStorage.removeSession(tokenHash, &item)
+			gStorage.execSQL(DELETE_SESSION, tokenHash)
 			return false, 0
 		}
 		item.timestamp = now
-		gStorage.session[s] = item
+		// avoid writing db on every request
+		if item.touchTime+SESSION_TOUCH_INTERVAL_SEC <= now &&
+			gStorage.execSQL(UPDATE_SESSION_LAST_SEEN, now, tokenHash) == nil {
+			item.touchTime = now
+		}
+		gStorage.session[tokenHash] = item
 		return true, item.permission
 	}
 	return false, 0
 }
 
+// DeleteExpiredSession remove the sessions which have been idle for more than idleSec
+// or created for more than lifetimeSec
+func DeleteExpiredSession(idleSec, lifetimeSec int) error {
+	now := time.Now().Unix()
+	idleDeadline := now - int64(idleSec)
+	createDeadline := now - int64(lifetimeSec)
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	for tokenHash, item := range gStorage.session {
+		if item.timestamp < idleDeadline || item.createTime < createDeadline {
+			gStorage.removeSession(tokenHash, &item)
+		}
+	}
+	// last_seen in db may fall behind at most SESSION_TOUCH_INTERVAL_SEC
+	return gStorage.execSQL(DELETE_EXPIRED_SESSION, idleDeadline-SESSION_TOUCH_INTERVAL_SEC, createDeadline)
+}
+
+// GetSessionOfUser returns the session of name, every user has at most one session
+func GetSessionOfUser(name string) (Session, bool) {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	tokenHash, ok := gStorage.loginOnce[name]
+	if !ok {
+		return Session{}, false
+	}
+	item := gStorage.session[tokenHash]
+	return item.toSession(tokenHash), true
+}
+
+func (s *storage) setSessionPermission(name string, permission int) error {
+	tokenHash, ok := s.loginOnce[name]
+	if !ok {
+		return ErrNotLogined
+	}
+	err := s.execSQL(UPDATE_SESSION_PERM, permission, tokenHash)
+	if err != nil {
+		return err
+	}
+	item := s.session[tokenHash]
+	item.permission = permission
+	s.session[tokenHash] = item
+	return nil
+}
+
+// TransferWriteUser take the write permission from the session of from, which is kept as
+// a read-only session, and give it to the session of to, either of them can be empty
+func TransferWriteUser(from, to string) error {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	if gStorage.loginedWriteUser != from {
+		return ErrWriteUserChanged
+	}
+	if to != "" {
+		if _, ok := gStorage.loginOnce[to]; !ok {
+			return ErrNotLogined
+		}
+	}
+	if from != "" {
+		item := gStorage.session[gStorage.loginOnce[from]]
+		if err := gStorage.setSessionPermission(from, item.permission&^WRITE_PERM); err != nil {
+			return err
+		}
+		gStorage.loginedWriteUser = ""
+	}
+	if to != "" {
+		tokenHash := gStorage.loginOnce[to]
+		item := gStorage.session[tokenHash]
+		if err := gStorage.setSessionPermission(to, item.permission|WRITE_PERM); err != nil {
+			return err
+		}
+		// the new holder is not idle until it has a chance to write
+		item = gStorage.session[tokenHash]
+		item.writeTime = time.Now().Unix()
+		gStorage.session[tokenHash] = item
+		gStorage.loginedWriteUser = to
+	}
+	return nil
+}
+
+// TouchWriteUser record a write request of name, which keeps the write lock from idle release
+func TouchWriteUser(name string) {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	if tokenHash, ok := gStorage.loginOnce[name]; ok {
+		item := gStorage.session[tokenHash]
+		item.writeTime = time.Now().Unix()
+		gStorage.session[tokenHash] = item
+	}
+}
+
 func GetLoginWriteUser() string {
 	gStorage.mutex.Lock()
 	defer gStorage.mutex.Unlock()
 	return gStorage.loginedWriteUser
 }
 
-func Logout(name string) {
+func Logout(name string) error {
 	gStorage.mutex.Lock()
 	defer gStorage.mutex.Unlock()
-	token, _ := gStorage.loginOnce[name]
-	delete(gStorage.session, token)
-	delete(gStorage.loginOnce, name)
-	if name == gStorage.loginedWriteUser {
-		gStorage.loginedWriteUser = ""
+	if token, ok := gStorage.loginOnce[name]; ok {
+		item := gStorage.session[token]
+		gStorage.removeSession(token, &item)
 	}
+	return gStorage.execSQL(DELETE_SESSION_OF_USER, name)
 }
 
 func GetLoginUserByToken(token string) string {
 	gStorage.mutex.Lock()
 	defer gStorage.mutex.Unlock()
-	if item, ok := gStorage.session[token]; ok {
+	if item, ok := gStorage.session[hashToken(token)]; ok {
 		return item.userName
 	}
 	return ""
 }
+
+func (item *sessionItem) toSession(tokenHash string) Session {
+	return Session{
+		Id:         tokenHash,
+		UserName:   item.userName,
+		Permission: item.permission,
+		IP:         item.ip,
+		UserAgent:  item.userAgent,
+		LoginTime:  item.createTime,
+		LastActive: item.timestamp,
+		LastWrite:  item.writeTime,
+	}
+}
+
+// ListSession list the sessions of userName, or all sessions if userName is empty
+func ListSession(userName string) []Session {
+	sessions := []Session{}
+	gStorage.mutex.Lock()
+	for tokenHash, item := range gStorage.session {
+		if userName == "" || item.userName == userName {
+			sessions = append(sessions, item.toSession(tokenHash))
+		}
+	}
+	gStorage.mutex.Unlock()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LoginTime > sessions[j].LoginTime
+	})
+	return sessions
+}
+
+func GetSession(id string) (Session, bool) {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	item, ok := gStorage.session[id]
+	if !ok {
+		return Session{}, false
+	}
+	return item.toSession(id), true
+}
+
+// DeleteSession revoke the session by id, the token becomes invalid immediately
+func DeleteSession(id string) error {
+	gStorage.mutex.Lock()
+	defer gStorage.mutex.Unlock()
+	if item, ok := gStorage.session[id]; ok {
+		gStorage.removeSession(id, &item)
+	}
+	return gStorage.execSQL(DELETE_SESSION, id)
+}
@@ -0,0 +1,195 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/common"
+)
+
+const (
+	MINUTES_PER_DAY = 24 * 60
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+/*
+* Silence suppresses the notifications of the matched alerts, which are still recorded:
+* 1. the empty name, level and target match any alert, and the target matches the alerts
+*    whose target contains it, e.g. a host matches all its chunkservers
+* 2. it is active between start and end time, end time 0 means never expire
+* 3. the recurring one, i.e. maintenance window, is only active in the daily window of
+*    the weekdays in local time, e.g. tue 02:00-04:00, the window spans midnight if
+*    start is after end
+ */
+type Silence struct {
+	Id          int64    `json:"id"`
+	ClusterId   int      `json:"-"`
+	Name        string   `json:"name"`
+	Level       int      `json:"-"`
+	LevelStr    string   `json:"level"`
+	Target      string   `json:"target"`
+	StartTimeMs int64    `json:"startTime"`
+	EndTimeMs   int64    `json:"endTime"`
+	Weekdays    []string `json:"weekdays"`
+	// minutes of the day
+	WindowStartMin int    `json:"-"`
+	WindowEndMin   int    `json:"-"`
+	Comment        string `json:"comment"`
+	Creator        string `json:"creator"`
+	CreateTimeMs   int64  `json:"-"`
+	CreateTime     string `json:"createTime"`
+}
+
+func IsWeekday(name string) bool {
+	for _, w := range weekdayNames {
+		if w == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseClock returns the minutes of the day of "HH:MM"
+func ParseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func (s *Silence) IsRecurring() bool {
+	return len(s.Weekdays) > 0
+}
+
+func (s *Silence) onWeekday(day time.Weekday) bool {
+	for _, w := range s.Weekdays {
+		if w == weekdayNames[day] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Silence) IsActive(now time.Time) bool {
+	ms := now.UnixMilli()
+	if ms < s.StartTimeMs || (s.EndTimeMs > 0 && ms >= s.EndTimeMs) {
+		return false
+	}
+	if !s.IsRecurring() {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if s.WindowStartMin < s.WindowEndMin {
+		return s.onWeekday(now.Weekday()) && minute >= s.WindowStartMin && minute < s.WindowEndMin
+	}
+	// the window starts on the weekday and ends on the next day
+	if minute >= s.WindowStartMin {
+		return s.onWeekday(now.Weekday())
+	}
+	return minute < s.WindowEndMin && s.onWeekday((now.Weekday()+6)%7)
+}
+
+func (s *Silence) Matches(alert *Alert) bool {
+	return (s.Name == "" || s.Name == alert.Name) &&
+		(s.Level == 0 || s.Level == alert.Level) &&
+		(s.Target == "" || strings.Contains(alert.Target, s.Target))
+}
+
+// IsSilenced reports whether any of silences is active and matches the alert
+func IsSilenced(silences []Silence, alert *Alert, now time.Time) bool {
+	for i := range silences {
+		if silences[i].Matches(alert) && silences[i].IsActive(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func scanSilence(rows *sql.Rows) (Silence, error) {
+	var s Silence
+	var weekdays string
+	err := rows.Scan(&s.Id, &s.ClusterId, &s.Name, &s.Level, &s.Target, &s.StartTimeMs, &s.EndTimeMs, &weekdays,
+		&s.WindowStartMin, &s.WindowEndMin, &s.Comment, &s.Creator, &s.CreateTimeMs)
+	if err != nil {
+		return s, err
+	}
+	if s.Level != 0 {
+		s.LevelStr = getLevelStr(s.Level)
+	}
+	s.Weekdays = []string{}
+	if weekdays != "" {
+		s.Weekdays = strings.Split(weekdays, ",")
+	}
+	s.CreateTime = common.Mill2TimeStr(s.CreateTimeMs)
+	return s, nil
+}
+
+func CreateSilence(s *Silence) (int64, error) {
+	return gStorage.execSQLInsert(CREATE_ALERT_SILENCE, s.ClusterId, s.Name, s.Level, s.Target, s.StartTimeMs, s.EndTimeMs,
+		strings.Join(s.Weekdays, ","), s.WindowStartMin, s.WindowEndMin, s.Comment, s.Creator, s.CreateTimeMs)
+}
+
+// UpdateSilence returns false if the silence not exist
+func UpdateSilence(s *Silence) (bool, error) {
+	affected, err := gStorage.execSQLAffected(UPDATE_ALERT_SILENCE, s.Name, s.Level, s.Target, s.StartTimeMs, s.EndTimeMs,
+		strings.Join(s.Weekdays, ","), s.WindowStartMin, s.WindowEndMin, s.Comment, s.Id, s.ClusterId)
+	return affected > 0, err
+}
+
+// DeleteSilence returns false if the silence not exist
+func DeleteSilence(clusterId int, id int64) (bool, error) {
+	affected, err := gStorage.execSQLAffected(DELETE_ALERT_SILENCE, id, clusterId)
+	return affected > 0, err
+}
+
+// DeleteExpiredSilence delete the silences ended before expirationMs
+func DeleteExpiredSilence(clusterId int, expirationMs int64) error {
+	return gStorage.execSQL(DELETE_EXPIRED_ALERT_SILENCE, clusterId, expirationMs)
+}
+
+func ListSilence(clusterId int) ([]Silence, error) {
+	silences := []Silence{}
+	rows, err := gStorage.querySQL(LIST_ALERT_SILENCE, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanSilence(rows)
+		if err != nil {
+			return nil, err
+		}
+		silences = append(silences, s)
+	}
+	return silences, nil
+}
@@ -44,7 +44,8 @@ var (
 			username TEXT NOT NULL PRIMARY KEY,
 			password TEXT NOT NULL,
 			email TEXT,
-			permission INTEGER NOT NULL
+			permission INTEGER NOT NULL,
+			algorithm TEXT NOT NULL DEFAULT 'md5'
 		)
 	`
 	// system log table
@@ -58,7 +59,8 @@ var (
 			method TEXT,
 			error_code INTEGER,
 			error_msg TEXT,
-			content TEXT
+			content TEXT,
+			api_key TEXT NOT NULL DEFAULT ''
 		)
 	`
 	// alert conf table
@@ -72,6 +74,13 @@ var (
 			enable INTEGER CHECK (enable IN (0, 1)),
 			rule TEXT,
 			desc TEXT,
+			custom INTEGER NOT NULL DEFAULT 0,
+			creator TEXT NOT NULL DEFAULT '',
+			expr TEXT NOT NULL DEFAULT '',
+			op TEXT NOT NULL DEFAULT '',
+			threshold REAL NOT NULL DEFAULT 0,
+			for_sec INTEGER NOT NULL DEFAULT 0,
+			summary TEXT NOT NULL DEFAULT '',
 			PRIMARY KEY (cluster, name)
 		)
 	`
@@ -93,9 +102,113 @@ var (
 			level INTEGER,
 			name TEXT,
 			duration INTEGER,
-			summary TEXT
+			summary TEXT,
+			fingerprint TEXT NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT '',
+			state TEXT NOT NULL DEFAULT 'resolved',
+			end_time INTEGER NOT NULL DEFAULT 0,
+			notify_time INTEGER NOT NULL DEFAULT 0,
+			ack_user TEXT NOT NULL DEFAULT '',
+			ack_time INTEGER NOT NULL DEFAULT 0,
+			assignee TEXT NOT NULL DEFAULT '',
+			fire_time INTEGER NOT NULL DEFAULT 0,
+			escalation INTEGER NOT NULL DEFAULT 0
 		)
 	`
+	CREATE_ALERT_INDEX = `CREATE INDEX IF NOT EXISTS alert_state ON alert(cluster, name, state)`
+	// alert note table
+	CREATE_ALERT_NOTE_TABLE = `
+		CREATE TABLE IF NOT EXISTS alert_note (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			alert INTEGER NOT NULL,
+			user TEXT NOT NULL,
+			time INTEGER NOT NULL,
+			content TEXT NOT NULL
+		)
+	`
+	CREATE_ALERT_NOTE_INDEX = `CREATE INDEX IF NOT EXISTS alert_note_alert ON alert_note(alert)`
+	// webhook table
+	CREATE_WEBHOOK_TABLE = `
+		CREATE TABLE IF NOT EXISTS webhook (
+			name TEXT NOT NULL PRIMARY KEY,
+			type TEXT NOT NULL DEFAULT 'generic',
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL DEFAULT '',
+			secret_header TEXT NOT NULL DEFAULT '',
+			body_template TEXT NOT NULL DEFAULT '',
+			timeout INTEGER NOT NULL DEFAULT 0,
+			insecure_skip_verify INTEGER NOT NULL DEFAULT 0,
+			ca_cert TEXT NOT NULL DEFAULT ''
+		)
+	`
+	// alert webhook table
+	CREATE_ALERT_WEBHOOK_TABLE = `
+		CREATE TABLE IF NOT EXISTS alert_webhook (
+			cluster INTEGER,
+			alert TEXT,
+			webhook TEXT,
+			UNIQUE (cluster, alert, webhook) ON CONFLICT IGNORE
+		)
+	`
+	// alert silence table, the recurring one has weekdays and the daily window in minutes
+	CREATE_ALERT_SILENCE_TABLE = `
+		CREATE TABLE IF NOT EXISTS alert_silence (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			cluster INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			level INTEGER NOT NULL DEFAULT 0,
+			target TEXT NOT NULL DEFAULT '',
+			start_time INTEGER NOT NULL,
+			end_time INTEGER NOT NULL DEFAULT 0,
+			weekdays TEXT NOT NULL DEFAULT '',
+			window_start INTEGER NOT NULL DEFAULT 0,
+			window_end INTEGER NOT NULL DEFAULT 0,
+			comment TEXT NOT NULL DEFAULT '',
+			creator TEXT NOT NULL DEFAULT '',
+			create_time INTEGER NOT NULL
+		)
+	`
+	// alert escalation policy table, tiers is the json of the escalation tiers
+	CREATE_ESCALATION_POLICY_TABLE = `
+		CREATE TABLE IF NOT EXISTS escalation_policy (
+			cluster INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			level INTEGER NOT NULL,
+			alert TEXT NOT NULL DEFAULT '',
+			enable INTEGER NOT NULL DEFAULT 1,
+			tiers TEXT NOT NULL,
+			UNIQUE (cluster, name)
+		)
+	`
+	// on-call schedule table, users is the rotation joined by comma
+	CREATE_ONCALL_SCHEDULE_TABLE = `
+		CREATE TABLE IF NOT EXISTS oncall_schedule (
+			cluster INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			users TEXT NOT NULL,
+			start_time INTEGER NOT NULL,
+			rotation_hours INTEGER NOT NULL,
+			UNIQUE (cluster, name)
+		)
+	`
+	// notification outbox table
+	CREATE_NOTIFICATION_TABLE = `
+		CREATE TABLE IF NOT EXISTS notification (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			create_time INTEGER NOT NULL,
+			channel TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			username TEXT NOT NULL DEFAULT '',
+			recipient TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			state TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_time INTEGER NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			update_time INTEGER NOT NULL
+		)
+	`
+	CREATE_NOTIFICATION_INDEX = `CREATE INDEX IF NOT EXISTS notification_state ON notification(state, next_time)`
 	// read alert table
 	CREATE_READ_ALERT_TABLE = `
 		CREATE TABLE IF NOT EXISTS read_alert (
@@ -103,37 +216,233 @@ var (
 			id INTEGER
 		)
 	`
+	// session table
+	CREATE_SESSION_TABLE = `
+		CREATE TABLE IF NOT EXISTS session (
+			token TEXT NOT NULL PRIMARY KEY,
+			username TEXT NOT NULL,
+			permission INTEGER NOT NULL,
+			create_time INTEGER,
+			last_seen INTEGER,
+			ip TEXT,
+			user_agent TEXT,
+			sign_secret TEXT NOT NULL DEFAULT ''
+		)
+	`
+	// api key table
+	CREATE_API_KEY_TABLE = `
+		CREATE TABLE IF NOT EXISTS api_key (
+			id TEXT NOT NULL PRIMARY KEY,
+			secret TEXT NOT NULL,
+			username TEXT NOT NULL,
+			name TEXT,
+			scopes TEXT NOT NULL,
+			ip_allowlist TEXT,
+			expire_time INTEGER,
+			create_time INTEGER,
+			last_used INTEGER
+		)
+	`
+	// role table
+	CREATE_ROLE_TABLE = `
+		CREATE TABLE IF NOT EXISTS role (
+			name TEXT NOT NULL PRIMARY KEY,
+			methods TEXT NOT NULL,
+			builtin INTEGER NOT NULL DEFAULT 0 CHECK (builtin IN (0, 1))
+		)
+	`
+	// user role table
+	CREATE_USER_ROLE_TABLE = `
+		CREATE TABLE IF NOT EXISTS user_role (
+			username TEXT NOT NULL,
+			role TEXT NOT NULL,
+			UNIQUE (username, role) ON CONFLICT IGNORE
+		)
+	`
+	// volume grant table
+	CREATE_VOLUME_GRANT_TABLE = `
+		CREATE TABLE IF NOT EXISTS volume_grant (
+			username TEXT NOT NULL,
+			dir TEXT NOT NULL,
+			mode TEXT NOT NULL CHECK (mode IN ('ro', 'rw')),
+			UNIQUE (username, dir) ON CONFLICT REPLACE
+		)
+	`
+	// user totp table
+	CREATE_USER_TOTP_TABLE = `
+		CREATE TABLE IF NOT EXISTS user_totp (
+			username TEXT NOT NULL PRIMARY KEY,
+			secret TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 0 CHECK (enabled IN (0, 1)),
+			last_step INTEGER NOT NULL DEFAULT 0,
+			create_time INTEGER
+		)
+	`
+	// recovery code table
+	CREATE_RECOVERY_CODE_TABLE = `
+		CREATE TABLE IF NOT EXISTS recovery_code (
+			username TEXT NOT NULL,
+			code TEXT NOT NULL,
+			UNIQUE (username, code) ON CONFLICT IGNORE
+		)
+	`
+	// user lockout table
+	CREATE_USER_LOCKOUT_TABLE = `
+		CREATE TABLE IF NOT EXISTS user_lockout (
+			username TEXT NOT NULL PRIMARY KEY,
+			failures INTEGER NOT NULL DEFAULT 0,
+			window_start INTEGER NOT NULL DEFAULT 0,
+			locked_until INTEGER NOT NULL DEFAULT 0,
+			lockouts INTEGER NOT NULL DEFAULT 0,
+			permanent INTEGER NOT NULL DEFAULT 0 CHECK (permanent IN (0, 1))
+		)
+	`
+	// password reset table, only the hash of token is stored
+	CREATE_PASSWORD_RESET_TABLE = `
+		CREATE TABLE IF NOT EXISTS password_reset (
+			token TEXT NOT NULL PRIMARY KEY,
+			username TEXT NOT NULL,
+			expire_time INTEGER NOT NULL,
+			create_time INTEGER NOT NULL DEFAULT 0
+		)
+	`
+	// password history table
+	CREATE_PASSWORD_HISTORY_TABLE = `
+		CREATE TABLE IF NOT EXISTS password_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT NOT NULL,
+			password TEXT NOT NULL,
+			algorithm TEXT NOT NULL,
+			create_time INTEGER
+		)
+	`
+
+	// add columns which introduced after the table released
+	ADD_COLUMN       = `ALTER TABLE %s ADD COLUMN %s %s`
+	GET_TABLE_COLUMN = `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?`
 
 	// user
-	CREATE_ADMIN           = `INSERT OR IGNORE INTO user(username, password, email, permission) VALUES(?, ?, ?, ?)`
-	CREATE_USER            = `INSERT INTO user(username, password, email, permission) VALUES(?, ?, ?, ?)`
-	DELETE_USER            = `DELETE FROM user WHERE username=?`
-	GET_USER               = `SELECT * FROM user WHERE username=?`
-	GET_USER_EMAIL         = `SELECT email FROM user WHERE username=?`
-	GET_USER_PASSWORD      = `SELECT password FROM user WHERE username=?`
-	LIST_USER              = `SELECT * FROM user WHERE username!=?`
-	LIST_USER_WITH_EMAIL   = `SELECT username FROM user WHERE email!=?`
-	UPDATE_USER_PASSWORD   = `UPDATE user SET password=? WHERE username=?`
-	UPDATE_USER_EMAIL      = `UPDATE user SET email=? WHERE username=?`
-	UPDATE_USER_PERMISSION = `UPDATE user SET permission=? WHERE username=?`
+	CREATE_ADMIN = `INSERT OR IGNORE INTO user(username, password, email, permission, algorithm, password_time, must_change_password)
+	 VALUES(?, ?, ?, ?, ?, ?, 1)`
+	CREATE_USER = `INSERT INTO user(username, password, email, permission, algorithm, password_time)
+	 VALUES(?, ?, ?, ?, ?, ?)`
+	DELETE_USER = `DELETE FROM user WHERE username=?`
+	GET_USER    = `SELECT username, password, email, permission, algorithm, password_time, must_change_password
+	 FROM user WHERE username=?`
+	GET_USER_EMAIL = `SELECT email FROM user WHERE username=?`
+	LIST_USER      = `SELECT username, password, email, permission, algorithm, password_time, must_change_password
+	 FROM user WHERE username!=?`
+	LIST_USER_WITH_EMAIL     = `SELECT username FROM user WHERE email!=?`
+	REHASH_USER_PASSWORD     = `UPDATE user SET password=?, algorithm=? WHERE username=?`
+	UPDATE_USER_PASSWORD     = `UPDATE user SET password=?, algorithm=?, password_time=?, must_change_password=? WHERE username=?`
+	UPDATE_USER_EMAIL        = `UPDATE user SET email=? WHERE username=?`
+	UPDATE_USER_PERMISSION   = `UPDATE user SET permission=? WHERE username=?`
+	SET_MUST_CHANGE_PASSWORD = `UPDATE user SET must_change_password=1 WHERE username=?`
+	INIT_PASSWORD_TIME       = `UPDATE user SET password_time=? WHERE password_time=0`
+	ADD_EXTERNAL_USER        = `INSERT OR IGNORE INTO user(username, password, email, permission, algorithm) VALUES(?, '', ?, ?, ?)`
+	UPDATE_EXTERNAL_USER     = `UPDATE user SET email=?, permission=? WHERE username=? AND algorithm=?`
 
-	// system log
-	ADD_SYSTEM_LOG = `INSERT OR IGNORE INTO system_log(timestamp, ip, user, module, method, error_code, error_msg, content)
+	// password history
+	ADD_PASSWORD_HISTORY  = `INSERT INTO password_history(username, password, algorithm, create_time) VALUES(?, ?, ?, ?)`
+	LIST_PASSWORD_HISTORY = `SELECT password, algorithm FROM password_history WHERE username=? ORDER BY id DESC LIMIT ?`
+	TRIM_PASSWORD_HISTORY = `DELETE FROM password_history WHERE username=? AND id NOT IN
+	 (SELECT id FROM password_history WHERE username=? ORDER BY id DESC LIMIT ?)`
+	DELETE_PASSWORD_HISTORY_OF_USER = `DELETE FROM password_history WHERE username=?`
+
+	// password reset
+	ADD_PASSWORD_RESET            = `INSERT INTO password_reset(token, username, expire_time, create_time) VALUES(?, ?, ?, ?)`
+	GET_RECENT_PASSWORD_RESET_NUM = `SELECT COUNT(*) FROM password_reset WHERE username=? AND create_time>? AND expire_time>?`
+	GET_PASSWORD_RESET            = `SELECT username FROM password_reset WHERE token=? AND expire_time>?`
+	DELETE_PASSWORD_RESET         = `DELETE FROM password_reset WHERE token=?`
+	DELETE_PASSWORD_RESET_OF_USER = `DELETE FROM password_reset WHERE username=?`
+	DELETE_EXPIRED_PASSWORD_RESET = `DELETE FROM password_reset WHERE expire_time<=?`
+
+	// session
+	ADD_SESSION = `INSERT INTO session(token, username, permission, create_time, last_seen, ip, user_agent, sign_secret)
 	 VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+	LIST_SESSION             = `SELECT token, username, permission, create_time, last_seen, ip, user_agent, sign_secret FROM session`
+	UPDATE_SESSION_LAST_SEEN = `UPDATE session SET last_seen=? WHERE token=?`
+	UPDATE_SESSION_PERM      = `UPDATE session SET permission=? WHERE token=?`
+	DELETE_SESSION           = `DELETE FROM session WHERE token=?`
+	DELETE_SESSION_OF_USER   = `DELETE FROM session WHERE username=?`
+	DELETE_EXPIRED_SESSION   = `DELETE FROM session WHERE last_seen<? OR create_time<?`
+
+	// api key
+	ADD_API_KEY = `INSERT INTO api_key(id, secret, username, name, scopes, ip_allowlist, expire_time, create_time, last_used)
+	 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	GET_API_KEY = `SELECT id, secret, username, name, scopes, ip_allowlist, expire_time, create_time, last_used
+	 FROM api_key WHERE id=?`
+	LIST_API_KEY = `SELECT id, secret, username, name, scopes, ip_allowlist, expire_time, create_time, last_used
+	 FROM api_key ORDER BY create_time ASC`
+	LIST_API_KEY_OF_USER = `SELECT id, secret, username, name, scopes, ip_allowlist, expire_time, create_time, last_used
+	 FROM api_key WHERE username=? ORDER BY create_time ASC`
+	UPDATE_API_KEY_LAST_USED = `UPDATE api_key SET last_used=? WHERE id=?`
+	DELETE_API_KEY           = `DELETE FROM api_key WHERE id=?`
+	DELETE_API_KEY_OF_USER   = `DELETE FROM api_key WHERE username=?`
+
+	// role
+	SET_BUILTIN_ROLE = `INSERT OR REPLACE INTO role(name, methods, builtin) VALUES(?, ?, 1)`
+	CREATE_ROLE      = `INSERT INTO role(name, methods, builtin) VALUES(?, ?, 0)`
+	UPDATE_ROLE      = `UPDATE role SET methods=? WHERE name=? AND builtin=0`
+	DELETE_ROLE      = `DELETE FROM role WHERE name=? AND builtin=0`
+	LIST_ROLE        = `SELECT name, methods, builtin FROM role`
+
+	// user role
+	ADD_USER_ROLE           = `INSERT OR IGNORE INTO user_role(username, role) VALUES(?, ?)`
+	GET_USER_ROLE           = `SELECT role FROM user_role WHERE username=? ORDER BY role ASC`
+	DELETE_USER_ROLE        = `DELETE FROM user_role WHERE username=?`
+	DELETE_ROLE_OF_ALL_USER = `DELETE FROM user_role WHERE role=?`
+
+	// volume grant
+	ADD_VOLUME_GRANT            = `INSERT INTO volume_grant(username, dir, mode) VALUES(?, ?, ?)`
+	DELETE_VOLUME_GRANT         = `DELETE FROM volume_grant WHERE username=? AND dir=?`
+	DELETE_VOLUME_GRANT_OF_USER = `DELETE FROM volume_grant WHERE username=?`
+	LIST_VOLUME_GRANT           = `SELECT username, dir, mode FROM volume_grant ORDER BY username, dir`
+	LIST_VOLUME_GRANT_OF_USER   = `SELECT username, dir, mode FROM volume_grant WHERE username=? ORDER BY dir`
+
+	// user totp
+	GET_USER_TOTP        = `SELECT username, secret, enabled, last_step FROM user_totp WHERE username=?`
+	SET_USER_TOTP_SECRET = `INSERT OR REPLACE INTO user_totp(username, secret, enabled, last_step, create_time) VALUES(?, ?, 0, 0, ?)`
+	ENABLE_USER_TOTP     = `UPDATE user_totp SET enabled=1, last_step=? WHERE username=?`
+	USE_TOTP_STEP        = `UPDATE user_totp SET last_step=? WHERE username=? AND last_step<?`
+	DELETE_USER_TOTP     = `DELETE FROM user_totp WHERE username=?`
+
+	// recovery code
+	ADD_RECOVERY_CODE            = `INSERT INTO recovery_code(username, code) VALUES(?, ?)`
+	DELETE_RECOVERY_CODE         = `DELETE FROM recovery_code WHERE username=? AND code=?`
+	DELETE_RECOVERY_CODE_OF_USER = `DELETE FROM recovery_code WHERE username=?`
+
+	// user lockout
+	GET_USER_LOCKOUT = `SELECT username, failures, window_start, locked_until, lockouts, permanent
+	 FROM user_lockout WHERE username=?`
+	SET_USER_LOCKOUT = `INSERT OR REPLACE INTO user_lockout(username, failures, window_start, locked_until, lockouts, permanent)
+	 VALUES(?, ?, ?, ?, ?, ?)`
+	DELETE_USER_LOCKOUT = `DELETE FROM user_lockout WHERE username=?`
+	LIST_LOCKED_USER    = `SELECT username, failures, window_start, locked_until, lockouts, permanent
+	 FROM user_lockout WHERE permanent=1 OR locked_until>? ORDER BY username`
+
+	// system log
+	ADD_SYSTEM_LOG = `INSERT OR IGNORE INTO system_log(timestamp, ip, user, module, method, error_code, error_msg, content,
+	 api_key) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	GET_SYSTEM_LOG_NUM = `SELECT COUNT(*) FROM system_log WHERE timestamp>=? AND timestamp<=? AND
 	 ip||user||module||method||error_code||error_msg||content LIKE ?`
-	GET_SYSTEM_LOG = `SELECT * FROM system_log WHERE timestamp>=? AND timestamp<=? AND
+	GET_SYSTEM_LOG = `SELECT id, timestamp, ip, user, module, method, error_code, error_msg, content, api_key FROM system_log WHERE timestamp>=? AND timestamp<=? AND
 	 ip||user||module||method||error_code||error_msg||content LIKE ? ORDER BY timestamp DESC LIMIT ? OFFSET ?`
 	GET_SYSTEM_LOG_NUM_OF_USER = `SELECT COUNT(*) FROM system_log WHERE timestamp>=? AND timestamp<=? AND user=? AND
 	 ip||user||module||method||error_code||error_msg||content LIKE ?`
-	GET_SYSTEM_LOG_OF_USER = `SELECT * FROM system_log WHERE timestamp >= ? AND timestamp<=? AND user=? AND
+	GET_SYSTEM_LOG_OF_USER = `SELECT id, timestamp, ip, user, module, method, error_code, error_msg, content, api_key FROM system_log WHERE timestamp >= ? AND timestamp<=? AND user=? AND
 	 ip||user||module||method||error_code||error_msg||content LIKE ? ORDER BY timestamp DESC LIMIT ? OFFSET ?`
 	DELETE_SYSTEM_LOG = `DELETE FROM system_log WHERE timestamp<?`
 
 	// alert conf
-	ADD_ALERT_CONF    = `INSERT OR IGNORE INTO alert_conf(cluster, name, level, interval, times, enable, rule, desc) VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
-	UPDATE_ALERT_CONF = `UPDATE alert_conf SET interval=?, times=?, enable=?, rule=? WHERE cluster=? AND name=?`
-	GET_ALERT_CONF    = `SELECT * FROM alert_conf WHERE cluster=? ORDER BY name ASC`
+	ADD_ALERT_CONF                = `INSERT OR IGNORE INTO alert_conf(cluster, name, level, interval, times, enable, rule, desc, custom, creator, expr, op, threshold, for_sec, summary) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	UPDATE_ALERT_CONF             = `UPDATE alert_conf SET interval=?, times=?, enable=?, rule=? WHERE cluster=? AND name=?`
+	UPDATE_CUSTOM_ALERT_CONF      = `UPDATE alert_conf SET level=?, interval=?, enable=?, rule=?, desc=?, expr=?, op=?, threshold=?, for_sec=?, summary=? WHERE cluster=? AND name=? AND custom=1`
+	DELETE_CUSTOM_ALERT_CONF      = `DELETE FROM alert_conf WHERE cluster=? AND name=? AND custom=1`
+	DELETE_ALERT_USER_OF_ALERT    = `DELETE FROM alert_user WHERE cluster=? AND alert=?`
+	DELETE_ALERT_WEBHOOK_OF_ALERT = `DELETE FROM alert_webhook WHERE cluster=? AND alert=?`
+	GET_ALERT_CONF                = `SELECT cluster, name, level, interval, times, enable, rule, desc, custom, creator, expr, op, threshold, for_sec, summary FROM alert_conf WHERE cluster=? ORDER BY name ASC`
+	GET_ALERT_CONF_BY_NAME        = `SELECT cluster, name, level, interval, times, enable, rule, desc, custom, creator, expr, op, threshold, for_sec, summary FROM alert_conf WHERE cluster=? AND name=?`
 
 	// alert user
 	ADD_ALERT_USER    = `INSERT OR IGNORE INTO alert_user(cluster, alert, user) VALUES(?, ?, ?)`
@@ -141,11 +450,80 @@ var (
 	GET_ALERT_USER    = `SELECT user FROM alert_user WHERE cluster=? AND alert=?`
 
 	// alert
-	ADD_SYSTEM_ALERT            = `INSERT OR IGNORE INTO alert(cluster, timestamp, level, name, duration, summary) VALUES(?, ?, ?, ?, ?, ?)`
+	ADD_SYSTEM_ALERT            = `INSERT INTO alert(cluster, timestamp, level, name, duration, summary, fingerprint, target, state, end_time, notify_time, fire_time, escalation) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	UPDATE_SYSTEM_ALERT         = `UPDATE alert SET level=?, duration=?, summary=?, state=?, end_time=?, notify_time=?, fire_time=?, escalation=? WHERE id=?`
+	REMOVE_SYSTEM_ALERT         = `DELETE FROM alert WHERE id=?`
+	GET_ACTIVE_SYSTEM_ALERT     = `SELECT id, cluster, timestamp, level, name, duration, summary, fingerprint, target, state, end_time, notify_time, ack_user, ack_time, assignee, fire_time, escalation FROM alert WHERE cluster=? AND name=? AND state IN ('pending', 'firing')`
 	GET_SYSTEM_ALERT_NUM        = `SELECT COUNT(*) FROM alert WHERE timestamp>=? AND timestamp<=? AND cluster=?`
-	GET_SYSTEM_ALERT            = `SELECT * FROM alert WHERE timestamp>=? AND timestamp<=? AND cluster=?`
-	DELETE_SYSTEM_ALERT         = `DELETE FROM alert WHERE cluster=? AND timestamp<?`
-	GET_UNREAD_SYSTEM_ALERT_NUM = `SELECT COUNT(*) FROM alert WHERE id>? AND cluster=?`
+	GET_SYSTEM_ALERT            = `SELECT id, cluster, timestamp, level, name, duration, summary, fingerprint, target, state, end_time, notify_time, ack_user, ack_time, assignee, fire_time, escalation FROM alert WHERE timestamp>=? AND timestamp<=? AND cluster=?`
+	DELETE_SYSTEM_ALERT         = `DELETE FROM alert WHERE cluster=? AND timestamp<? AND state='resolved'`
+	GET_UNREAD_SYSTEM_ALERT_NUM = `SELECT COUNT(*) FROM alert WHERE id>? AND cluster=? AND state!='pending'`
+	GET_SYSTEM_ALERT_BY_ID      = `SELECT id, cluster, timestamp, level, name, duration, summary, fingerprint, target, state, end_time, notify_time, ack_user, ack_time, assignee, fire_time, escalation FROM alert WHERE id=? AND cluster=?`
+	ACK_SYSTEM_ALERT            = `UPDATE alert SET ack_user=?, ack_time=? WHERE id=? AND cluster=?`
+	ASSIGN_SYSTEM_ALERT         = `UPDATE alert SET assignee=? WHERE id=? AND cluster=?`
+
+	// alert note
+	ADD_ALERT_NOTE             = `INSERT INTO alert_note(alert, user, time, content) VALUES(?, ?, ?, ?)`
+	LIST_ALERT_NOTE            = `SELECT id, user, time, content FROM alert_note WHERE alert=? ORDER BY id ASC`
+	DELETE_ALERT_NOTE_OF_ALERT = `DELETE FROM alert_note WHERE alert=?`
+	DELETE_EXPIRED_ALERT_NOTE  = `DELETE FROM alert_note WHERE alert NOT IN (SELECT id FROM alert)`
+
+	// webhook
+	CREATE_WEBHOOK = `INSERT OR IGNORE INTO webhook(name, type, url, secret, secret_header, body_template, timeout,
+	 insecure_skip_verify, ca_cert) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	UPDATE_WEBHOOK = `UPDATE webhook SET type=?, url=?, secret=?, secret_header=?, body_template=?, timeout=?,
+	 insecure_skip_verify=?, ca_cert=? WHERE name=?`
+	DELETE_WEBHOOK = `DELETE FROM webhook WHERE name=?`
+	GET_WEBHOOK    = `SELECT name, type, url, secret, secret_header, body_template, timeout, insecure_skip_verify, ca_cert
+	 FROM webhook WHERE name=?`
+	LIST_WEBHOOK = `SELECT name, type, url, secret, secret_header, body_template, timeout, insecure_skip_verify, ca_cert
+	 FROM webhook ORDER BY name`
+
+	// alert webhook
+	ADD_ALERT_WEBHOOK               = `INSERT OR IGNORE INTO alert_webhook(cluster, alert, webhook) VALUES(?, ?, ?)`
+	DELETE_ALERT_WEBHOOK            = `DELETE FROM alert_webhook WHERE cluster=? AND alert=? AND webhook=?`
+	DELETE_ALERT_WEBHOOK_OF_WEBHOOK = `DELETE FROM alert_webhook WHERE webhook=?`
+	GET_ALERT_WEBHOOK               = `SELECT webhook FROM alert_webhook WHERE cluster=? AND alert=?`
+
+	// alert silence
+	ALERT_SILENCE_COLUMNS = `id, cluster, name, level, target, start_time, end_time, weekdays, window_start, window_end,
+	 comment, creator, create_time`
+	CREATE_ALERT_SILENCE = `INSERT INTO alert_silence(cluster, name, level, target, start_time, end_time, weekdays,
+	 window_start, window_end, comment, creator, create_time) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	UPDATE_ALERT_SILENCE = `UPDATE alert_silence SET name=?, level=?, target=?, start_time=?, end_time=?, weekdays=?,
+	 window_start=?, window_end=?, comment=? WHERE id=? AND cluster=?`
+	DELETE_ALERT_SILENCE         = `DELETE FROM alert_silence WHERE id=? AND cluster=?`
+	DELETE_EXPIRED_ALERT_SILENCE = `DELETE FROM alert_silence WHERE cluster=? AND end_time>0 AND end_time<?`
+	GET_ALERT_SILENCE            = `SELECT ` + ALERT_SILENCE_COLUMNS + ` FROM alert_silence WHERE id=? AND cluster=?`
+	LIST_ALERT_SILENCE           = `SELECT ` + ALERT_SILENCE_COLUMNS + ` FROM alert_silence WHERE cluster=? ORDER BY id DESC`
+
+	// notification
+	NOTIFICATION_COLUMNS = `id, create_time, channel, kind, username, recipient, payload, state, attempts,
+	 next_time, last_error, update_time`
+	ADD_NOTIFICATION = `INSERT INTO notification(create_time, channel, kind, username, recipient, payload, state,
+	 next_time, update_time) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	LIST_DUE_NOTIFICATION = `SELECT ` + NOTIFICATION_COLUMNS + ` FROM notification WHERE state=? AND next_time<=?
+	 ORDER BY next_time LIMIT ?`
+	CLAIM_NOTIFICATION         = `UPDATE notification SET state=?, update_time=? WHERE id=? AND state=?`
+	UPDATE_NOTIFICATION        = `UPDATE notification SET state=?, attempts=?, next_time=?, last_error=?, update_time=? WHERE id=?`
+	RESET_SENDING_NOTIFICATION = `UPDATE notification SET state=? WHERE state=?`
+	DELETE_NOTIFICATION        = `DELETE FROM notification WHERE state IN (?, ?) AND update_time<?`
+	GET_NOTIFICATION_NUM       = `SELECT COUNT(*) FROM notification`
+
+	// escalation policy
+	CREATE_ESCALATION_POLICY   = `INSERT OR IGNORE INTO escalation_policy(cluster, name, level, alert, enable, tiers) VALUES(?, ?, ?, ?, ?, ?)`
+	UPDATE_ESCALATION_POLICY   = `UPDATE escalation_policy SET level=?, alert=?, enable=?, tiers=? WHERE cluster=? AND name=?`
+	DELETE_ESCALATION_POLICY   = `DELETE FROM escalation_policy WHERE cluster=? AND name=?`
+	LIST_ESCALATION_POLICY     = `SELECT cluster, name, level, alert, enable, tiers FROM escalation_policy WHERE cluster=? ORDER BY name`
+	LIST_ALL_ESCALATION_POLICY = `SELECT cluster, name, level, alert, enable, tiers FROM escalation_policy ORDER BY cluster, name`
+
+	// on-call schedule
+	CREATE_ONCALL_SCHEDULE   = `INSERT OR IGNORE INTO oncall_schedule(cluster, name, users, start_time, rotation_hours) VALUES(?, ?, ?, ?, ?)`
+	UPDATE_ONCALL_SCHEDULE   = `UPDATE oncall_schedule SET users=?, start_time=?, rotation_hours=? WHERE cluster=? AND name=?`
+	DELETE_ONCALL_SCHEDULE   = `DELETE FROM oncall_schedule WHERE cluster=? AND name=?`
+	GET_ONCALL_SCHEDULE      = `SELECT cluster, name, users, start_time, rotation_hours FROM oncall_schedule WHERE cluster=? AND name=?`
+	LIST_ONCALL_SCHEDULE     = `SELECT cluster, name, users, start_time, rotation_hours FROM oncall_schedule WHERE cluster=? ORDER BY name`
+	LIST_ALL_ONCALL_SCHEDULE = `SELECT cluster, name, users, start_time, rotation_hours FROM oncall_schedule ORDER BY cluster, name`
 
 	// read alert
 	ADD_READ_ALERT_ID    = `INSERT OR IGNORE INTO read_alert(username, id) VALUES(?, ?)`
@@ -26,6 +26,7 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/opencurve/pigeon"
@@ -49,6 +50,9 @@ type storage struct {
 	mutex     *sync.Mutex
 	// only one person with write permission is allowed to login
 	loginedWriteUser string
+	// name->role
+	roles     map[string]Role
+	roleMutex *sync.RWMutex
 }
 
 func Init(cfg *pigeon.Configure) error {
@@ -63,26 +67,148 @@ func Init(cfg *pigeon.Configure) error {
 		return err
 	}
 	gStorage = &storage{db: db, dbMutex: &sync.RWMutex{}, session: make(map[string]sessionItem), loginedWriteUser: "",
-		loginOnce: make(map[string]string), mutex: &sync.Mutex{}}
+		loginOnce: make(map[string]string), mutex: &sync.Mutex{}, roles: make(map[string]Role),
+		roleMutex: &sync.RWMutex{}}
+	initPassWordHash(cfg)
+	initPassWordPolicy(cfg)
 
 	// init user table
 	if err = gStorage.execSQL(CREATE_USER_TABLE); err != nil {
 		return err
 	}
+	if err = gStorage.addColumn("user", "algorithm", "TEXT NOT NULL DEFAULT 'md5'"); err != nil {
+		return err
+	}
+	if err = gStorage.addColumn("user", "password_time", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err = gStorage.addColumn("user", "must_change_password", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
 	// create admin user
 	if err = createAdminUser(); err != nil {
 		return err
 	}
+	// the passwords set by older version start to age from now
+	if err = gStorage.execSQL(INIT_PASSWORD_TIME, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	// create role tables and load the roles
+	if err = gStorage.execSQL(CREATE_ROLE_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_USER_ROLE_TABLE); err != nil {
+		return err
+	}
+	if err = loadRole(); err != nil {
+		return err
+	}
+
+	// create volume grant table
+	if err = gStorage.execSQL(CREATE_VOLUME_GRANT_TABLE); err != nil {
+		return err
+	}
+
+	// create the tables of two-factor authentication
+	if err = gStorage.execSQL(CREATE_USER_TOTP_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_RECOVERY_CODE_TABLE); err != nil {
+		return err
+	}
+
+	// create user lockout table
+	if err = gStorage.execSQL(CREATE_USER_LOCKOUT_TABLE); err != nil {
+		return err
+	}
+
+	// create password history table
+	if err = gStorage.execSQL(CREATE_PASSWORD_HISTORY_TABLE); err != nil {
+		return err
+	}
+
+	// create password reset table
+	if err = gStorage.execSQL(CREATE_PASSWORD_RESET_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.addColumn("password_reset", "create_time", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// create session table and restore the sessions before restart
+	if err = gStorage.execSQL(CREATE_SESSION_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.addColumn("session", "sign_secret", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err = loadSession(); err != nil {
+		return err
+	}
+
+	// create api key table
+	if err = gStorage.execSQL(CREATE_API_KEY_TABLE); err != nil {
+		return err
+	}
+
+	// create webhook tables
+	if err = gStorage.execSQL(CREATE_WEBHOOK_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.addColumn("webhook", "type", "TEXT NOT NULL DEFAULT 'generic'"); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_ALERT_WEBHOOK_TABLE); err != nil {
+		return err
+	}
+
+	// create alert silence table
+	if err = gStorage.execSQL(CREATE_ALERT_SILENCE_TABLE); err != nil {
+		return err
+	}
+
+	// create alert escalation policy and on-call schedule table
+	if err = gStorage.execSQL(CREATE_ESCALATION_POLICY_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_ONCALL_SCHEDULE_TABLE); err != nil {
+		return err
+	}
+
+	// create notification outbox table
+	if err = gStorage.execSQL(CREATE_NOTIFICATION_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_NOTIFICATION_INDEX); err != nil {
+		return err
+	}
 
 	// create system operation log table
 	if err = gStorage.execSQL(CREATE_SYSTEM_LOG_TABLE); err != nil {
 		return err
 	}
+	if err = gStorage.addColumn("system_log", "api_key", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
 
 	// create system alert conf table
 	if err = gStorage.execSQL(CREATE_ALERT_CONF_TABLE); err != nil {
 		return err
 	}
+	for _, column := range [][2]string{
+		{"custom", "INTEGER NOT NULL DEFAULT 0"},
+		{"creator", "TEXT NOT NULL DEFAULT ''"},
+		{"expr", "TEXT NOT NULL DEFAULT ''"},
+		{"op", "TEXT NOT NULL DEFAULT ''"},
+		{"threshold", "REAL NOT NULL DEFAULT 0"},
+		{"for_sec", "INTEGER NOT NULL DEFAULT 0"},
+		{"summary", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if err = gStorage.addColumn("alert_conf", column[0], column[1]); err != nil {
+			return err
+		}
+	}
 
 	// create alert user table
 	if err = gStorage.execSQL(CREATE_ALERT_USER_TABLE); err != nil {
@@ -93,6 +219,33 @@ func Init(cfg *pigeon.Configure) error {
 	if err = gStorage.execSQL(CREATE_ALERT_TABLE); err != nil {
 		return err
 	}
+	for _, column := range [][2]string{
+		{"fingerprint", "TEXT NOT NULL DEFAULT ''"},
+		{"target", "TEXT NOT NULL DEFAULT ''"},
+		{"state", "TEXT NOT NULL DEFAULT 'resolved'"},
+		{"end_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"notify_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"ack_user", "TEXT NOT NULL DEFAULT ''"},
+		{"ack_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"assignee", "TEXT NOT NULL DEFAULT ''"},
+		{"fire_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"escalation", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err = gStorage.addColumn("alert", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+	if err = gStorage.execSQL(CREATE_ALERT_INDEX); err != nil {
+		return err
+	}
+
+	// create alert note table
+	if err = gStorage.execSQL(CREATE_ALERT_NOTE_TABLE); err != nil {
+		return err
+	}
+	if err = gStorage.execSQL(CREATE_ALERT_NOTE_INDEX); err != nil {
+		return err
+	}
 
 	// create user system alert table
 	if err = gStorage.execSQL(CREATE_READ_ALERT_TABLE); err != nil {
@@ -117,6 +270,48 @@ func (s *storage) execSQL(query string, args ...interface{}) error {
 	return err
 }
 
+// addColumn add the column to table if not exist, used to upgrade tables created by older version
+func (s *storage) addColumn(table, column, definition string) error {
+	rows, err := s.querySQL(GET_TABLE_COLUMN, table, column)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	count := 0
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			return err
+		}
+	}
+	rows.Close()
+	if count > 0 {
+		return nil
+	}
+	return s.execSQL(fmt.Sprintf(ADD_COLUMN, table, column, definition))
+}
+
+// execSQLAffected returns the number of rows affected, used to update conditionally
+func (s *storage) execSQLAffected(query string, args ...interface{}) (int64, error) {
+	s.dbMutex.Lock()
+	defer s.dbMutex.Unlock()
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// execSQLInsert returns the id of the inserted row
+func (s *storage) execSQLInsert(query string, args ...interface{}) (int64, error) {
+	s.dbMutex.Lock()
+	defer s.dbMutex.Unlock()
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
 func (s *storage) querySQL(query string, args ...interface{}) (*sql.Rows, error) {
 	s.dbMutex.RLock()
 	defer s.dbMutex.RUnlock()
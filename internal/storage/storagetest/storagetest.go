@@ -0,0 +1,43 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+// Package storagetest provides the storage fixture of tests
+package storagetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/opencurve/curve-manager/internal/storage"
+	"github.com/opencurve/pigeon"
+)
+
+// Init initialize storage with an empty database in the temporary dir of t
+func Init(t *testing.T) {
+	t.Helper()
+	cfg := &pigeon.Configure{Config: map[string]interface{}{
+		storage.SQLITE_DB_FILE: filepath.Join(t.TempDir(), "curve-manager.db"),
+	}}
+	if err := storage.Init(cfg); err != nil {
+		t.Fatalf("init storage failed: %v", err)
+	}
+}
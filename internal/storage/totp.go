@@ -0,0 +1,112 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// UserTOTP is the second factor of user, not enabled until the first code verified
+type UserTOTP struct {
+	UserName string
+	Secret   string
+	Enabled  bool
+	// the last step used to login, codes of the same or earlier step are rejected
+	LastStep int64
+}
+
+// GetUserTOTP returns sql.ErrNoRows if the user not enrolled
+func GetUserTOTP(name string) (UserTOTP, error) {
+	var totp UserTOTP
+	rows, err := gStorage.querySQL(GET_USER_TOTP, name)
+	if err != nil {
+		return totp, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return totp, sql.ErrNoRows
+	}
+	err = rows.Scan(&totp.UserName, &totp.Secret, &totp.Enabled, &totp.LastStep)
+	return totp, err
+}
+
+func IsTOTPEnabled(name string) (bool, error) {
+	totp, err := GetUserTOTP(name)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return totp.Enabled, err
+}
+
+// SetUserTOTPSecret start an enrollment, which replaces the unfinished one
+func SetUserTOTPSecret(name, secret string) error {
+	return gStorage.execSQL(SET_USER_TOTP_SECRET, name, secret, time.Now().Unix())
+}
+
+// EnableUserTOTP finish the enrollment by the first verified step
+func EnableUserTOTP(name string, step int64) error {
+	return gStorage.execSQL(ENABLE_USER_TOTP, step, name)
+}
+
+func DeleteUserTOTP(name string) error {
+	err := gStorage.execSQL(DELETE_USER_TOTP, name)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_RECOVERY_CODE_OF_USER, name)
+}
+
+// UseTOTPStep mark step used, returns false if the step or a later one already used
+func UseTOTPStep(name string, step int64) (bool, error) {
+	n, err := gStorage.execSQLAffected(USE_TOTP_STEP, step, name, step)
+	return n == 1, err
+}
+
+// normalizeRecoveryCode ignores the separators and case, so the code is hashed
+// the same way whether it is stored or typed by user
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// SetRecoveryCode replace the recovery codes of user, only the hashes are stored
+func SetRecoveryCode(name string, codes []string) error {
+	err := gStorage.execSQL(DELETE_RECOVERY_CODE_OF_USER, name)
+	if err != nil {
+		return err
+	}
+	for _, code := range codes {
+		err = gStorage.execSQL(ADD_RECOVERY_CODE, name, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode consume the code, every code can only be used once
+func UseRecoveryCode(name, code string) (bool, error) {
+	n, err := gStorage.execSQLAffected(DELETE_RECOVERY_CODE, name, hashToken(normalizeRecoveryCode(code)))
+	return n == 1, err
+}
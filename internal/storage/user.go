@@ -24,6 +24,7 @@ package storage
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/opencurve/curve-manager/internal/common"
 )
@@ -32,10 +33,10 @@ const (
 	USER_ADMIN_NAME     = "admin"
 	USER_ADMIN_PASSWORD = "curve"
 
-	ADMIN_PERM = 7
-	WRITE_PERM = 2
-
-	NEW_PASSWORD_LENGTH = 8
+	ADMIN_PERM   = 7
+	READ_PERM    = 4
+	WRITE_PERM   = 2
+	MANAGER_PERM = 1
 )
 
 type UserInfo struct {
@@ -44,11 +45,23 @@ type UserInfo struct {
 	Email      string `json:"email"`
 	Permission int    `json:"permission" binding:"required"`
 	Token      string `json:"token,omitempty" binding:"required"`
+	Algorithm  string `json:"-"`
+	// roles assigned besides the legacy permission
+	Roles []string `json:"roles,omitempty"`
+	// unix seconds when the password was set
+	PassWordTime int64 `json:"-"`
+	// the user can only change password before other operations
+	MustChangePassWord bool `json:"mustChangePassword,omitempty"`
+	// the key of hmac-sha256 request signature, only returned by login
+	SignSecret string `json:"signSecret,omitempty"`
 }
 
 func createAdminUser() error {
-	passwd := common.GetMd5Sum32Little(USER_ADMIN_PASSWORD)
-	return gStorage.execSQL(CREATE_ADMIN, USER_ADMIN_NAME, passwd, "", ADMIN_PERM)
+	passwd, algorithm, err := hashPassWord(common.GetMd5Sum32Little(USER_ADMIN_PASSWORD))
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(CREATE_ADMIN, USER_ADMIN_NAME, passwd, "", ADMIN_PERM, algorithm, time.Now().Unix())
 }
 
 func GetUser(name string) (UserInfo, error) {
@@ -59,7 +72,8 @@ func GetUser(name string) (UserInfo, error) {
 	}
 	defer rows.Close()
 	if rows.Next() {
-		err = rows.Scan(&user.UserName, &user.PassWord, &user.Email, &user.Permission)
+		err = rows.Scan(&user.UserName, &user.PassWord, &user.Email, &user.Permission, &user.Algorithm,
+			&user.PassWordTime, &user.MustChangePassWord)
 		if err != nil {
 			return user, err
 		}
@@ -70,15 +84,91 @@ func GetUser(name string) (UserInfo, error) {
 }
 
 func CreateUser(name, passwd, email string, permission int) error {
-	return gStorage.execSQL(CREATE_USER, name, passwd, email, permission)
+	hash, algorithm, err := hashPassWord(passwd)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	err = gStorage.execSQL(CREATE_USER, name, hash, email, permission, algorithm, now)
+	if err != nil {
+		return err
+	}
+	return addPassWordHistory(name, hash, algorithm, now)
+}
+
+// SyncExternalUser create or refresh the user authenticated by an external authenticator,
+// the local users with the same name are left untouched
+func SyncExternalUser(user *UserInfo, source string) error {
+	err := gStorage.execSQL(ADD_EXTERNAL_USER, user.UserName, user.Email, user.Permission, source)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(UPDATE_EXTERNAL_USER, user.Email, user.Permission, user.UserName, source)
 }
 
 func DeleteUser(name string) error {
-	return gStorage.execSQL(DELETE_USER, name)
+	err := gStorage.execSQL(DELETE_USER, name)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(DELETE_USER_ROLE, name)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(DELETE_VOLUME_GRANT_OF_USER, name)
+	if err != nil {
+		return err
+	}
+	err = DeleteUserTOTP(name)
+	if err != nil {
+		return err
+	}
+	err = DeleteUserLockout(name)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(DELETE_PASSWORD_HISTORY_OF_USER, name)
+	if err != nil {
+		return err
+	}
+	err = gStorage.execSQL(DELETE_PASSWORD_RESET_OF_USER, name)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_API_KEY_OF_USER, name)
+}
+
+// UpdateUserPassWord set a new password of user, mustChange forces the user to change
+// it again at next login, which is used when the password is generated by others
+func UpdateUserPassWord(name, passwd string, mustChange bool) error {
+	hash, algorithm, err := hashPassWord(passwd)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	err = gStorage.execSQL(UPDATE_USER_PASSWORD, hash, algorithm, now, mustChange, name)
+	if err != nil {
+		return err
+	}
+	// the outstanding reset tokens are useless after the password changed
+	err = gStorage.execSQL(DELETE_PASSWORD_RESET_OF_USER, name)
+	if err != nil {
+		return err
+	}
+	return addPassWordHistory(name, hash, algorithm, now)
 }
 
-func UpdateUserPassWord(name, passwd string) error {
-	return gStorage.execSQL(UPDATE_USER_PASSWORD, passwd, name)
+// RehashUserPassWord store the same password with the current algorithm
+func RehashUserPassWord(name, passwd string) error {
+	hash, algorithm, err := hashPassWord(passwd)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(REHASH_USER_PASSWORD, hash, algorithm, name)
+}
+
+func SetMustChangePassWord(name string) error {
+	return gStorage.execSQL(SET_MUST_CHANGE_PASSWORD, name)
 }
 
 func UpdateUserEmail(name, email string) error {
@@ -109,7 +199,8 @@ func ListUser(userName string) (*[]UserInfo, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var user UserInfo
-		err = rows.Scan(&user.UserName, &user.PassWord, &user.Email, &user.Permission)
+		err = rows.Scan(&user.UserName, &user.PassWord, &user.Email, &user.Permission, &user.Algorithm,
+			&user.PassWordTime, &user.MustChangePassWord)
 		if err != nil {
 			return nil, err
 		}
@@ -154,25 +245,3 @@ func GetUserEmail(name string) (string, error) {
 	}
 	return email, nil
 }
-
-func GetUserPassword(name string) (string, error) {
-	rows, err := gStorage.querySQL(GET_USER_PASSWORD, name)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-	var passwd string
-	if rows.Next() {
-		err = rows.Scan(&passwd)
-		if err != nil {
-			return "", err
-		}
-	} else {
-		return "", fmt.Errorf("user not exist")
-	}
-	return passwd, nil
-}
-
-func GetNewPassWord() string {
-	return common.GetRandString(NEW_PASSWORD_LENGTH)
-}
@@ -0,0 +1,145 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type Webhook struct {
+	Name string `json:"name"`
+	// generic, dingtalk, feishu, wecom or slack
+	Type string `json:"type"`
+	// the token in url of chat robots is redacted when listed
+	URL string `json:"url"`
+	// never returned, HasSecret tells whether it is set
+	Secret       string `json:"-"`
+	HasSecret    bool   `json:"hasSecret"`
+	SecretHeader string `json:"secretHeader"`
+	BodyTemplate string `json:"bodyTemplate"`
+	// 0 means the default timeout
+	TimeoutSec         int    `json:"timeout"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	CACert             string `json:"caCert"`
+}
+
+func scanWebhook(rows *sql.Rows) (Webhook, error) {
+	var w Webhook
+	var insecure int
+	err := rows.Scan(&w.Name, &w.Type, &w.URL, &w.Secret, &w.SecretHeader, &w.BodyTemplate, &w.TimeoutSec, &insecure, &w.CACert)
+	w.HasSecret = w.Secret != ""
+	w.InsecureSkipVerify = insecure == 1
+	return w, err
+}
+
+func CreateWebhook(w *Webhook) error {
+	affected, err := gStorage.execSQLAffected(CREATE_WEBHOOK, w.Name, w.Type, w.URL, w.Secret, w.SecretHeader, w.BodyTemplate,
+		w.TimeoutSec, w.InsecureSkipVerify, w.CACert)
+	if err == nil && affected == 0 {
+		err = fmt.Errorf("webhook %s already exists", w.Name)
+	}
+	return err
+}
+
+func UpdateWebhook(w *Webhook) error {
+	return gStorage.execSQL(UPDATE_WEBHOOK, w.Type, w.URL, w.Secret, w.SecretHeader, w.BodyTemplate, w.TimeoutSec,
+		w.InsecureSkipVerify, w.CACert, w.Name)
+}
+
+// DeleteWebhook remove the webhook and the alerts sent to it
+func DeleteWebhook(name string) error {
+	err := gStorage.execSQL(DELETE_ALERT_WEBHOOK_OF_WEBHOOK, name)
+	if err != nil {
+		return err
+	}
+	return gStorage.execSQL(DELETE_WEBHOOK, name)
+}
+
+// GetWebhook returns sql.ErrNoRows if not exist
+func GetWebhook(name string) (Webhook, error) {
+	rows, err := gStorage.querySQL(GET_WEBHOOK, name)
+	if err != nil {
+		return Webhook{}, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return Webhook{}, sql.ErrNoRows
+	}
+	return scanWebhook(rows)
+}
+
+func ListWebhook() ([]Webhook, error) {
+	webhooks := []Webhook{}
+	rows, err := gStorage.querySQL(LIST_WEBHOOK)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
+
+func AddAlertWebhook(clusterId int, alertName string, webhooks []string) error {
+	for _, webhook := range webhooks {
+		err := gStorage.execSQL(ADD_ALERT_WEBHOOK, clusterId, alertName, webhook)
+		if err != nil {
+			return fmt.Errorf("webhook: %s, error: %s", webhook, err)
+		}
+	}
+	return nil
+}
+
+func DeleteAlertWebhook(clusterId int, alertName string, webhooks []string) error {
+	for _, webhook := range webhooks {
+		err := gStorage.execSQL(DELETE_ALERT_WEBHOOK, clusterId, alertName, webhook)
+		if err != nil {
+			return fmt.Errorf("webhook: %s, error: %s", webhook, err)
+		}
+	}
+	return nil
+}
+
+func GetAlertWebhook(clusterId int, alertName string) ([]string, error) {
+	webhooks := []string{}
+	rows, err := gStorage.querySQL(GET_ALERT_WEBHOOK, clusterId, alertName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var webhook string
+		err = rows.Scan(&webhook)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,295 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/email"
+)
+
+/*
+* The chat robots have their own message formats and signatures:
+* dingtalk: markdown, signed by timestamp and sign in url query if secret set
+* feishu: interactive card, signed by timestamp and sign in body if secret set
+* wecom: markdown, the key in url is the credential
+* slack: blocks of incoming webhook, the url is the credential
+ */
+const (
+	TYPE_GENERIC  = "generic"
+	TYPE_DINGTALK = "dingtalk"
+	TYPE_FEISHU   = "feishu"
+	TYPE_WECOM    = "wecom"
+	TYPE_SLACK    = "slack"
+
+	LEVEL_CRITICAL = "critical"
+	STATE_RESOLVED = "resolved"
+
+	// replace the token in the url of chat robots
+	REDACTED = "***"
+)
+
+var chatFormatters = map[string]func(rc *Receiver, alert *email.Alert, now time.Time) (string, []byte, error){
+	TYPE_DINGTALK: formatDingTalk,
+	TYPE_FEISHU:   formatFeishu,
+	TYPE_WECOM:    formatWeCom,
+	TYPE_SLACK:    formatSlack,
+}
+
+func isValidType(t string) bool {
+	_, ok := chatFormatters[t]
+	return ok || t == TYPE_GENERIC || t == ""
+}
+
+// RedactURL hide the token in the url of chat robots, which is the credential of the robot:
+// the query values, e.g. access_token of dingtalk and key of wecom, and the last path segment
+// of feishu and slack. The url of generic webhook is returned as it is
+func RedactURL(t, rawURL string) string {
+	if _, ok := chatFormatters[t]; !ok {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return REDACTED
+	}
+	path := u.EscapedPath()
+	if t == TYPE_FEISHU || t == TYPE_SLACK {
+		trimmed := strings.TrimSuffix(path, "/")
+		if i := strings.LastIndex(trimmed, "/"); i >= 0 && i < len(trimmed)-1 {
+			path = trimmed[:i+1] + REDACTED
+		}
+	}
+	redacted := u.Scheme + "://" + u.Host + path
+	if u.RawQuery != "" {
+		keys := []string{}
+		for k := range u.Query() {
+			keys = append(keys, k+"="+REDACTED)
+		}
+		sort.Strings(keys)
+		redacted += "?" + strings.Join(keys, "&")
+	}
+	return redacted
+}
+
+func chatTitle(alert *email.Alert) string {
+	if alert.State == STATE_RESOLVED {
+		return fmt.Sprintf("[%s] Curve-Manager Alert: %s", strings.ToUpper(STATE_RESOLVED), alert.Name)
+	}
+	return fmt.Sprintf("[%s] Curve-Manager Alert: %s", strings.ToUpper(alert.Level), alert.Name)
+}
+
+type chatField struct {
+	name  string
+	value string
+}
+
+func chatFields(alert *email.Alert) []chatField {
+	fields := []chatField{
+		{"Level", alert.Level},
+		{"State", alert.State},
+		{"Cluster", strconv.Itoa(alert.ClusterId)},
+		{"Alert", alert.Name},
+	}
+	if alert.Target != "" {
+		fields = append(fields, chatField{"Target", alert.Target})
+	}
+	fields = append(fields,
+		chatField{"Duration", (time.Duration(alert.DurationSec) * time.Second).String()},
+		chatField{"Time", alert.Time})
+	if alert.EndTime != "" {
+		fields = append(fields, chatField{"End Time", alert.EndTime})
+	}
+	return fields
+}
+
+// chatMarkdown returns the common markdown of dingtalk, feishu and wecom,
+// level is rendered by the function as the color differs
+func chatMarkdown(alert *email.Alert, level func(string) string) string {
+	lines := []string{}
+	for _, f := range chatFields(alert) {
+		if f.name == "Level" {
+			f.value = level(f.value)
+		}
+		lines = append(lines, fmt.Sprintf("**%s**: %s", f.name, f.value))
+	}
+	lines = append(lines, fmt.Sprintf("**Summary**: %s", alert.Summary))
+	return strings.Join(lines, "\n\n")
+}
+
+func hmacBase64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func formatDingTalk(rc *Receiver, alert *email.Alert, now time.Time) (string, []byte, error) {
+	target := rc.URL
+	if rc.Secret != "" {
+		timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+		sign := hmacBase64(rc.Secret, timestamp+"\n"+rc.Secret)
+		u, err := url.Parse(rc.URL)
+		if err != nil {
+			return "", nil, err
+		}
+		query := u.Query()
+		query.Set("timestamp", timestamp)
+		query.Set("sign", sign)
+		u.RawQuery = query.Encode()
+		target = u.String()
+	}
+	color := func(level string) string {
+		if alert.State == STATE_RESOLVED {
+			return fmt.Sprintf("<font color=\"#008000\">%s</font>", level)
+		} else if level == LEVEL_CRITICAL {
+			return fmt.Sprintf("<font color=\"#FF0000\">%s</font>", level)
+		}
+		return fmt.Sprintf("<font color=\"#FF9900\">%s</font>", level)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": chatTitle(alert),
+			"text":  "### " + chatTitle(alert) + "\n\n" + chatMarkdown(alert, color),
+		},
+	})
+	return target, body, err
+}
+
+func formatFeishu(rc *Receiver, alert *email.Alert, now time.Time) (string, []byte, error) {
+	template := "orange"
+	if alert.State == STATE_RESOLVED {
+		template = "green"
+	} else if alert.Level == LEVEL_CRITICAL {
+		template = "red"
+	}
+	message := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": chatTitle(alert)},
+				"template": template,
+			},
+			"elements": []interface{}{
+				map[string]interface{}{
+					"tag": "div",
+					"text": map[string]string{
+						"tag":     "lark_md",
+						"content": chatMarkdown(alert, func(level string) string { return level }),
+					},
+				},
+			},
+		},
+	}
+	if rc.Secret != "" {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		message["timestamp"] = timestamp
+		message["sign"] = hmacBase64(timestamp+"\n"+rc.Secret, "")
+	}
+	body, err := json.Marshal(message)
+	return rc.URL, body, err
+}
+
+func formatWeCom(rc *Receiver, alert *email.Alert, now time.Time) (string, []byte, error) {
+	color := func(level string) string {
+		if alert.State == STATE_RESOLVED {
+			return fmt.Sprintf("<font color=\"info\">%s</font>", level)
+		} else if level == LEVEL_CRITICAL {
+			return fmt.Sprintf("<font color=\"warning\">%s</font>", level)
+		}
+		return fmt.Sprintf("<font color=\"comment\">%s</font>", level)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": "### " + chatTitle(alert) + "\n" + strings.ReplaceAll(chatMarkdown(alert, color), "\n\n", "\n"),
+		},
+	})
+	return rc.URL, body, err
+}
+
+func formatSlack(rc *Receiver, alert *email.Alert, now time.Time) (string, []byte, error) {
+	fields := []interface{}{}
+	for _, f := range chatFields(alert) {
+		fields = append(fields, map[string]string{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%s*\n%s", f.name, f.value),
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		// shown in the notifications of slack
+		"text": chatTitle(alert) + ": " + alert.Summary,
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "header",
+				"text": map[string]string{"type": "plain_text", "text": chatTitle(alert)},
+			},
+			map[string]interface{}{
+				"type":   "section",
+				"fields": fields,
+			},
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": "*Summary*\n" + alert.Summary},
+			},
+		},
+	})
+	return rc.URL, body, err
+}
+
+// checkChatResponse returns the error in the 2xx response of chat robots
+func checkChatResponse(t string, body []byte) error {
+	switch t {
+	case TYPE_DINGTALK, TYPE_WECOM:
+		resp := struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("invalid response: %s", body)
+		}
+		if resp.ErrCode != 0 {
+			return fmt.Errorf("errcode: %d, errmsg: %s", resp.ErrCode, resp.ErrMsg)
+		}
+	case TYPE_FEISHU:
+		resp := struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("invalid response: %s", body)
+		}
+		if resp.Code != 0 {
+			return fmt.Errorf("code: %d, msg: %s", resp.Code, resp.Msg)
+		}
+	}
+	return nil
+}
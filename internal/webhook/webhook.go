@@ -0,0 +1,195 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/opencurve/curve-manager/internal/email"
+)
+
+const (
+	// the hex hmac-sha256 of timestamp + "." + body
+	DEFAULT_SECRET_HEADER = "X-Curve-Manager-Signature"
+	TIMESTAMP_HEADER      = "X-Curve-Manager-Timestamp"
+
+	DEFAULT_TIMEOUT = 10 * time.Second
+	MAX_TIMEOUT     = 60 * time.Second
+
+	// the response body kept in error
+	MAX_ERROR_BODY_LENGTH = 256
+)
+
+type Receiver struct {
+	// generic or one of the chat robots, generic if empty
+	Type         string
+	URL          string
+	Secret       string
+	SecretHeader string
+	// text/template which renders the json body of generic, the json of data if empty
+	BodyTemplate       string
+	Timeout            time.Duration
+	InsecureSkipVerify bool
+	// pem encoded certificates to verify the server, the system pool if empty
+	CACert string
+}
+
+var funcs = template.FuncMap{
+	// quote the value as json, e.g. {"text": {{json .Summary}}}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func parseTemplate(body string) (*template.Template, error) {
+	return template.New("body").Funcs(funcs).Parse(body)
+}
+
+// Check returns error if the receiver can not be used
+func (rc *Receiver) Check() error {
+	if !isValidType(rc.Type) {
+		return fmt.Errorf("unsupported webhook type: %s", rc.Type)
+	}
+	u, err := url.Parse(rc.URL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url: %s", rc.URL)
+	}
+	if rc.Timeout < 0 || rc.Timeout > MAX_TIMEOUT {
+		return fmt.Errorf("webhook timeout should be in [0, %s]", MAX_TIMEOUT)
+	}
+	if rc.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(rc.CACert)) {
+		return fmt.Errorf("invalid webhook ca cert")
+	}
+	if rc.BodyTemplate != "" {
+		_, err = parseTemplate(rc.BodyTemplate)
+	}
+	return err
+}
+
+// Render returns the json body of data
+func (rc *Receiver) Render(data interface{}) ([]byte, error) {
+	if rc.BodyTemplate == "" {
+		return json.Marshal(data)
+	}
+	t, err := parseTemplate(rc.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err = t.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("webhook body template renders invalid json")
+	}
+	return body.Bytes(), nil
+}
+
+func (rc *Receiver) client() *resty.Client {
+	timeout := rc.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: rc.InsecureSkipVerify}
+	if rc.CACert != "" {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(rc.CACert))
+		tlsConfig.RootCAs = pool
+	}
+	return resty.New().SetTimeout(timeout).SetTLSClientConfig(tlsConfig)
+}
+
+// Sign returns the signature of body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send render the alert and post it to the receiver, any response other than 2xx
+// or an error reported by the chat robot is an error
+func (rc *Receiver) Send(alert *email.Alert) error {
+	target := rc.URL
+	var body []byte
+	var err error
+	format, isChat := chatFormatters[rc.Type]
+	if isChat {
+		target, body, err = format(rc, alert, time.Now())
+	} else {
+		body, err = rc.Render(alert)
+	}
+	if err != nil {
+		return err
+	}
+	req := rc.client().R().
+		SetHeader("Content-Type", "application/json").
+		SetHeader("User-Agent", "Curve-Manager").
+		SetBody(body)
+	if rc.Secret != "" && !isChat {
+		header := rc.SecretHeader
+		if header == "" {
+			header = DEFAULT_SECRET_HEADER
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.SetHeader(TIMESTAMP_HEADER, timestamp)
+		req.SetHeader(header, Sign(rc.Secret, timestamp, body))
+	}
+	resp, err := req.Post(target)
+	if err != nil {
+		// the url of chat robots carries the token, which is re-encoded with the signature
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = RedactURL(rc.Type, urlErr.URL)
+		}
+		return err
+	}
+	if !resp.IsSuccess() {
+		respBody := resp.String()
+		if len(respBody) > MAX_ERROR_BODY_LENGTH {
+			respBody = respBody[:MAX_ERROR_BODY_LENGTH]
+		}
+		return fmt.Errorf("webhook responds %s: %s", resp.Status(), respBody)
+	}
+	if isChat {
+		return checkChatResponse(rc.Type, resp.Body())
+	}
+	return nil
+}
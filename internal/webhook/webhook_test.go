@@ -0,0 +1,49 @@
+/*
+*  Copyright (c) 2023 NetEase Inc.
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+ */
+
+/*
+* Project: Curve-Manager
+* Created Date: 2026-10-16
+* Author: wanghai (SeanHai)
+ */
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencurve/curve-manager/internal/email"
+)
+
+func TestSendErrorRedactsToken(t *testing.T) {
+	for _, secret := range []string{"", "SEC-secret"} {
+		rc := &Receiver{
+			Type:    TYPE_DINGTALK,
+			URL:     "http://127.0.0.1:1/robot/send?access_token=robot-token",
+			Secret:  secret,
+			Timeout: time.Second,
+		}
+		err := rc.Send(&email.Alert{Name: "test"})
+		if err == nil {
+			t.Fatal("send to a closed port should fail")
+		}
+		if strings.Contains(err.Error(), "robot-token") {
+			t.Fatalf("token is not redacted: %v", err)
+		}
+	}
+}